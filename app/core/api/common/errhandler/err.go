@@ -35,6 +35,12 @@ var (
 	RespFailedToValidateDefinitions = []byte(`{"error": "failed to validate definitions"}`)
 	RespEntityNameAlreadyExists     = []byte(`{"error": "entity name already exists"}`)
 	RespEntityIDAlreadyExists       = []byte(`{"error": "entity id already exists"}`)
+
+	RespEntityVersionConflict = []byte(`{"error": "entity was modified by another request"}`)
+	RespIfMatchRequired       = []byte(`{"error": "If-Match header with the entity version is required"}`)
+	RespInvalidIfMatch        = []byte(`{"error": "invalid If-Match header"}`)
+
+	RespNotFound = []byte(`{"error": "not found"}`)
 )
 
 type Error struct {
@@ -60,6 +66,36 @@ func ValidationErrors(w http.ResponseWriter, resp []byte) {
 	_, _ = w.Write(resp)
 }
 
+func NotFound(w http.ResponseWriter, resp []byte) {
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = w.Write(resp)
+}
+
+func Conflict(w http.ResponseWriter, resp []byte) {
+	w.WriteHeader(http.StatusConflict)
+	_, _ = w.Write(resp)
+}
+
+func UnprocessableEntity(w http.ResponseWriter, resp []byte) {
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	_, _ = w.Write(resp)
+}
+
+func Locked(w http.ResponseWriter, resp []byte) {
+	w.WriteHeader(http.StatusLocked)
+	_, _ = w.Write(resp)
+}
+
+func PreconditionFailed(w http.ResponseWriter, resp []byte) {
+	w.WriteHeader(http.StatusPreconditionFailed)
+	_, _ = w.Write(resp)
+}
+
+func PreconditionRequired(w http.ResponseWriter, resp []byte) {
+	w.WriteHeader(http.StatusPreconditionRequired)
+	_, _ = w.Write(resp)
+}
+
 func Unauthorized(w http.ResponseWriter, resp []byte) {
 	w.WriteHeader(http.StatusBadRequest)
 	_, _ = w.Write(resp)
@@ -9,6 +9,12 @@ import (
 
 func (am *AuthMiddleware) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// SessionMiddleware already validated the session
+		if ctxUtil.GetSession(r.Context()) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		conf := env.New()
 
 		sessionID, err := r.Cookie(conf.Session.SessionCookieName)
@@ -32,3 +38,45 @@ func (am *AuthMiddleware) AuthMiddleware(next http.Handler) http.Handler {
 		return
 	})
 }
+
+// SessionMiddleware stores the session of a valid session cookie in the context like AuthMiddleware,
+// requests without a valid session continue without one
+func (am *AuthMiddleware) SessionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conf := env.New()
+
+		sessionID, err := r.Cookie(conf.Session.SessionCookieName)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sessionDetails, err := am.session.ValidateSession(am.ctx, sessionID.Value)
+		if err != nil {
+			am.logger.Debug().Err(err).Msg("Invalid session, continuing without one")
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := ctxUtil.SetSession(r.Context(), sessionDetails)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AdminMiddleware only lets administrators through, it runs after AuthMiddleware
+func (am *AuthMiddleware) AdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := am.queries.GetUserByID(r.Context(), ctxUtil.GetUserID(r.Context()))
+		if err != nil {
+			am.logger.Debug().Err(err).Msg("Failed to load the user of the session")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !user.IsAdmin {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+)
+
+// ClientIP stores the address of the client in the context. Behind a proxy the remote address is
+// the proxy, chi's RealIP in front of this middleware takes the client from the forwarded headers.
+func ClientIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientIP := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			clientIP = host
+		}
+
+		ctx := ctxUtil.SetClientIP(r.Context(), clientIP)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -15,6 +15,10 @@ func (c *Controller) RegisterUses() {
 
 	c.Router.Use(middleware.Logger)
 	c.Router.Use(internalMiddleware.RequestID)
+	if c.Conf.Server.TrustProxy {
+		c.Router.Use(middleware.RealIP)
+	}
+	c.Router.Use(internalMiddleware.ClientIP)
 	c.Router.Use(internalMiddleware.JSONMiddleware)
 
 	// CORS Origins
@@ -29,7 +33,7 @@ func (c *Controller) RegisterUses() {
 	// CORS Methods
 	var allowedMethods []string
 	allowedMethods = []string{
-		"GET", "POST", "PUT", "DELETE", "OPTIONS",
+		"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS",
 	}
 	if len(c.Conf.Server.CORSMethods) != 0 {
 		allowedMethods = c.Conf.Server.CORSMethods
@@ -38,7 +42,7 @@ func (c *Controller) RegisterUses() {
 	// CORS Headers
 	var allowedHeaders []string
 	allowedHeaders = []string{
-		"Accept", "Authorization", "Content-Type", "X-CSRF-Token",
+		"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "If-Match",
 	}
 	if len(c.Conf.Server.CORSHeaders) != 0 {
 		allowedHeaders = c.Conf.Server.CORSHeaders
@@ -47,7 +51,7 @@ func (c *Controller) RegisterUses() {
 	// CORS Exposed Headers
 	var exposedHeaders []string
 	exposedHeaders = []string{
-		"Link",
+		"Link", "ETag",
 	}
 	if len(c.Conf.Server.CORSExposedHeaders) != 0 {
 		exposedHeaders = c.Conf.Server.CORSExposedHeaders
@@ -7,10 +7,17 @@ import (
 	"github.com/oriiyx/fritz/app/core/api/base"
 	"github.com/oriiyx/fritz/app/core/api/middleware"
 	"github.com/oriiyx/fritz/app/core/api/middleware/requestlog"
+	"github.com/oriiyx/fritz/app/core/services"
+	"github.com/oriiyx/fritz/app/core/services/audit"
 	"github.com/oriiyx/fritz/app/core/services/auth"
 	defHandler "github.com/oriiyx/fritz/app/core/services/definitions"
 	"github.com/oriiyx/fritz/app/core/services/entities"
+	"github.com/oriiyx/fritz/app/core/services/entities/locks"
+	"github.com/oriiyx/fritz/app/core/services/entities/trash"
 	"github.com/oriiyx/fritz/app/core/services/entities/tree"
+	"github.com/oriiyx/fritz/app/core/services/imports"
+	"github.com/oriiyx/fritz/app/core/services/imports/pipeline"
+	"github.com/oriiyx/fritz/app/core/services/webhooks"
 )
 
 func (c *Controller) RegisterRoutes() {
@@ -19,6 +26,9 @@ func (c *Controller) RegisterRoutes() {
 	am := middleware.NewAuthMiddleware(c.Logger, c.Queries, c.Conf, c.Ctx)
 
 	c.Router.Route("/api/v1", func(r chi.Router) {
+		// The acting user of every request with a session, routes that need one add AuthMiddleware
+		r.Use(am.SessionMiddleware)
+
 		r.Method(http.MethodPost, "/auth/login", requestlog.NewHandler(authHandler.Login, c.Logger))
 		r.Method(http.MethodPost, "/auth/register", requestlog.NewHandler(authHandler.Register, c.Logger))
 
@@ -31,6 +41,7 @@ func (c *Controller) RegisterRoutes() {
 		r.Route("/definitions", func(definitions chi.Router) {
 			definitions.Method(http.MethodGet, "/", requestlog.NewHandler(definitionsHandler.GetExisting, c.Logger))
 			definitions.Method(http.MethodGet, "/data-component-types", requestlog.NewHandler(definitionsHandler.GetDataComponentTypes, c.Logger))
+			definitions.Method(http.MethodGet, "/status", requestlog.NewHandler(definitionsHandler.Status, c.Logger))
 			definitions.Method(http.MethodPost, "/create", requestlog.NewHandler(definitionsHandler.Create, c.Logger))
 			definitions.Method(http.MethodPut, "/{id}/update", requestlog.NewHandler(definitionsHandler.Update, c.Logger))
 			definitions.Method(http.MethodDelete, "/{id}/delete", requestlog.NewHandler(definitionsHandler.Delete, c.Logger))
@@ -39,22 +50,103 @@ func (c *Controller) RegisterRoutes() {
 
 		entitiesHandler := entities.New(handlerFactory.Create("entities"))
 		treeHandler := tree.New(handlerFactory.Create("tree"))
+		locksHandler := locks.New(handlerFactory.Create("locks"))
 		r.Route("/entities", func(entities chi.Router) {
 			entities.Method(http.MethodPost, "/", requestlog.NewHandler(entitiesHandler.GetEntityData, c.Logger))
+			entities.Method(http.MethodPost, "/batch", requestlog.NewHandler(entitiesHandler.BatchEntities, c.Logger))
+			entities.Method(http.MethodGet, "/search", requestlog.NewHandler(entitiesHandler.SearchEntities, c.Logger))
+			entities.Method(http.MethodGet, "/by-path", requestlog.NewHandler(entitiesHandler.GetEntityByPath, c.Logger))
+			entities.Method(http.MethodGet, "/{definition_id}", requestlog.NewHandler(entitiesHandler.ListEntities, c.Logger))
+			entities.Method(http.MethodGet, "/{entity_id}/breadcrumb", requestlog.NewHandler(entitiesHandler.GetEntityBreadcrumb, c.Logger))
+			entities.Method(http.MethodGet, "/{definition_id}/export", requestlog.NewHandler(entitiesHandler.ExportEntities, c.Logger))
 			entities.Method(http.MethodPost, "/{definition_id}/read", requestlog.NewHandler(entitiesHandler.ReadEntity, c.Logger))
 			entities.Method(http.MethodPost, "/{definition_id}/create", requestlog.NewHandler(entitiesHandler.CreateEntity, c.Logger))
 			entities.Method(http.MethodPost, "/{definition_id}/{entity_id}/transition", requestlog.NewHandler(entitiesHandler.TransitionEntity, c.Logger))
+			entities.Method(http.MethodPatch, "/{definition_id}/{entity_id}", requestlog.NewHandler(entitiesHandler.PatchEntity, c.Logger))
+			entities.Method(http.MethodPost, "/{definition_id}/{entity_id}/copy", requestlog.NewHandler(entitiesHandler.CopyEntity, c.Logger))
+			entities.Method(http.MethodPost, "/{definition_id}/{entity_id}/move", requestlog.NewHandler(entitiesHandler.MoveEntity, c.Logger))
+			entities.Method(http.MethodPost, "/{definition_id}/{entity_id}/rename", requestlog.NewHandler(entitiesHandler.RenameEntity, c.Logger))
+			entities.Method(http.MethodGet, "/{definition_id}/{entity_id}/versions", requestlog.NewHandler(entitiesHandler.ListVersions, c.Logger))
+			entities.Method(http.MethodGet, "/{definition_id}/{entity_id}/versions/diff", requestlog.NewHandler(entitiesHandler.DiffVersions, c.Logger))
+			entities.Method(http.MethodGet, "/{definition_id}/{entity_id}/versions/{version}", requestlog.NewHandler(entitiesHandler.GetVersion, c.Logger))
+			entities.Method(http.MethodPost, "/{definition_id}/{entity_id}/versions/{version}/restore", requestlog.NewHandler(entitiesHandler.RestoreVersion, c.Logger))
+			entities.Method(http.MethodGet, "/{definition_id}/{entity_id}/draft", requestlog.NewHandler(entitiesHandler.GetDraft, c.Logger))
+			entities.Method(http.MethodPut, "/{definition_id}/{entity_id}/draft", requestlog.NewHandler(entitiesHandler.SaveDraft, c.Logger))
+			entities.Method(http.MethodDelete, "/{definition_id}/{entity_id}/draft", requestlog.NewHandler(entitiesHandler.DiscardDraft, c.Logger))
+			entities.Method(http.MethodPost, "/{definition_id}/{entity_id}/publish", requestlog.NewHandler(entitiesHandler.PublishEntity, c.Logger))
+			entities.Method(http.MethodPost, "/{definition_id}/{entity_id}/unpublish", requestlog.NewHandler(entitiesHandler.UnpublishEntity, c.Logger))
+			entities.Method(http.MethodGet, "/{definition_id}/{entity_id}/schedules", requestlog.NewHandler(entitiesHandler.ListSchedules, c.Logger))
+			entities.Method(http.MethodPost, "/{definition_id}/{entity_id}/schedules", requestlog.NewHandler(entitiesHandler.CreateSchedule, c.Logger))
+			entities.Method(http.MethodDelete, "/{definition_id}/{entity_id}/schedules/{schedule_id}", requestlog.NewHandler(entitiesHandler.CancelSchedule, c.Logger))
 			entities.Method(http.MethodPost, "/{definition_id}/save", requestlog.NewHandler(entitiesHandler.SaveEntity, c.Logger))
 			entities.Method(http.MethodPost, "/{definition_id}/delete", requestlog.NewHandler(entitiesHandler.DeleteEntity, c.Logger))
 
+			entities.Route("/{entity_id}/lock", func(lock chi.Router) {
+				lock.Method(http.MethodGet, "/", requestlog.NewHandler(locksHandler.Get, c.Logger))
+				lock.Group(func(held chi.Router) {
+					held.Use(am.AuthMiddleware)
+					held.Method(http.MethodPost, "/", requestlog.NewHandler(locksHandler.Acquire, c.Logger))
+					held.Method(http.MethodPut, "/", requestlog.NewHandler(locksHandler.Renew, c.Logger))
+					held.Method(http.MethodDelete, "/", requestlog.NewHandler(locksHandler.Release, c.Logger))
+				})
+			})
+
+			entities.Route("/folders", func(folders chi.Router) {
+				folders.Method(http.MethodPost, "/", requestlog.NewHandler(entitiesHandler.CreateFolder, c.Logger))
+				folders.Method(http.MethodGet, "/{entity_id}", requestlog.NewHandler(entitiesHandler.GetFolder, c.Logger))
+				folders.Method(http.MethodPatch, "/{entity_id}", requestlog.NewHandler(entitiesHandler.UpdateFolder, c.Logger))
+				folders.Method(http.MethodDelete, "/{entity_id}", requestlog.NewHandler(entitiesHandler.DeleteFolder, c.Logger))
+				folders.Method(http.MethodPost, "/{entity_id}/rename", requestlog.NewHandler(entitiesHandler.RenameFolder, c.Logger))
+				folders.Method(http.MethodPost, "/{entity_id}/move", requestlog.NewHandler(entitiesHandler.MoveFolder, c.Logger))
+			})
+
 			entities.Route("/tree", func(tree chi.Router) {
 				tree.Method(http.MethodPost, "/children", requestlog.NewHandler(treeHandler.Children, c.Logger))
+				tree.Method(http.MethodPost, "/{entity_id}/reorder", requestlog.NewHandler(treeHandler.Reorder, c.Logger))
+				tree.Method(http.MethodGet, "/{entity_id}/descendants", requestlog.NewHandler(treeHandler.Descendants, c.Logger))
+				tree.Method(http.MethodGet, "/{entity_id}/descendants/counts", requestlog.NewHandler(treeHandler.DescendantCounts, c.Logger))
+				tree.Method(http.MethodGet, "/{entity_id}/ancestor-of/{descendant_id}", requestlog.NewHandler(treeHandler.IsAncestorOf, c.Logger))
 			})
 		})
 
+		trashHandler := trash.New(handlerFactory.Create("trash"))
+		r.Route("/trash", func(trash chi.Router) {
+			trash.Method(http.MethodGet, "/", requestlog.NewHandler(trashHandler.List, c.Logger))
+			trash.Method(http.MethodGet, "/{trash_id}", requestlog.NewHandler(trashHandler.Get, c.Logger))
+			trash.Method(http.MethodPost, "/{trash_id}/restore", requestlog.NewHandler(trashHandler.Restore, c.Logger))
+			trash.Method(http.MethodDelete, "/{trash_id}", requestlog.NewHandler(trashHandler.Purge, c.Logger))
+		})
+
+		importRuns := c.Kernel.Registry().MustGet(services.ImportRuns).(*pipeline.Background)
+		importsHandler := imports.New(handlerFactory.Create("imports"), importRuns)
+		r.Route("/imports", func(imports chi.Router) {
+			imports.Method(http.MethodGet, "/profiles", requestlog.NewHandler(importsHandler.GetProfiles, c.Logger))
+			imports.Method(http.MethodPost, "/profiles", requestlog.NewHandler(importsHandler.CreateProfile, c.Logger))
+			imports.Method(http.MethodGet, "/profiles/{profile_id}", requestlog.NewHandler(importsHandler.GetProfile, c.Logger))
+			imports.Method(http.MethodPut, "/profiles/{profile_id}", requestlog.NewHandler(importsHandler.UpdateProfile, c.Logger))
+			imports.Method(http.MethodDelete, "/profiles/{profile_id}", requestlog.NewHandler(importsHandler.DeleteProfile, c.Logger))
+			imports.Method(http.MethodPost, "/profiles/{profile_id}/runs", requestlog.NewHandler(importsHandler.StartRun, c.Logger))
+			imports.Method(http.MethodGet, "/runs", requestlog.NewHandler(importsHandler.GetRuns, c.Logger))
+			imports.Method(http.MethodGet, "/runs/{run_id}", requestlog.NewHandler(importsHandler.GetRun, c.Logger))
+		})
+
 		r.Group(func(protectedRouter chi.Router) {
 			protectedRouter.Use(am.AuthMiddleware)
 			protectedRouter.Method(http.MethodGet, "/auth/me", requestlog.NewHandler(authHandler.MeHandler, c.Logger))
+
+			auditHandler := audit.New(handlerFactory.Create("audit"))
+			protectedRouter.With(am.AdminMiddleware).Method(http.MethodGet, "/audit", requestlog.NewHandler(auditHandler.List, c.Logger))
+
+			webhooksHandler := webhooks.New(handlerFactory.Create("webhooks"))
+			protectedRouter.With(am.AdminMiddleware).Route("/webhooks", func(webhooks chi.Router) {
+				webhooks.Method(http.MethodGet, "/", requestlog.NewHandler(webhooksHandler.List, c.Logger))
+				webhooks.Method(http.MethodPost, "/", requestlog.NewHandler(webhooksHandler.Create, c.Logger))
+				webhooks.Method(http.MethodGet, "/{webhook_id}", requestlog.NewHandler(webhooksHandler.Get, c.Logger))
+				webhooks.Method(http.MethodPut, "/{webhook_id}", requestlog.NewHandler(webhooksHandler.Update, c.Logger))
+				webhooks.Method(http.MethodDelete, "/{webhook_id}", requestlog.NewHandler(webhooksHandler.Delete, c.Logger))
+				webhooks.Method(http.MethodGet, "/{webhook_id}/deliveries", requestlog.NewHandler(webhooksHandler.ListDeliveries, c.Logger))
+				webhooks.Method(http.MethodPost, "/{webhook_id}/deliveries/{delivery_id}/retry", requestlog.NewHandler(webhooksHandler.RetryDelivery, c.Logger))
+			})
 		})
 	})
 }
@@ -1,6 +1,9 @@
 package kernel
 
-import "context"
+import (
+	"context"
+	"sort"
+)
 
 // HookFunc is a callback function for hooks
 type HookFunc func(ctx context.Context, data interface{}) error
@@ -30,15 +33,20 @@ func NewHooks() *Hooks {
 	}
 }
 
-// Register adds a hook callback
+// Register adds a hook callback. Callbacks run by priority, those of equal priority in the order
+// they were registered.
 func (h *Hooks) Register(name string, priority HookPriority, fn HookFunc) {
 	entry := hookEntry{priority: priority, fn: fn}
 	h.hooks[name] = append(h.hooks[name], entry)
 
-	// Sort by priority (implement if needed)
+	entries := h.hooks[name]
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].priority < entries[j].priority
+	})
 }
 
-// Trigger executes all callbacks for a hook
+// Trigger executes all callbacks for a hook, the first callback that fails stops the others and
+// its error is returned
 func (h *Hooks) Trigger(ctx context.Context, name string, data interface{}) error {
 	entries, exists := h.hooks[name]
 	if !exists {
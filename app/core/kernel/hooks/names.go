@@ -8,6 +8,18 @@ const (
 	HookAfterEntityUpdate  = "entity.after_update"
 	HookBeforeEntityDelete = "entity.before_delete"
 	HookAfterEntityDelete  = "entity.after_delete"
+
+	HookBeforeEntityPublish   = "entity.before_publish"
+	HookAfterEntityPublish    = "entity.after_publish"
+	HookBeforeEntityUnpublish = "entity.before_unpublish"
+	HookAfterEntityUnpublish  = "entity.after_unpublish"
+)
+
+// Definition Hook Constants
+const (
+	HookAfterDefinitionCreate = "definition.after_create"
+	HookAfterDefinitionUpdate = "definition.after_update"
+	HookAfterDefinitionDelete = "definition.after_delete"
 )
 
 // Controller Hook Constants
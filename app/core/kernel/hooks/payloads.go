@@ -0,0 +1,55 @@
+package hooks
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// EntityCreatePayload is passed to the entity create hooks. Before the create Entity holds the
+// metadata about to be written without an ID, after it the created entity. A before hook may change
+// Data, the changed data is what gets validated and written.
+type EntityCreatePayload struct {
+	Class  string                 `json:"class"`
+	Entity db.Entity              `json:"entity"`
+	Data   map[string]interface{} `json:"data"`
+	UserID pgtype.UUID            `json:"user_id"`
+}
+
+// EntityUpdatePayload is passed to the entity update hooks. Before the update Entity is the current
+// entity, after it the updated one. A before hook may change Data, the changed data is what gets
+// validated and written.
+type EntityUpdatePayload struct {
+	Class  string                 `json:"class"`
+	Entity db.Entity              `json:"entity"`
+	Data   map[string]interface{} `json:"data"`
+	UserID pgtype.UUID            `json:"user_id"`
+
+	// Partial is set for a patch, Data then only holds the fields that change
+	Partial bool `json:"partial"`
+
+	// Draft is set when the data goes to the draft of a published entity instead of its data row
+	Draft bool `json:"draft"`
+}
+
+// EntityDeletePayload is passed to the entity delete hooks, Entity is the entity moved to the trash
+type EntityDeletePayload struct {
+	Class  string      `json:"class"`
+	Entity db.Entity   `json:"entity"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+// EntityPublishPayload is passed to the entity publish and unpublish hooks. Before the change Entity
+// is the current entity, after it the entity with its new publication state.
+type EntityPublishPayload struct {
+	Class  string      `json:"class"`
+	Entity db.Entity   `json:"entity"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+// DefinitionPayload is passed to the definition hooks, Definition is the definition as created,
+// updated or deleted
+type DefinitionPayload struct {
+	Definition *definitions.EntityDefinition `json:"definition"`
+	UserID     pgtype.UUID                   `json:"user_id"`
+}
@@ -0,0 +1,64 @@
+package kernel
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHooksPriority(t *testing.T) {
+	hooks := NewHooks()
+
+	var order []string
+	record := func(name string) HookFunc {
+		return func(ctx context.Context, data interface{}) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	hooks.Register("entity.before_create", PriorityLow, record("low"))
+	hooks.Register("entity.before_create", PriorityNormal, record("normal"))
+	hooks.Register("entity.before_create", PriorityHigh, record("high"))
+	hooks.Register("entity.before_create", PriorityNormal, record("normal 2"))
+
+	if err := hooks.Trigger(context.Background(), "entity.before_create", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"high", "normal", "normal 2", "low"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("expected order %v, got %v", want, order)
+	}
+}
+
+func TestHooksTriggerStopsOnError(t *testing.T) {
+	hooks := NewHooks()
+	veto := errors.New("sku is reserved")
+
+	type payload struct{ Data map[string]interface{} }
+
+	hooks.Register("entity.before_update", PriorityHigh, func(ctx context.Context, data interface{}) error {
+		data.(*payload).Data["sku"] = "TV-1"
+		return nil
+	})
+	hooks.Register("entity.before_update", PriorityNormal, func(ctx context.Context, data interface{}) error {
+		return veto
+	})
+	hooks.Register("entity.before_update", PriorityLow, func(ctx context.Context, data interface{}) error {
+		t.Error("a hook ran after a failing hook")
+		return nil
+	})
+
+	p := &payload{Data: map[string]interface{}{}}
+	if err := hooks.Trigger(context.Background(), "entity.before_update", p); !errors.Is(err, veto) {
+		t.Errorf("expected the veto, got %v", err)
+	}
+	if p.Data["sku"] != "TV-1" {
+		t.Errorf("expected the payload to carry the change of the first hook, got %v", p.Data)
+	}
+
+	if err := hooks.Trigger(context.Background(), "entity.unknown", nil); err != nil {
+		t.Errorf("expected a hook without callbacks to pass, got %v", err)
+	}
+}
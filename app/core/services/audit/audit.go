@@ -0,0 +1,238 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/versions"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+const (
+	TargetEntity     = "entity"
+	TargetDefinition = "definition"
+)
+
+const (
+	ActionCreate         = "create"
+	ActionUpdate         = "update"
+	ActionDelete         = "delete"
+	ActionMove           = "move"
+	ActionCopy           = "copy"
+	ActionReorder        = "reorder"
+	ActionPublish        = "publish"
+	ActionUnpublish      = "unpublish"
+	ActionSaveDraft      = "save_draft"
+	ActionDiscardDraft   = "discard_draft"
+	ActionRestoreVersion = "restore_version"
+	ActionRestore        = "restore"
+	ActionPurge          = "purge"
+	ActionBreakLock      = "break_lock"
+)
+
+// Change is a field an operation changed, a missing value is null
+type Change struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// State is the JSON encoded value of each field of a target at one point in time
+type State map[string]json.RawMessage
+
+// Entry is an operation on a target. The actor, request ID and client address come from the
+// context it is recorded with, ActorID stands in for the actor of work without a request.
+type Entry struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Changes    []Change
+	ActorID    pgtype.UUID
+}
+
+// EntityEntry is the entry of an operation on an entity
+func EntityEntry(action string, entityID pgtype.UUID, changes []Change) Entry {
+	return Entry{Action: action, TargetType: TargetEntity, TargetID: entityID.String(), Changes: changes}
+}
+
+// RecordEntity appends the entry of an operation that took an entity from the before to the after
+// state, a nil state is an entity that did not exist
+func RecordEntity(ctx context.Context, queries *db.Queries, action string, entityID pgtype.UUID, before, after State) error {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+	return Record(ctx, queries, EntityEntry(action, entityID, changes))
+}
+
+// Record appends the entry to the audit trail. The queries should be bound to the transaction of
+// the operation so an operation that rolls back leaves no entry.
+func Record(ctx context.Context, queries *db.Queries, entry Entry) error {
+	changes := entry.Changes
+	if changes == nil {
+		changes = []Change{}
+	}
+
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	actorID := entry.ActorID
+	if !actorID.Valid {
+		actorID = ctxUtil.GetUserID(ctx)
+	}
+
+	return queries.CreateAuditLogEntry(ctx, db.CreateAuditLogEntryParams{
+		ActorID:    actorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Changes:    encoded,
+		RequestID:  optionalText(ctxUtil.RequestID(ctx)),
+		Ip:         optionalText(ctxUtil.ClientIP(ctx)),
+	})
+}
+
+// EntityState is the state of an entity, its position and publication with the component columns
+// of its data prefixed by data. Bookkeeping such as versions and timestamps is left out, data is
+// nil for an entity without a data row.
+func EntityState(entity db.Entity, data interface{}, columns []adapters.Column) (State, error) {
+	state := State{}
+
+	metadata := map[string]interface{}{
+		"parent_id": entity.ParentID,
+		"o_key":     entity.OKey,
+		"o_path":    entity.OPath,
+		"published": entity.Published,
+	}
+	for field, value := range metadata {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		state[field] = encoded
+	}
+
+	if data == nil {
+		return state, nil
+	}
+
+	snapshot, err := versions.Snapshot(data, columns)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := DraftState(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	for field, value := range fields {
+		state[field] = value
+	}
+
+	return state, nil
+}
+
+// ReadEntityState reads the data row of an entity for its EntityState, the adapter must be bound
+// to the transaction of the write that follows
+func ReadEntityState(ctx context.Context, adapter adapters.EntityAdapter, entity db.Entity) (State, error) {
+	if !entity.HasData {
+		return EntityState(entity, nil, adapter.Columns())
+	}
+
+	data, err := adapter.Read(ctx, entity.ID)
+	if err != nil {
+		return nil, err
+	}
+	return EntityState(entity, data, adapter.Columns())
+}
+
+// DraftState is the state of the pending data of a draft, its fields are prefixed by data like the
+// data of EntityState
+func DraftState(draft []byte) (State, error) {
+	state := State{}
+	if len(draft) == 0 {
+		return state, nil
+	}
+
+	fields, err := ObjectState(json.RawMessage(draft))
+	if err != nil {
+		return nil, err
+	}
+	for field, value := range fields {
+		state["data."+field] = value
+	}
+	return state, nil
+}
+
+// ObjectState is the state of a value that encodes as a JSON object, one field per key
+func ObjectState(value interface{}) (State, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	state := State{}
+	if err := json.Unmarshal(encoded, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Diff lists the fields that differ between two states by field name. A field missing from one
+// state is null there, so a nil old state records every field of a created target.
+func Diff(old, new State) ([]Change, error) {
+	fields := make([]string, 0, len(old)+len(new))
+	for field := range old {
+		fields = append(fields, field)
+	}
+	for field := range new {
+		if _, ok := old[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := []Change{}
+	for _, field := range fields {
+		oldValue, newValue := nullable(old[field]), nullable(new[field])
+
+		equal, err := equalJSON(oldValue, newValue)
+		if err != nil {
+			return nil, err
+		}
+		if !equal {
+			changes = append(changes, Change{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	return changes, nil
+}
+
+// equalJSON compares decoded values so formatting differences of the encoding don't count
+func equalJSON(a, b json.RawMessage) (bool, error) {
+	var aValue, bValue interface{}
+	if err := json.Unmarshal(a, &aValue); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, &bValue); err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(aValue, bValue), nil
+}
+
+func nullable(value json.RawMessage) json.RawMessage {
+	if value == nil {
+		return json.RawMessage("null")
+	}
+	return value
+}
+
+func optionalText(value string) pgtype.Text {
+	return pgtype.Text{String: value, Valid: value != ""}
+}
@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+var productColumns = []adapters.Column{
+	{Name: "sku", DBType: "varchar"},
+	{Name: "price", DBType: "float8", Nullable: true},
+}
+
+func encodeChanges(t *testing.T, changes []Change) string {
+	t.Helper()
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(encoded)
+}
+
+func TestDiff(t *testing.T) {
+	old := State{
+		"sku":   json.RawMessage(`"TV"`),
+		"price": json.RawMessage(`12.5`),
+		"tags":  json.RawMessage(`["a", "b"]`),
+	}
+	new := State{
+		"sku":   json.RawMessage(`"TV-2"`),
+		"price": json.RawMessage(`12.50`),
+		"tags":  json.RawMessage(`["a","b"]`),
+		"color": json.RawMessage(`"red"`),
+	}
+
+	changes, err := Diff(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `[{"field":"color","old":null,"new":"red"},{"field":"sku","old":"TV","new":"TV-2"}]`
+	if got := encodeChanges(t, changes); got != want {
+		t.Errorf("expected changes %s, got %s", want, got)
+	}
+}
+
+func TestDiffCreateAndDelete(t *testing.T) {
+	state := State{"sku": json.RawMessage(`"TV"`)}
+
+	created, err := Diff(nil, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := encodeChanges(t, created), `[{"field":"sku","old":null,"new":"TV"}]`; got != want {
+		t.Errorf("expected changes %s, got %s", want, got)
+	}
+
+	deleted, err := Diff(state, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := encodeChanges(t, deleted), `[{"field":"sku","old":"TV","new":null}]`; got != want {
+		t.Errorf("expected changes %s, got %s", want, got)
+	}
+
+	// Nothing changed encodes as an empty list, never null
+	unchanged, err := Diff(state, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := encodeChanges(t, unchanged); got != `[]` {
+		t.Errorf("expected no changes, got %s", got)
+	}
+}
+
+func TestEntityState(t *testing.T) {
+	entity := db.Entity{OKey: "tv", OPath: "/products/", Published: true, Version: 4}
+	row := struct {
+		ID        int64     `json:"id"`
+		Sku       string    `json:"sku"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}{ID: 7, Sku: "TV", UpdatedAt: time.Now()}
+
+	state, err := EntityState(entity, row, productColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for field, want := range map[string]string{
+		"o_key":      `"tv"`,
+		"o_path":     `"/products/"`,
+		"published":  `true`,
+		"parent_id":  `null`,
+		"data.sku":   `"TV"`,
+		"data.price": ``,
+		"version":    ``,
+	} {
+		if got := string(state[field]); got != want {
+			t.Errorf("expected %s to be %q, got %q", field, want, got)
+		}
+	}
+}
+
+func TestDraftState(t *testing.T) {
+	state, err := DraftState([]byte(`{"sku": "TV", "price": 12.5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state) != 2 || string(state["data.sku"]) != `"TV"` || string(state["data.price"]) != `12.5` {
+		t.Errorf("unexpected draft state %v", state)
+	}
+
+	empty, err := DraftState(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(empty) != 0 {
+		t.Errorf("expected an empty state, got %v", empty)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 600, time.UTC)
+	id := pgtype.UUID{Bytes: [16]byte{1, 2, 3}, Valid: true}
+
+	gotAt, gotID, ok := decodeCursor(encodeCursor(createdAt, id))
+	if !ok {
+		t.Fatal("failed to decode cursor")
+	}
+	if !gotAt.Equal(createdAt) || gotID != id {
+		t.Errorf("expected %v %v, got %v %v", createdAt, id, gotAt, gotID)
+	}
+
+	for _, cursor := range []string{"", "not base64!", "bm8gc2VwYXJhdG9y"} {
+		if _, _, ok := decodeCursor(cursor); ok {
+			t.Errorf("expected cursor %q to be invalid", cursor)
+		}
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	params, err := Query{Action: ActionPublish}.params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.RowLimit != DefaultLimit || params.Action.String != ActionPublish || params.TargetType.Valid {
+		t.Errorf("unexpected params %+v", params)
+	}
+
+	for _, q := range []Query{
+		{Limit: MaxLimit + 1},
+		{ActorID: "nobody"},
+		{Cursor: "bm8gc2VwYXJhdG9y"},
+	} {
+		if _, err := q.params(); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("expected query %+v to be invalid, got %v", q, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/oriiyx/fritz/app/core/api/base"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+)
+
+type Handler struct {
+	*base.HandlerController
+}
+
+func New(ctrl *base.HandlerController) *Handler {
+	return &Handler{
+		HandlerController: ctrl,
+	}
+}
+
+// List is an endpoint that lists the audit trail newest first. It filters by actor_id, action,
+// target_type and target_id, since and until take RFC 3339 times, and cursor continues after the
+// previous page.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	q, err := parseQuery(r.URL.Query())
+	if err != nil {
+		errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+		return
+	}
+
+	page, err := List(r.Context(), h.Queries, q)
+	if err != nil {
+		if errors.Is(err, ErrInvalidQuery) {
+			errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to list audit log")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+func parseQuery(values url.Values) (Query, error) {
+	q := Query{
+		ActorID:    values.Get("actor_id"),
+		Action:     values.Get("action"),
+		TargetType: values.Get("target_type"),
+		TargetID:   values.Get("target_id"),
+		Cursor:     values.Get("cursor"),
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > MaxLimit {
+			return q, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+		q.Limit = n
+	}
+
+	for _, bound := range []struct {
+		name string
+		dest *time.Time
+	}{{"since", &q.Since}, {"until", &q.Until}} {
+		value := values.Get(bound.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return q, fmt.Errorf("%s must be an RFC 3339 time", bound.name)
+		}
+		*bound.dest = t
+	}
+
+	return q, nil
+}
@@ -0,0 +1,159 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// ErrInvalidQuery is returned for a query with a malformed filter, limit or cursor
+var ErrInvalidQuery = errors.New("invalid audit log query")
+
+// Query selects a page of the audit trail, newest first. Empty filters match every entry, Cursor
+// continues after the last entry of the previous page.
+type Query struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+	Cursor     string
+}
+
+// LogEntry is a recorded entry with the email of its actor, if the actor still exists
+type LogEntry struct {
+	ID         pgtype.UUID        `json:"id"`
+	ActorID    pgtype.UUID        `json:"actor_id"`
+	ActorEmail pgtype.Text        `json:"actor_email"`
+	Action     string             `json:"action"`
+	TargetType string             `json:"target_type"`
+	TargetID   string             `json:"target_id"`
+	Changes    json.RawMessage    `json:"changes"`
+	RequestID  pgtype.Text        `json:"request_id"`
+	IP         pgtype.Text        `json:"ip"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type Page struct {
+	Items      []LogEntry `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// List reads the page of the audit trail q selects
+func List(ctx context.Context, queries *db.Queries, q Query) (*Page, error) {
+	params, err := q.params()
+	if err != nil {
+		return nil, err
+	}
+
+	// One row more than the limit tells whether another page follows
+	params.RowLimit++
+	rows, err := queries.ListAuditLog(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Items: make([]LogEntry, 0, len(rows))}
+	if len(rows) == int(params.RowLimit) {
+		rows = rows[:len(rows)-1]
+		last := rows[len(rows)-1]
+		page.NextCursor = encodeCursor(last.CreatedAt.Time, last.ID)
+	}
+
+	for _, row := range rows {
+		page.Items = append(page.Items, LogEntry{
+			ID:         row.ID,
+			ActorID:    row.ActorID,
+			ActorEmail: row.ActorEmail,
+			Action:     row.Action,
+			TargetType: row.TargetType,
+			TargetID:   row.TargetID,
+			Changes:    row.Changes,
+			RequestID:  row.RequestID,
+			IP:         row.Ip,
+			CreatedAt:  row.CreatedAt,
+		})
+	}
+
+	return page, nil
+}
+
+func (q Query) params() (db.ListAuditLogParams, error) {
+	params := db.ListAuditLogParams{
+		Action:     optionalText(q.Action),
+		TargetType: optionalText(q.TargetType),
+		TargetID:   optionalText(q.TargetID),
+		RowLimit:   DefaultLimit,
+	}
+
+	if q.Limit != 0 {
+		if q.Limit < 0 || q.Limit > MaxLimit {
+			return params, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxLimit)
+		}
+		params.RowLimit = int32(q.Limit)
+	}
+	if q.ActorID != "" {
+		if err := params.ActorID.Scan(q.ActorID); err != nil {
+			return params, fmt.Errorf("%w: invalid actor_id", ErrInvalidQuery)
+		}
+	}
+	if !q.Since.IsZero() {
+		params.Since = pgtype.Timestamptz{Time: q.Since, Valid: true}
+	}
+	if !q.Until.IsZero() {
+		params.Until = pgtype.Timestamptz{Time: q.Until, Valid: true}
+	}
+	if q.Cursor != "" {
+		createdAt, id, ok := decodeCursor(q.Cursor)
+		if !ok {
+			return params, fmt.Errorf("%w: invalid cursor", ErrInvalidQuery)
+		}
+		params.BeforeCreatedAt = pgtype.Timestamptz{Time: createdAt, Valid: true}
+		params.BeforeID = id
+	}
+
+	return params, nil
+}
+
+// encodeCursor keeps the position of an entry, the id breaks ties of entries written at once
+func encodeCursor(createdAt time.Time, id pgtype.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+func decodeCursor(cursor string) (time.Time, pgtype.UUID, bool) {
+	var id pgtype.UUID
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, id, false
+	}
+
+	createdAt, rawID, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, id, false
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return time.Time{}, id, false
+	}
+	if err := id.Scan(rawID); err != nil {
+		return time.Time{}, id, false
+	}
+
+	return at, id, true
+}
@@ -7,6 +7,7 @@ const (
 	Controller   = "controller"
 	Database     = "database"
 	EnvConfig    = "env_config"
+	ImportRuns   = "import_runs"
 	Logger       = "logger"
 	Router       = "router"
 	Queries      = "queries"
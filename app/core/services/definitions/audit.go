@@ -0,0 +1,47 @@
+package definitions
+
+import (
+	"net/http"
+
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+)
+
+// recordAudit appends the audit entry of a definition change, a nil definition is one that did not
+// exist. The schema is already changed when it runs, so a failure is logged rather than answered.
+func (h *Handler) recordAudit(r *http.Request, action string, before, after *definitions.EntityDefinition) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	entry, err := definitionEntry(action, before, after)
+	if err == nil {
+		err = audit.Record(r.Context(), h.Queries, entry)
+	}
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("definition_id", entry.TargetID).Msg("Failed to record definition audit entry")
+	}
+}
+
+// definitionEntry diffs the top level fields of the definitions, the layout is a single field
+func definitionEntry(action string, before, after *definitions.EntityDefinition) (audit.Entry, error) {
+	entry := audit.Entry{Action: action, TargetType: audit.TargetDefinition}
+
+	var beforeState, afterState audit.State
+	var err error
+	if before != nil {
+		entry.TargetID = before.ID
+		if beforeState, err = audit.ObjectState(before); err != nil {
+			return entry, err
+		}
+	}
+	if after != nil {
+		entry.TargetID = after.ID
+		if afterState, err = audit.ObjectState(after); err != nil {
+			return entry, err
+		}
+	}
+
+	entry.Changes, err = audit.Diff(beforeState, afterState)
+	return entry, err
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/oriiyx/fritz/app/core/api/base"
 	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
 	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/audit"
 	"github.com/oriiyx/fritz/app/core/services/objects/definition_builder"
 	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
 	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
@@ -118,6 +120,9 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.recordAudit(r, audit.ActionCreate, nil, &req)
+	h.triggerAfter(r, hooks.HookAfterDefinitionCreate, &req)
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -9,6 +9,8 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
 	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/audit"
 	"github.com/oriiyx/fritz/app/core/services/objects/definition_builder"
 	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
 )
@@ -120,6 +122,9 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.recordAudit(r, audit.ActionDelete, definition, nil)
+	h.triggerAfter(r, hooks.HookAfterDefinitionDelete, definition)
+
 	h.Logger.Info().
 		Str("entity_id", definition.ID).
 		Msg("SQLC generation completed successfully")
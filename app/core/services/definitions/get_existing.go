@@ -7,6 +7,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
 	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/services/entities/drift"
 	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
 )
 
@@ -38,3 +39,17 @@ func (h *Handler) GetSingleExisting(w http.ResponseWriter, r *http.Request) {
 
 	_ = json.NewEncoder(w).Encode(definition)
 }
+
+// Status reports drift between compiled adapters, stored definitions and live entity tables
+func (h *Handler) Status(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	report, err := drift.NewChecker(h.Queries, h.Conf.Definitions.DriftPolicy).Check(r.Context())
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to check definitions drift")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,24 @@
+package definitions
+
+import (
+	"net/http"
+
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+)
+
+// triggerAfter runs the callbacks of a definition hook. The schema is already changed, so an error
+// of a callback is logged and the response stays successful.
+func (h *Handler) triggerAfter(r *http.Request, name string, definition *definitions.EntityDefinition) {
+	if h.Hooks == nil {
+		return
+	}
+
+	payload := &hooks.DefinitionPayload{Definition: definition, UserID: ctxUtil.GetUserID(r.Context())}
+	if err := h.Hooks.Trigger(r.Context(), name, payload); err != nil {
+		reqID := ctxUtil.RequestID(r.Context())
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("hook", name).Msg("Hook failed after the operation")
+	}
+}
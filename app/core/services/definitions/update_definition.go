@@ -8,6 +8,8 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
 	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/audit"
 	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
 	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
 	helpers "github.com/oriiyx/fritz/app/core/utils/helpers/schema"
@@ -38,7 +40,15 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	validation, err := h.entityBuilder.ValidateExistingDefinition(&req)
+	// 1. get existing definition
+	existingDefinition, err := h.entityBuilder.LoadDefinitionByID(ID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("definition_id", ID).Msg("Failed to load existing definition for update")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	validation, err := h.entityBuilder.ValidateExistingDefinition(&req, existingDefinition)
 	if err != nil {
 		h.Logger.Error().Err(err).Msg("Validation of definitions at create entrypoint failed")
 		errhandler.BadRequest(w, errhandler.RespFailedToValidateDefinitions)
@@ -50,14 +60,6 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 1. get existing definition
-	existingDefinition, err := h.entityBuilder.LoadDefinitionByID(ID)
-	if err != nil {
-		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("definition_id", ID).Msg("Failed to load existing definition for update")
-		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
-		return
-	}
-
 	// 2. compare differences
 	changeset, err := h.entityBuilder.CompareDefinitions(existingDefinition, &req)
 	if err != nil {
@@ -66,8 +68,19 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 3. create UPDATE TABLE dynamic query and run it
+	// 3. create UPDATE TABLE dynamic query and run it, the search vector depends on the
+	// searchable columns so it is rebuilt around the changeset when needed
 	tablename := h.entityBuilder.CreateEntityTableName(existingDefinition)
+	rebuildSearch := h.entityBuilder.SearchVectorChanged(existingDefinition, &req, changeset)
+	if rebuildSearch {
+		err = h.entityBuilder.DropSearchVector(r.Context(), tablename)
+		if err != nil {
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("definition_id", ID).Msg("Failed to drop search vector")
+			errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+			return
+		}
+	}
+
 	err = h.entityBuilder.UpdateTableFromChangeset(changeset, tablename, r.Context())
 	if err != nil {
 		h.Logger.Error().Err(err).Interface("definition", req).Msg("Failed to create update table queries")
@@ -75,6 +88,15 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if rebuildSearch {
+		err = h.entityBuilder.AddSearchVector(r.Context(), &req)
+		if err != nil {
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("definition_id", ID).Msg("Failed to add search vector")
+			errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+			return
+		}
+	}
+
 	// 4. Update the database/schema/entity_*.sql with a fresh schema
 	err = h.entityBuilder.StoreDefinitionIntoEntityFile(&req)
 	if err != nil {
@@ -130,5 +152,8 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.recordAudit(r, audit.ActionUpdate, existingDefinition, &req)
+	h.triggerAfter(r, hooks.HookAfterDefinitionUpdate, &req)
+
 	w.WriteHeader(http.StatusOK)
 }
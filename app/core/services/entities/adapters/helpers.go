@@ -1,307 +1,275 @@
 package adapters
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-// String helpers - existing ones remain the same
-func mustGetString(data map[string]interface{}, key string) string {
-	v, ok := data[key]
-	if !ok {
-		panic(fmt.Sprintf("missing required field: %s", key))
+// dateLayouts are the accepted input formats for date components
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02T00:00:00.000Z",
+	time.RFC3339,
+}
+
+// ValidationError lists every field of a payload that failed validation against its definition
+type ValidationError struct {
+	Fields map[string][]string `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
 	}
-	s, ok := v.(string)
-	if !ok {
-		panic(fmt.Sprintf("field %s must be string, got %T", key, v))
+	sort.Strings(fields)
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("%s: %s", field, strings.Join(e.Fields[field], ", ")))
 	}
-	return s
+
+	return fmt.Sprintf("validation failed: %s", strings.Join(parts, "; "))
 }
 
-func getString(data map[string]interface{}, key string) string {
-	v, ok := data[key]
-	if !ok {
-		return ""
+// Add records a failure message for the given field
+func (e *ValidationError) Add(field, message string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string][]string)
+	}
+	e.Fields[field] = append(e.Fields[field], message)
+}
+
+// AsValidationError unwraps a ValidationError from err
+func AsValidationError(err error) (*ValidationError, bool) {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr, true
+	}
+	return nil, false
+}
+
+// payload reads typed values out of a JSON data map and collects every failure
+// instead of stopping at the first one
+type payload struct {
+	data map[string]interface{}
+	errs ValidationError
+
+	// partial payloads only carry the keys that should change
+	partial bool
+}
+
+func newPayload(data map[string]interface{}) *payload {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	return &payload{data: data}
+}
+
+func newPatchPayload(data map[string]interface{}) *payload {
+	p := newPayload(data)
+	p.partial = true
+	return p
+}
+
+// has reports whether key was supplied at all, including as an explicit null
+func (p *payload) has(key string) bool {
+	_, ok := p.data[key]
+	return ok
+}
+
+// err returns the collected ValidationError or nil when the payload is valid
+func (p *payload) err() error {
+	if len(p.errs.Fields) == 0 {
+		return nil
 	}
-	if v == nil {
+	return &p.errs
+}
+
+func (p *payload) fail(key, message string) {
+	p.errs.Add(key, message)
+}
+
+func (p *payload) failed(key string) bool {
+	_, exists := p.errs.Fields[key]
+	return exists
+}
+
+// lookup returns the value for key and whether it holds something other than null
+func (p *payload) lookup(key string) (interface{}, bool) {
+	v, ok := p.data[key]
+	if !ok || v == nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// String helpers
+
+// mustString reads a NOT NULL string. An empty string fails like a missing key, a required text
+// component must carry some text.
+func (p *payload) mustString(key string) string {
+	v, ok := p.lookup(key)
+	if !ok {
+		p.fail(key, "is required")
 		return ""
 	}
 	s, ok := v.(string)
 	if !ok {
-		return fmt.Sprintf("%v", v)
+		p.fail(key, fmt.Sprintf("must be a string, got %s", jsonType(v)))
+		return ""
+	}
+	if s == "" {
+		p.fail(key, "is required")
 	}
 	return s
 }
 
-func getPgText(data map[string]interface{}, key string) pgtype.Text {
-	v, ok := data[key]
-	if !ok || v == nil {
+func (p *payload) pgText(key string) pgtype.Text {
+	v, ok := p.lookup(key)
+	if !ok {
 		return pgtype.Text{Valid: false}
 	}
 	s, ok := v.(string)
 	if !ok {
-		s = fmt.Sprintf("%v", v)
+		p.fail(key, fmt.Sprintf("must be a string, got %s", jsonType(v)))
+		return pgtype.Text{Valid: false}
 	}
 	return pgtype.Text{String: s, Valid: true}
 }
 
 // Integer helpers - mandatory (NOT NULL)
-func mustGetInt32(data map[string]interface{}, key string) int32 {
-	v, ok := data[key]
-	if !ok {
-		panic(fmt.Sprintf("missing required field: %s", key))
-	}
-
-	switch val := v.(type) {
-	case float64:
-		return int32(val)
-	case int:
-		return int32(val)
-	case int32:
-		return val
-	case int64:
-		return int32(val)
-	default:
-		panic(fmt.Sprintf("field %s must be numeric, got %T", key, v))
-	}
+func (p *payload) mustInt16(key string) int16 {
+	return int16(p.mustInteger(key, 16))
 }
 
-func mustGetInt64(data map[string]interface{}, key string) int64 {
-	v, ok := data[key]
-	if !ok {
-		panic(fmt.Sprintf("missing required field: %s", key))
-	}
-
-	switch val := v.(type) {
-	case float64:
-		return int64(val)
-	case int:
-		return int64(val)
-	case int32:
-		return int64(val)
-	case int64:
-		return val
-	default:
-		panic(fmt.Sprintf("field %s must be numeric, got %T", key, v))
-	}
+func (p *payload) mustInt32(key string) int32 {
+	return int32(p.mustInteger(key, 32))
 }
 
-func mustGetInt16(data map[string]interface{}, key string) int16 {
-	v, ok := data[key]
-	if !ok {
-		panic(fmt.Sprintf("missing required field: %s", key))
-	}
+func (p *payload) mustInt64(key string) int64 {
+	return p.mustInteger(key, 64)
+}
 
-	switch val := v.(type) {
-	case float64:
-		return int16(val)
-	case int:
-		return int16(val)
-	case int16:
-		return val
-	case int32:
-		return int16(val)
-	case int64:
-		return int16(val)
-	default:
-		panic(fmt.Sprintf("field %s must be numeric, got %T", key, v))
+func (p *payload) mustInteger(key string, bits int) int64 {
+	if _, ok := p.lookup(key); !ok {
+		p.fail(key, "is required")
+		return 0
 	}
+	n, _ := p.integer(key, bits)
+	return n
 }
 
-// Integer helpers - nullable (pgtype.Int4, pgtype.Int8, pgtype.Int2)
-func getPgInt4(data map[string]interface{}, key string) pgtype.Int4 {
-	v, ok := data[key]
-	if !ok || v == nil {
-		return pgtype.Int4{Valid: false}
-	}
+// Integer helpers - nullable (pgtype.Int2, pgtype.Int4, pgtype.Int8)
+func (p *payload) pgInt2(key string) pgtype.Int2 {
+	n, ok := p.integer(key, 16)
+	return pgtype.Int2{Int16: int16(n), Valid: ok}
+}
 
-	var intVal int32
-	switch val := v.(type) {
-	case float64:
-		intVal = int32(val)
-	case int:
-		intVal = int32(val)
-	case int32:
-		intVal = val
-	case int64:
-		intVal = int32(val)
-	default:
-		return pgtype.Int4{Valid: false}
-	}
+func (p *payload) pgInt4(key string) pgtype.Int4 {
+	n, ok := p.integer(key, 32)
+	return pgtype.Int4{Int32: int32(n), Valid: ok}
+}
 
-	return pgtype.Int4{Int32: intVal, Valid: true}
+func (p *payload) pgInt8(key string) pgtype.Int8 {
+	n, ok := p.integer(key, 64)
+	return pgtype.Int8{Int64: n, Valid: ok}
 }
 
-func getPgInt8(data map[string]interface{}, key string) pgtype.Int8 {
-	v, ok := data[key]
-	if !ok || v == nil {
-		return pgtype.Int8{Valid: false}
+// integer reads a whole number that fits into a signed integer of the given size
+func (p *payload) integer(key string, bits int) (int64, bool) {
+	v, ok := p.lookup(key)
+	if !ok {
+		return 0, false
 	}
 
-	var intVal int64
-	switch val := v.(type) {
-	case float64:
-		intVal = int64(val)
-	case int:
-		intVal = int64(val)
-	case int32:
-		intVal = int64(val)
-	case int64:
-		intVal = val
-	default:
-		return pgtype.Int8{Valid: false}
+	f, ok := toFloat64(v)
+	if !ok {
+		p.fail(key, fmt.Sprintf("must be a number, got %s", jsonType(v)))
+		return 0, false
 	}
-
-	return pgtype.Int8{Int64: intVal, Valid: true}
-}
-
-func getPgInt2(data map[string]interface{}, key string) pgtype.Int2 {
-	v, ok := data[key]
-	if !ok || v == nil {
-		return pgtype.Int2{Valid: false}
+	if f != math.Trunc(f) {
+		p.fail(key, "must be a whole number")
+		return 0, false
 	}
 
-	var intVal int16
-	switch val := v.(type) {
-	case float64:
-		intVal = int16(val)
-	case int:
-		intVal = int16(val)
-	case int16:
-		intVal = val
-	case int32:
-		intVal = int16(val)
-	case int64:
-		intVal = int16(val)
-	default:
-		return pgtype.Int2{Valid: false}
+	limit := math.Ldexp(1, bits-1)
+	if f < -limit || f >= limit {
+		p.fail(key, fmt.Sprintf("is out of range for a %d-bit integer", bits))
+		return 0, false
 	}
 
-	return pgtype.Int2{Int16: intVal, Valid: true}
+	return int64(f), true
 }
 
 // Float helpers - mandatory (NOT NULL)
-func mustGetFloat32(data map[string]interface{}, key string) float32 {
-	v, ok := data[key]
-	if !ok {
-		panic(fmt.Sprintf("missing required field: %s", key))
-	}
-
-	switch val := v.(type) {
-	case float64:
-		return float32(val)
-	case int:
-		return float32(val)
-	case int32:
-		return float32(val)
-	case int64:
-		return float32(val)
-	case float32:
-		return val
-	default:
-		panic(fmt.Sprintf("field %s must be numeric, got %T", key, v))
-	}
+func (p *payload) mustFloat32(key string) float32 {
+	return float32(p.mustFloat64(key))
 }
 
-func mustGetFloat64(data map[string]interface{}, key string) float64 {
-	v, ok := data[key]
-	if !ok {
-		panic(fmt.Sprintf("missing required field: %s", key))
-	}
-
-	switch val := v.(type) {
-	case float64:
-		return val
-	case int:
-		return float64(val)
-	case int32:
-		return float64(val)
-	case int64:
-		return float64(val)
-	case float32:
-		return float64(val)
-	default:
-		panic(fmt.Sprintf("field %s must be numeric, got %T", key, v))
+func (p *payload) mustFloat64(key string) float64 {
+	if _, ok := p.lookup(key); !ok {
+		p.fail(key, "is required")
+		return 0
 	}
+	f, _ := p.float(key)
+	return f
 }
 
-func getPgFloat4(data map[string]interface{}, key string) pgtype.Float4 {
-	v, ok := data[key]
-	if !ok || v == nil {
-		return pgtype.Float4{Valid: false}
-	}
-
-	var floatVal float32
-	switch val := v.(type) {
-	case float64:
-		floatVal = float32(val)
-	case int:
-		floatVal = float32(val)
-	case int16:
-		floatVal = float32(val)
-	case int32:
-		floatVal = float32(val)
-	case int64:
-		floatVal = float32(val)
-	case float32:
-		floatVal = val
-	default:
-		return pgtype.Float4{Valid: false}
-	}
+// Float helpers - nullable (pgtype.Float4, pgtype.Float8)
+func (p *payload) pgFloat4(key string) pgtype.Float4 {
+	f, ok := p.float(key)
+	return pgtype.Float4{Float32: float32(f), Valid: ok}
+}
 
-	return pgtype.Float4{Float32: floatVal, Valid: true}
+func (p *payload) pgFloat8(key string) pgtype.Float8 {
+	f, ok := p.float(key)
+	return pgtype.Float8{Float64: f, Valid: ok}
 }
 
-func getPgFloat8(data map[string]interface{}, key string) pgtype.Float8 {
-	v, ok := data[key]
-	if !ok || v == nil {
-		return pgtype.Float8{Valid: false}
+func (p *payload) float(key string) (float64, bool) {
+	v, ok := p.lookup(key)
+	if !ok {
+		return 0, false
 	}
 
-	var floatVal float64
-	switch val := v.(type) {
-	case float64:
-		floatVal = val
-	case int:
-		floatVal = float64(val)
-	case int16:
-		floatVal = float64(val)
-	case int32:
-		floatVal = float64(val)
-	case int64:
-		floatVal = float64(val)
-	case float32:
-		floatVal = float64(val)
-	default:
-		return pgtype.Float8{Valid: false}
+	f, ok := toFloat64(v)
+	if !ok {
+		p.fail(key, fmt.Sprintf("must be a number, got %s", jsonType(v)))
+		return 0, false
 	}
 
-	return pgtype.Float8{Float64: floatVal, Valid: true}
+	return f, true
 }
 
 // Boolean helpers
-func mustGetBool(data map[string]interface{}, key string) bool {
-	v, ok := data[key]
-	if !ok {
-		panic(fmt.Sprintf("missing required field: %s", key))
+func (p *payload) mustBool(key string) bool {
+	if _, ok := p.lookup(key); !ok {
+		p.fail(key, "is required")
+		return false
 	}
-	b, ok := v.(bool)
-	if !ok {
-		panic(fmt.Sprintf("field %s must be bool, got %T", key, v))
-	}
-	return b
+	b := p.pgBool(key)
+	return b.Bool
 }
 
-func getPgBool(data map[string]interface{}, key string) pgtype.Bool {
-	v, ok := data[key]
-	if !ok || v == nil {
+func (p *payload) pgBool(key string) pgtype.Bool {
+	v, ok := p.lookup(key)
+	if !ok {
 		return pgtype.Bool{Valid: false}
 	}
 
 	b, ok := v.(bool)
 	if !ok {
+		p.fail(key, fmt.Sprintf("must be a boolean, got %s", jsonType(v)))
 		return pgtype.Bool{Valid: false}
 	}
 
@@ -309,23 +277,25 @@ func getPgBool(data map[string]interface{}, key string) pgtype.Bool {
 }
 
 // Date/Time helpers
-func getPgDate(data map[string]interface{}, key string) pgtype.Date {
-	v, ok := data[key]
-	if !ok || v == nil {
+func (p *payload) pgDate(key string) pgtype.Date {
+	v, ok := p.lookup(key)
+	if !ok {
 		return pgtype.Date{Valid: false}
 	}
 
 	var t time.Time
 	switch val := v.(type) {
 	case string:
-		parsed, err := time.Parse("2006-01-02T00:00:00.000Z", val)
-		if err != nil {
+		parsed, ok := parseTime(val, dateLayouts...)
+		if !ok {
+			p.fail(key, "must be a date in YYYY-MM-DD format")
 			return pgtype.Date{Valid: false}
 		}
 		t = parsed
 	case time.Time:
 		t = val
 	default:
+		p.fail(key, fmt.Sprintf("must be a date string, got %s", jsonType(v)))
 		return pgtype.Date{Valid: false}
 	}
 
@@ -335,23 +305,25 @@ func getPgDate(data map[string]interface{}, key string) pgtype.Date {
 	}
 }
 
-func getPgTimestamp(data map[string]interface{}, key string) pgtype.Timestamptz {
-	v, ok := data[key]
-	if !ok || v == nil {
+func (p *payload) pgTimestamp(key string) pgtype.Timestamptz {
+	v, ok := p.lookup(key)
+	if !ok {
 		return pgtype.Timestamptz{Valid: false}
 	}
 
 	var t time.Time
 	switch val := v.(type) {
 	case string:
-		parsed, err := time.Parse(time.RFC3339, val)
-		if err != nil {
+		parsed, ok := parseTime(val, time.RFC3339Nano)
+		if !ok {
+			p.fail(key, "must be an RFC3339 timestamp")
 			return pgtype.Timestamptz{Valid: false}
 		}
 		t = parsed
 	case time.Time:
 		t = val
 	default:
+		p.fail(key, fmt.Sprintf("must be a timestamp string, got %s", jsonType(v)))
 		return pgtype.Timestamptz{Valid: false}
 	}
 
@@ -360,3 +332,140 @@ func getPgTimestamp(data map[string]interface{}, key string) pgtype.Timestamptz
 		Valid: true,
 	}
 }
+
+// Rules generated from component settings
+func (p *payload) required(key string) {
+	if p.failed(key) || (p.partial && !p.has(key)) {
+		return
+	}
+
+	v, ok := p.lookup(key)
+	if !ok {
+		if p.partial {
+			p.fail(key, "cannot be null")
+			return
+		}
+		p.fail(key, "is required")
+		return
+	}
+
+	if s, isString := v.(string); isString && s == "" {
+		p.fail(key, "is required")
+	}
+}
+
+// allowOnly rejects every key that is not a component of the definition
+func (p *payload) allowOnly(keys ...string) {
+	allowed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		allowed[key] = true
+	}
+
+	for key := range p.data {
+		if !allowed[key] {
+			p.fail(key, "is not defined on this entity class")
+		}
+	}
+}
+
+func (p *payload) matches(key string, pattern *regexp.Regexp) {
+	s, ok := p.validString(key)
+	if ok && !pattern.MatchString(s) {
+		p.fail(key, fmt.Sprintf("must match pattern %s", pattern.String()))
+	}
+}
+
+func (p *payload) maxLength(key string, length int) {
+	s, ok := p.validString(key)
+	if ok && utf8.RuneCountInString(s) > length {
+		p.fail(key, fmt.Sprintf("must be a maximum of %d characters in length", length))
+	}
+}
+
+func (p *payload) minNumber(key string, limit float64) {
+	f, ok := p.validNumber(key)
+	if ok && f < limit {
+		p.fail(key, fmt.Sprintf("must be greater than or equal to %v", limit))
+	}
+}
+
+func (p *payload) maxNumber(key string, limit float64) {
+	f, ok := p.validNumber(key)
+	if ok && f > limit {
+		p.fail(key, fmt.Sprintf("must be less than or equal to %v", limit))
+	}
+}
+
+// validString returns the value of key if it is a string that has not failed conversion
+func (p *payload) validString(key string) (string, bool) {
+	if p.failed(key) {
+		return "", false
+	}
+	v, ok := p.lookup(key)
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+// validNumber returns the value of key if it is a number that has not failed conversion
+func (p *payload) validNumber(key string) (float64, bool) {
+	if p.failed(key) {
+		return 0, false
+	}
+	v, ok := p.lookup(key)
+	if !ok {
+		return 0, false
+	}
+	return toFloat64(v)
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case int16:
+		return float64(val), true
+	case int32:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case json.Number:
+		f, err := val.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func parseTime(value string, layouts ...string) (time.Time, bool) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// jsonType names the JSON type of v for error messages
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float32, float64, int, int16, int32, int64, json.Number:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,67 @@
+package adapters
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMustString(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		data   map[string]interface{}
+		want   string
+		errors []string
+	}{
+		{"value", map[string]interface{}{"title": "TV"}, "TV", nil},
+		{"missing", map[string]interface{}{}, "", []string{"is required"}},
+		{"null", map[string]interface{}{"title": nil}, "", []string{"is required"}},
+		{"empty string", map[string]interface{}{"title": ""}, "", []string{"is required"}},
+		{"number", map[string]interface{}{"title": 12.5}, "", []string{"must be a string, got number"}},
+	} {
+		p := newPayload(tc.data)
+		if got := p.mustString("title"); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+		if got := p.errs.Fields["title"]; !reflect.DeepEqual(got, tc.errors) {
+			t.Errorf("%s: expected errors %v, got %v", tc.name, tc.errors, got)
+		}
+	}
+}
+
+func TestAllowOnly(t *testing.T) {
+	p := newPayload(map[string]interface{}{"title": "TV", "stock": 3.0, "colour": "red"})
+	p.allowOnly("title", "stock")
+
+	want := map[string][]string{"colour": {"is not defined on this entity class"}}
+	if !reflect.DeepEqual(p.errs.Fields, want) {
+		t.Errorf("expected errors %v, got %v", want, p.errs.Fields)
+	}
+
+	p = newPatchPayload(map[string]interface{}{"title": "TV"})
+	p.allowOnly("title", "stock")
+	if err := p.err(); err != nil {
+		t.Errorf("expected a payload of known keys to be valid, got %v", err)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	var validationErr ValidationError
+	validationErr.Add("title", "is required")
+	validationErr.Add("stock", "must be a number, got string")
+	validationErr.Add("title", "must be a maximum of 255 characters in length")
+
+	want := "validation failed: stock: must be a number, got string; title: is required, must be a maximum of 255 characters in length"
+	if got := validationErr.Error(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	got, ok := AsValidationError(fmt.Errorf("failed to save: %w", &validationErr))
+	if !ok || got != &validationErr {
+		t.Errorf("expected the wrapped validation error, got %v", got)
+	}
+	if _, ok := AsValidationError(errors.New("connection refused")); ok {
+		t.Error("expected a plain error not to be a validation error")
+	}
+}
@@ -3,15 +3,39 @@ package adapters
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+
+	"github.com/jackc/pgx/v5"
 )
 
 // EntityAdapter is the interface all generated adapters implement
 type EntityAdapter interface {
+	// Validate and ValidatePatch run the conversions and rules of Create and Patch without
+	// writing, errors are *ValidationError
+	Validate(data map[string]interface{}) error
+	ValidatePatch(data map[string]interface{}) error
 	Create(ctx context.Context, entityID any, data map[string]interface{}) (interface{}, error)
 	Read(ctx context.Context, id any) (interface{}, error)
-	Update(ctx context.Context, id any, data map[string]interface{}) (interface{}, error)
+	// Update and Patch only write when the entity is still at the given version
+	Update(ctx context.Context, id any, version int64, data map[string]interface{}) (interface{}, error)
+	// Patch writes only the keys present in data, a null value clears the column
+	Patch(ctx context.Context, id any, version int64, data map[string]interface{}) (interface{}, error)
 	Delete(ctx context.Context, id any) error
+	// WithTx returns a copy of the adapter that runs its queries in tx
+	WithTx(tx pgx.Tx) EntityAdapter
+
+	// SchemaHash returns the definition schema hash the adapter was generated from
+	SchemaHash() string
+	// Columns returns the component columns the adapter was generated for
+	Columns() []Column
+}
+
+// Column describes a single component column as it was known at generation time
+type Column struct {
+	Name     string `json:"name"`
+	DBType   string `json:"db_type"`
+	Nullable bool   `json:"nullable"`
 }
 
 var (
@@ -37,3 +61,17 @@ func Get(classID string) (EntityAdapter, error) {
 	}
 	return adapter, nil
 }
+
+// Registered returns the class IDs of all registered adapters in alphabetical order
+func Registered() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	classIDs := make([]string, 0, len(registry))
+	for classID := range registry {
+		classIDs = append(classIDs, classID)
+	}
+	sort.Strings(classIDs)
+
+	return classIDs
+}
@@ -0,0 +1,28 @@
+package entities
+
+import (
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// folderState is the audited state of a folder, its position with the folder metadata
+func folderState(entity db.Entity, folder db.EntityFolder) (audit.State, error) {
+	state, err := audit.EntityState(entity, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := audit.ObjectState(map[string]interface{}{
+		"description":     folder.Description,
+		"icon":            folder.Icon,
+		"allowed_classes": allowedClasses(folder.AllowedClasses),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for field, value := range metadata {
+		state[field] = value
+	}
+
+	return state, nil
+}
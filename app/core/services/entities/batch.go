@@ -0,0 +1,67 @@
+package entities
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/services/entities/batch"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	validatorUtil "github.com/oriiyx/fritz/app/core/utils/validator"
+)
+
+// BatchRequest is a list of operations executed in one transaction. With Atomic set the first
+// failing operation rolls back the whole batch, otherwise only the failing operations are undone.
+type BatchRequest struct {
+	Atomic     bool              `json:"atomic"`
+	Operations []batch.Operation `json:"operations" validate:"required,min=1,max=1000,dive"`
+}
+
+// BatchEntities is an endpoint that runs create, save, move, publish and delete operations across
+// classes in a single transaction and reports a result per operation. The operations run the
+// entity hooks like their single endpoints, after hooks once the batch is committed.
+func (h *Handler) BatchEntities(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to decode request")
+		errhandler.BadRequest(w, errhandler.RespInvalidRequestBody)
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to marshal validation errors")
+			errhandler.ServerError(w, errhandler.RespJSONEncodeFailure)
+			return
+		}
+		errhandler.ValidationErrors(w, respBody)
+		return
+	}
+
+	cfg := batch.Config{Hooks: h.Hooks, Policy: h.versionPolicy()}
+	resp, err := batch.Execute(r.Context(), h.DB, h.Queries, req.Operations, req.Atomic, cfg)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to execute batch")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return
+	}
+
+	for _, hook := range resp.AfterHooks() {
+		h.triggerAfter(r, reqID, hook.Name, hook.Payload)
+	}
+
+	h.Logger.Info().
+		Str(l.KeyReqID, reqID).
+		Bool("atomic", req.Atomic).
+		Bool("committed", resp.Committed).
+		Int("succeeded", resp.Succeeded).
+		Int("failed", resp.Failed).
+		Msg("Entity batch executed")
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
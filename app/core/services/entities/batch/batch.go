@@ -0,0 +1,210 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oriiyx/fritz/app/core/kernel"
+	"github.com/oriiyx/fritz/app/core/services/entities/versions"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// MaxOperations bounds a single batch, larger jobs are split by the client
+const MaxOperations = 1000
+
+// OpType names a batch operation
+type OpType string
+
+const (
+	// OpCreate creates an entity and, when data is given, its data row. Its path is derived from the
+	// parent, a given path must match.
+	OpCreate OpType = "create"
+	// OpSave writes the data of an existing entity, creating the data row on the first save. The
+	// data of a published entity goes to its draft.
+	OpSave OpType = "save"
+	// OpMove changes the parent or key of an entity, the paths of the entity and its descendants
+	// are derived from the parent
+	OpMove OpType = "move"
+	// OpPublish publishes an entity with its pending draft, or unpublishes it when published is false
+	OpPublish OpType = "publish"
+	// OpDelete moves an entity with its data and children to the trash
+	OpDelete OpType = "delete"
+)
+
+// Operation is a single step of a batch. Which fields are used depends on Op, save, move and
+// publish require the entity version like If-Match does for single requests.
+type Operation struct {
+	Op        OpType                 `json:"op" validate:"required,oneof=create save move publish delete"`
+	Class     string                 `json:"class,omitempty"`
+	ID        string                 `json:"id,omitempty"`
+	Version   *int64                 `json:"version,omitempty"`
+	ParentID  *string                `json:"parent_id,omitempty"`
+	Key       string                 `json:"key,omitempty" validate:"max=255"`
+	Path      string                 `json:"path,omitempty"`
+	Type      string                 `json:"type,omitempty"`
+	Published *bool                  `json:"published,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// Status is the outcome of an operation
+type Status string
+
+const (
+	StatusOK Status = "ok"
+	// StatusFailed marks the operation that failed, its changes were not applied
+	StatusFailed Status = "failed"
+	// StatusRolledBack marks operations that succeeded but were undone because the atomic
+	// batch failed later
+	StatusRolledBack Status = "rolled_back"
+	// StatusSkipped marks operations that did not run because the atomic batch had failed
+	StatusSkipped Status = "skipped"
+)
+
+// Result reports a single operation, Errors holds per-field validation messages. A save of a
+// published entity goes to its draft, Draft then holds the saved data.
+type Result struct {
+	Index  int                 `json:"index"`
+	Op     OpType              `json:"op"`
+	Status Status              `json:"status"`
+	Entity *db.Entity          `json:"entity,omitempty"`
+	Data   interface{}         `json:"data,omitempty"`
+	Draft  json.RawMessage     `json:"draft,omitempty"`
+	Error  string              `json:"error,omitempty"`
+	Errors map[string][]string `json:"errors,omitempty"`
+
+	// hooks are the after hooks of the operation, they run once the batch is committed
+	hooks []Hook
+}
+
+// Hook is an after hook of a committed operation with its payload
+type Hook struct {
+	Name    string
+	Payload interface{}
+}
+
+// Response reports the whole batch, Committed is false when nothing was written
+type Response struct {
+	Committed bool     `json:"committed"`
+	Succeeded int      `json:"succeeded"`
+	Failed    int      `json:"failed"`
+	Results   []Result `json:"results"`
+}
+
+// AfterHooks returns the after hooks of the committed operations in their order, the caller runs
+// them once Execute returned. Nothing is returned when the batch was not committed.
+func (resp *Response) AfterHooks() []Hook {
+	if !resp.Committed {
+		return nil
+	}
+
+	var after []Hook
+	for _, result := range resp.Results {
+		if result.Status == StatusOK {
+			after = append(after, result.hooks...)
+		}
+	}
+	return after
+}
+
+// Config is what operations need besides the database, they run the same hooks and keep the same
+// version history as the single entity endpoints
+type Config struct {
+	// Hooks runs the before hooks of every operation, a veto fails the operation. Nil runs none.
+	Hooks *kernel.Hooks
+	// Policy prunes the version history of the written entities
+	Policy versions.Policy
+}
+
+// Beginner starts transactions, it is satisfied by *pgxpool.Pool and pgx.Tx
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Execute runs every operation in a single transaction. With atomic set the first failure rolls
+// the whole batch back, otherwise each operation runs in its own savepoint and the successful
+// ones are committed together. The error is only set when the transaction itself failed.
+func Execute(ctx context.Context, conn Beginner, queries *db.Queries, ops []Operation, atomic bool, cfg Config) (*Response, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin batch: %w", err)
+	}
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	resp := &Response{Results: make([]Result, len(ops))}
+
+	for i, op := range ops {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var result Result
+		if atomic {
+			result = apply(ctx, tx, queries.WithTx(tx), op, cfg)
+		} else {
+			result, err = applyInSavepoint(ctx, tx, queries, op, cfg)
+			if err != nil {
+				return nil, err
+			}
+		}
+		result.Index = i
+		result.Op = op.Op
+		resp.Results[i] = result
+
+		if result.Status == StatusFailed {
+			resp.Failed++
+			if atomic {
+				abort(resp, ops, i)
+				return resp, nil
+			}
+			continue
+		}
+		resp.Succeeded++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit batch: %w", err)
+	}
+	resp.Committed = true
+
+	return resp, nil
+}
+
+// applyInSavepoint runs an operation so that its failure only undoes the operation itself
+func applyInSavepoint(ctx context.Context, tx pgx.Tx, queries *db.Queries, op Operation, cfg Config) (Result, error) {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	result := apply(ctx, savepoint, queries.WithTx(savepoint), op, cfg)
+	if result.Status == StatusFailed {
+		if err := savepoint.Rollback(ctx); err != nil {
+			return Result{}, fmt.Errorf("failed to roll back savepoint: %w", err)
+		}
+		return result, nil
+	}
+
+	if err := savepoint.Commit(ctx); err != nil {
+		return Result{}, fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return result, nil
+}
+
+// abort marks the results of an atomic batch that failed at index failed
+func abort(resp *Response, ops []Operation, failed int) {
+	resp.Succeeded = 0
+	for i := range resp.Results {
+		switch {
+		case i < failed:
+			resp.Results[i].Status = StatusRolledBack
+			resp.Results[i].Entity = nil
+			resp.Results[i].Data = nil
+			resp.Results[i].Draft = nil
+			resp.Results[i].hooks = nil
+		case i > failed:
+			resp.Results[i] = Result{Index: i, Op: ops[i].Op, Status: StatusSkipped}
+		}
+	}
+}
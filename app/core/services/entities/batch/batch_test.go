@@ -0,0 +1,129 @@
+package batch
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+func TestCheck(t *testing.T) {
+	version := int64(2)
+	root := ""
+
+	valid := []Operation{
+		{Op: OpCreate, Class: "product", Key: "a"},
+		{Op: OpCreate, Class: "product", Key: "a", Path: "/a"},
+		{Op: OpSave, ID: "id", Version: &version, Data: map[string]interface{}{}},
+		{Op: OpMove, ID: "id", Version: &version, ParentID: &root},
+		{Op: OpPublish, ID: "id", Version: &version},
+		{Op: OpDelete, ID: "id"},
+	}
+	for _, op := range valid {
+		if err := op.check(); err != nil {
+			t.Errorf("%s: expected no error, got %v", op.Op, err)
+		}
+	}
+
+	invalid := []Operation{
+		{Op: OpCreate, Class: "product", Path: "/a"},
+		{Op: OpSave, ID: "id", Data: map[string]interface{}{}},
+		{Op: OpMove, ID: "id", Version: &version},
+		{Op: OpMove, ID: "id", Version: &version, Path: "/a"},
+		{Op: OpPublish, ID: "id"},
+		{Op: OpDelete},
+		{Op: "copy", ID: "id"},
+	}
+	for _, op := range invalid {
+		if err := op.check(); err == nil {
+			t.Errorf("%s: expected an error", op.Op)
+		}
+	}
+}
+
+func TestAbort(t *testing.T) {
+	ops := []Operation{{Op: OpCreate}, {Op: OpSave}, {Op: OpDelete}}
+	resp := &Response{
+		Succeeded: 1,
+		Failed:    1,
+		Results: []Result{
+			{Index: 0, Op: OpCreate, Status: StatusOK, Entity: &db.Entity{}},
+			{Index: 1, Op: OpSave, Status: StatusFailed, Error: "boom"},
+			{},
+		},
+	}
+
+	abort(resp, ops, 1)
+
+	expected := []Status{StatusRolledBack, StatusFailed, StatusSkipped}
+	for i, status := range expected {
+		if resp.Results[i].Status != status {
+			t.Errorf("result %d: expected %s, got %s", i, status, resp.Results[i].Status)
+		}
+	}
+	if resp.Results[0].Entity != nil {
+		t.Errorf("expected rolled back results to drop their entity")
+	}
+	if resp.Results[2].Op != OpDelete || resp.Results[2].Index != 2 {
+		t.Errorf("expected skipped results to keep index and op, got %+v", resp.Results[2])
+	}
+	if resp.Succeeded != 0 || resp.Committed {
+		t.Errorf("expected nothing to be reported as written")
+	}
+}
+
+func TestAfterHooks(t *testing.T) {
+	resp := &Response{
+		Results: []Result{
+			{Status: StatusOK, hooks: []Hook{{Name: "first"}}},
+			{Status: StatusFailed},
+			{Status: StatusOK, hooks: []Hook{{Name: "second"}, {Name: "third"}}},
+		},
+	}
+
+	if hooks := resp.AfterHooks(); hooks != nil {
+		t.Errorf("expected no hooks before the batch is committed, got %v", hooks)
+	}
+
+	resp.Committed = true
+	hooks := resp.AfterHooks()
+	if len(hooks) != 3 || hooks[0].Name != "first" || hooks[1].Name != "second" || hooks[2].Name != "third" {
+		t.Errorf("expected the hooks of the committed operations in order, got %v", hooks)
+	}
+
+	abort(resp, []Operation{{Op: OpCreate}, {Op: OpSave}, {Op: OpSave}}, 1)
+	resp.Committed = true
+	if hooks := resp.AfterHooks(); hooks != nil {
+		t.Errorf("expected rolled back operations to drop their hooks, got %v", hooks)
+	}
+}
+
+func TestFailure(t *testing.T) {
+	validationErr := &adapters.ValidationError{}
+	validationErr.Add("title", "is required")
+
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{fmt.Errorf("create: %w", validationErr), "validation failed"},
+		{pgx.ErrNoRows, errVersionConflict.Error()},
+		{&pgconn.PgError{Code: uniqueViolation, Message: "duplicate key"}, "an entity with this path and key already exists"},
+		{&pgconn.PgError{Code: "23503", Message: "violates foreign key constraint"}, "database error: violates foreign key constraint"},
+		{errNotFound, errNotFound.Error()},
+	}
+
+	for _, test := range tests {
+		result := failure(test.err)
+		if result.Status != StatusFailed || result.Error != test.expected {
+			t.Errorf("failure(%v): expected %q, got %+v", test.err, test.expected, result)
+		}
+	}
+
+	if result := failure(validationErr); len(result.Errors["title"]) != 1 {
+		t.Errorf("expected field errors, got %v", result.Errors)
+	}
+}
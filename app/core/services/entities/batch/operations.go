@@ -0,0 +1,429 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/locks"
+	"github.com/oriiyx/fritz/app/core/services/entities/publishing"
+	"github.com/oriiyx/fritz/app/core/services/entities/trash"
+	"github.com/oriiyx/fritz/app/core/services/entities/tree"
+	"github.com/oriiyx/fritz/app/core/services/entities/writes"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// uniqueViolation is the postgres error code of a unique constraint violation
+const uniqueViolation = "23505"
+
+var (
+	errVersionConflict = errors.New("entity was modified by another request")
+	errNotFound        = errors.New("entity not found")
+)
+
+// apply runs a single operation, every error is reported on the result
+func apply(ctx context.Context, tx pgx.Tx, queries *db.Queries, op Operation, cfg Config) Result {
+	if err := op.check(); err != nil {
+		return failure(err)
+	}
+
+	result := Result{Status: StatusOK}
+
+	var err error
+	switch op.Op {
+	case OpCreate:
+		err = create(ctx, tx, queries, op, cfg, &result)
+	case OpSave:
+		err = save(ctx, tx, queries, op, cfg, &result)
+	case OpMove:
+		err = move(ctx, queries, op, &result)
+	case OpPublish:
+		err = publish(ctx, tx, queries, op, cfg, &result)
+	case OpDelete:
+		err = remove(ctx, queries, op, cfg, &result)
+	}
+	if err != nil {
+		return failure(err)
+	}
+
+	return result
+}
+
+// trigger runs the callbacks of a before hook, an error vetoes the operation
+func (cfg Config) trigger(ctx context.Context, name string, payload interface{}) error {
+	if cfg.Hooks == nil {
+		return nil
+	}
+	return cfg.Hooks.Trigger(ctx, name, payload)
+}
+
+// after queues an after hook of the operation
+func (r *Result) after(name string, payload interface{}) {
+	r.hooks = append(r.hooks, Hook{Name: name, Payload: payload})
+}
+
+// check reports the fields an operation is missing before anything is written
+func (op Operation) check() error {
+	switch op.Op {
+	case OpCreate:
+		if op.Class == "" || op.Key == "" {
+			return errors.New("create needs class and key")
+		}
+	case OpSave:
+		if op.ID == "" || op.Version == nil || op.Data == nil {
+			return errors.New("save needs id, version and data")
+		}
+	case OpMove:
+		if op.ID == "" || op.Version == nil {
+			return errors.New("move needs id and version")
+		}
+		if op.Path != "" {
+			return errors.New("move derives the path from the parent, give parent_id or key")
+		}
+		if op.ParentID == nil && op.Key == "" {
+			return errors.New("move needs a parent_id or key to change")
+		}
+	case OpPublish:
+		if op.ID == "" || op.Version == nil {
+			return errors.New("publish needs id and version")
+		}
+	case OpDelete:
+		if op.ID == "" {
+			return errors.New("delete needs id")
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	return nil
+}
+
+func create(ctx context.Context, tx pgx.Tx, queries *db.Queries, op Operation, cfg Config, result *Result) error {
+	adapter, err := adapters.Get(op.Class)
+	if err != nil {
+		return errors.New("unknown entity class")
+	}
+
+	var parentID pgtype.UUID
+	if op.ParentID != nil {
+		if parentID, err = parseUUID(*op.ParentID, "parent_id"); err != nil {
+			return err
+		}
+	}
+	parentID, path, err := tree.Locate(ctx, queries, parentID, op.Key, op.Path)
+	if err != nil {
+		return err
+	}
+
+	userID := ctxUtil.GetUserID(ctx)
+	params := db.CreateEntityParams{
+		EntityClass: op.Class,
+		ParentID:    parentID,
+		OKey:        op.Key,
+		OPath:       path,
+		OType:       "object",
+		CreatedBy:   userID,
+		UpdatedBy:   userID,
+	}
+	if op.Type != "" {
+		params.OType = op.Type
+	}
+	if op.Published != nil {
+		params.Published = *op.Published
+	}
+
+	payload := &hooks.EntityCreatePayload{
+		Class: op.Class,
+		Entity: db.Entity{
+			EntityClass: params.EntityClass,
+			ParentID:    params.ParentID,
+			OKey:        params.OKey,
+			OPath:       params.OPath,
+			OType:       params.OType,
+			Published:   params.Published,
+			CreatedBy:   params.CreatedBy,
+			UpdatedBy:   params.UpdatedBy,
+		},
+		Data:   op.Data,
+		UserID: userID,
+	}
+	if err := cfg.trigger(ctx, hooks.HookBeforeEntityCreate, payload); err != nil {
+		return err
+	}
+
+	if err := tree.CheckChild(ctx, queries, params.ParentID, op.Class); err != nil {
+		return err
+	}
+
+	entity, err := queries.CreateEntity(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	var data interface{}
+	if payload.Data != nil {
+		if entity, data, err = writes.Data(ctx, tx, queries, adapter, entity, entity.Version, payload.Data, userID); err != nil {
+			return err
+		}
+		err = cfg.record(ctx, queries, adapter, audit.ActionCreate, nil, entity, data)
+	} else {
+		err = writes.Audit(ctx, queries, adapter, audit.ActionCreate, nil, entity, nil, userID)
+	}
+	if err != nil {
+		return err
+	}
+
+	payload.Entity = entity
+	result.Entity, result.Data = &entity, data
+	result.after(hooks.HookAfterEntityCreate, payload)
+	return nil
+}
+
+// save writes the data of an entity like a single save, a published entity keeps serving its
+// published data and the data goes to its draft
+func save(ctx context.Context, tx pgx.Tx, queries *db.Queries, op Operation, cfg Config, result *Result) error {
+	entity, err := load(ctx, queries, op)
+	if err != nil {
+		return err
+	}
+
+	adapter, err := adapters.Get(entity.EntityClass)
+	if err != nil {
+		return errors.New("unknown entity class")
+	}
+
+	userID := ctxUtil.GetUserID(ctx)
+	payload := &hooks.EntityUpdatePayload{
+		Class:  entity.EntityClass,
+		Entity: entity,
+		Data:   op.Data,
+		UserID: userID,
+		Draft:  entity.Published,
+	}
+	if err := cfg.trigger(ctx, hooks.HookBeforeEntityUpdate, payload); err != nil {
+		return err
+	}
+
+	if entity.Published {
+		entity, draft, err := publishing.SaveAuditedDraft(ctx, queries, adapter.WithTx(tx), entity, *op.Version, payload.Data, userID)
+		if err != nil {
+			return err
+		}
+		payload.Entity = entity
+
+		result.Entity, result.Draft = &entity, draft.Data
+		result.after(hooks.HookAfterEntityUpdate, payload)
+		return nil
+	}
+
+	before, err := audit.ReadEntityState(ctx, adapter.WithTx(tx), entity)
+	if err != nil {
+		return err
+	}
+
+	entity, data, err := writes.Data(ctx, tx, queries, adapter, entity, *op.Version, payload.Data, userID)
+	if err != nil {
+		return err
+	}
+	if err := cfg.record(ctx, queries, adapter, audit.ActionUpdate, before, entity, data); err != nil {
+		return err
+	}
+
+	payload.Entity = entity
+	result.Entity, result.Data = &entity, data
+	result.after(hooks.HookAfterEntityUpdate, payload)
+	return nil
+}
+
+// record writes the version history record and the audit entry of a write to the live data of
+// an entity, before is nil for a create
+func (cfg Config) record(ctx context.Context, queries *db.Queries, adapter adapters.EntityAdapter, action string, before audit.State, entity db.Entity, data interface{}) error {
+	return writes.Record(ctx, queries, adapter, action, before, entity, data, ctxUtil.GetUserID(ctx), cfg.Policy)
+}
+
+// move places the entity under a new parent or key at the expected version, the paths of the
+// entity and its descendants are derived from the parent
+func move(ctx context.Context, queries *db.Queries, op Operation, result *Result) error {
+	entity, err := load(ctx, queries, op)
+	if err != nil {
+		return err
+	}
+
+	parentID, key := entity.ParentID, entity.OKey
+	if op.ParentID != nil {
+		// An empty parent_id moves the entity to the root
+		if parentID, err = parseUUID(tree.RootEntityID, "parent_id"); err != nil {
+			return err
+		}
+		if *op.ParentID != "" {
+			if parentID, err = parseUUID(*op.ParentID, "parent_id"); err != nil {
+				return err
+			}
+		}
+	}
+	if op.Key != "" {
+		key = op.Key
+	}
+
+	entity, _, err = tree.Move(ctx, queries, entity, parentID, key, *op.Version, ctxUtil.GetUserID(ctx))
+	if err != nil {
+		return err
+	}
+
+	result.Entity = &entity
+	return nil
+}
+
+// publish publishes an entity, or unpublishes it when published is false, like the publish
+// endpoints. Publishing writes the pending draft to the data row.
+func publish(ctx context.Context, tx pgx.Tx, queries *db.Queries, op Operation, cfg Config, result *Result) error {
+	entity, err := load(ctx, queries, op)
+	if err != nil {
+		return err
+	}
+
+	adapter, err := adapters.Get(entity.EntityClass)
+	if err != nil {
+		return errors.New("unknown entity class")
+	}
+
+	published := op.Published == nil || *op.Published
+	beforeHook, afterHook, action := hooks.HookBeforeEntityUnpublish, hooks.HookAfterEntityUnpublish, audit.ActionUnpublish
+	if published {
+		beforeHook, afterHook, action = hooks.HookBeforeEntityPublish, hooks.HookAfterEntityPublish, audit.ActionPublish
+	}
+
+	userID := ctxUtil.GetUserID(ctx)
+	payload := &hooks.EntityPublishPayload{Class: entity.EntityClass, Entity: entity, UserID: userID}
+	if err := cfg.trigger(ctx, beforeHook, payload); err != nil {
+		return err
+	}
+
+	before, err := audit.ReadEntityState(ctx, adapter.WithTx(tx), entity)
+	if err != nil {
+		return err
+	}
+
+	previous := entity.Version
+
+	var data interface{}
+	if published {
+		entity, data, err = publishing.Publish(ctx, tx, queries, adapter, entity, *op.Version, userID)
+	} else {
+		entity, err = publishing.Unpublish(ctx, queries, entity, *op.Version, userID)
+		if err == nil && entity.HasData {
+			data, err = adapter.WithTx(tx).Read(ctx, entity.ID)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	result.Entity, result.Data = &entity, data
+
+	// Nothing changed, an entity already in the requested state is not written
+	if entity.Version == previous {
+		return nil
+	}
+
+	if err := cfg.record(ctx, queries, adapter, action, before, entity, data); err != nil {
+		return err
+	}
+
+	payload.Entity = entity
+	result.after(afterHook, payload)
+	return nil
+}
+
+// remove moves the entity and its descendants to the trash
+func remove(ctx context.Context, queries *db.Queries, op Operation, cfg Config, result *Result) error {
+	entity, err := load(ctx, queries, op)
+	if err != nil {
+		return err
+	}
+
+	payload := &hooks.EntityDeletePayload{
+		Class:  entity.EntityClass,
+		Entity: entity,
+		UserID: ctxUtil.GetUserID(ctx),
+	}
+	if err := cfg.trigger(ctx, hooks.HookBeforeEntityDelete, payload); err != nil {
+		return err
+	}
+
+	if _, err = trash.Trash(ctx, queries, entity.ID, payload.UserID); err != nil {
+		return err
+	}
+
+	result.after(hooks.HookAfterEntityDelete, payload)
+	return nil
+}
+
+// load reads the entity of an operation and checks its class, its edit lock and, when given, its
+// version
+func load(ctx context.Context, queries *db.Queries, op Operation) (db.Entity, error) {
+	id, err := parseUUID(op.ID, "id")
+	if err != nil {
+		return db.Entity{}, err
+	}
+
+	entity, err := queries.GetEntityByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity, errNotFound
+	}
+	if err != nil {
+		return entity, err
+	}
+
+	if op.Class != "" && entity.EntityClass != op.Class {
+		return entity, fmt.Errorf("entity is a %s, not a %s", entity.EntityClass, op.Class)
+	}
+	if op.Version != nil && entity.Version != *op.Version {
+		return entity, errVersionConflict
+	}
+	if err := locks.Check(ctx, queries, entity.ID, ctxUtil.GetUserID(ctx)); err != nil {
+		return entity, err
+	}
+
+	return entity, nil
+}
+
+func parseUUID(value, field string) (pgtype.UUID, error) {
+	var id pgtype.UUID
+	if err := id.Scan(value); err != nil {
+		return id, fmt.Errorf("invalid %s", field)
+	}
+	return id, nil
+}
+
+// failure turns an operation error into its result, database details are not exposed
+func failure(err error) Result {
+	result := Result{Status: StatusFailed}
+
+	var pgErr *pgconn.PgError
+	validationErr, isValidation := adapters.AsValidationError(err)
+
+	switch {
+	case isValidation:
+		result.Error = "validation failed"
+		result.Errors = validationErr.Fields
+	case errors.Is(err, pgx.ErrNoRows):
+		// Version checked writes match no rows when a concurrent write got there first
+		result.Error = errVersionConflict.Error()
+	case errors.As(err, &pgErr) && pgErr.Code == uniqueViolation:
+		result.Error = "an entity with this path and key already exists"
+	case errors.As(err, &pgErr):
+		result.Error = "database error: " + pgErr.Message
+	default:
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,329 @@
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/tree"
+	"github.com/oriiyx/fritz/app/core/services/entities/versions"
+	"github.com/oriiyx/fritz/app/core/services/entities/writes"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	validatorUtil "github.com/oriiyx/fritz/app/core/utils/validator"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+const (
+	// MaxCopyKeyAttempts bounds the suffixes tried for a free key under the target parent
+	MaxCopyKeyAttempts = 100
+
+	// uniqueViolation is the postgres error code of a unique constraint violation
+	uniqueViolation = "23505"
+)
+
+var errCopyKeyTaken = errors.New("no free key for the copy under the target parent")
+
+// CopyEntityRequest - the target parent, an optional new key and whether descendants are copied
+type CopyEntityRequest struct {
+	ParentID  string `json:"parent_id" validate:"required"`
+	Key       string `json:"key,omitempty" validate:"omitempty,max=255"`
+	Recursive bool   `json:"recursive"`
+}
+
+// copier copies entities and their data rows inside a single transaction
+type copier struct {
+	h        *Handler
+	tx       pgx.Tx
+	queries  *db.Queries
+	userID   pgtype.UUID
+	children map[pgtype.UUID][]db.Entity
+	count    int
+
+	// created holds the create hook payload of every copy, its after hooks run once committed
+	created []*hooks.EntityCreatePayload
+}
+
+// CopyEntity is an endpoint that duplicates an entity and its data row under a target parent. In
+// recursive mode every descendant is copied as well with its path rewritten below the copy. A key
+// that is taken under the target parent gets a -copy suffix. Copies start unpublished.
+func (h *Handler) CopyEntity(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	var req CopyEntityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to decode request")
+		errhandler.BadRequest(w, errhandler.RespInvalidRequestBody)
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to marshal validation errors")
+			errhandler.ServerError(w, errhandler.RespJSONEncodeFailure)
+			return
+		}
+		errhandler.ValidationErrors(w, respBody)
+		return
+	}
+
+	source, _, ok := h.loadEntity(w, r, reqID)
+	if !ok {
+		return
+	}
+	if tree.IsRoot(source.ID) {
+		errhandler.BadRequest(w, []byte(`{"error": "the root entity cannot be copied"}`))
+		return
+	}
+
+	var parentID pgtype.UUID
+	if err := parentID.Scan(req.ParentID); err != nil {
+		errhandler.BadRequest(w, []byte(`{"error": "invalid parent_id"}`))
+		return
+	}
+
+	parent, err := h.Queries.GetEntityByID(r.Context(), parentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "target parent not found"}`))
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to load target parent")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	key := source.OKey
+	if req.Key != "" {
+		key = req.Key
+	}
+
+	var root db.Entity
+	c := &copier{h: h, userID: ctxUtil.GetUserID(r.Context())}
+	err = h.inTx(r.Context(), func(tx pgx.Tx, queries *db.Queries) error {
+		c.tx, c.queries = tx, queries
+
+		// The subtree is read before anything is written so a copy into its own subtree does
+		// not copy itself again
+		if req.Recursive {
+			subtree, err := queries.ListEntitySubtree(r.Context(), source.ID)
+			if err != nil {
+				return err
+			}
+			c.children = make(map[pgtype.UUID][]db.Entity, len(subtree))
+			for _, entity := range subtree {
+				if entity.ID != source.ID {
+					c.children[entity.ParentID] = append(c.children[entity.ParentID], entity)
+				}
+			}
+		}
+
+		if err := tree.CheckChild(r.Context(), queries, parent.ID, source.EntityClass); err != nil {
+			return err
+		}
+
+		key, err := c.freeKey(r.Context(), parent.OPath, key)
+		if err != nil {
+			return err
+		}
+
+		root, err = c.copy(r.Context(), source, parent, key)
+		if err != nil {
+			return err
+		}
+
+		after, err := audit.ObjectState(map[string]interface{}{
+			"source_id":    source.ID,
+			"parent_id":    root.ParentID,
+			"o_key":        root.OKey,
+			"o_path":       root.OPath,
+			"entity_count": c.count,
+		})
+		if err != nil {
+			return err
+		}
+		return audit.RecordEntity(r.Context(), queries, audit.ActionCopy, root.ID, nil, after)
+	})
+	if err != nil {
+		if h.writeVetoError(w, reqID, err) {
+			return
+		}
+
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, errCopyKeyTaken), errors.Is(err, tree.ErrClassNotAllowed):
+			errhandler.UnprocessableEntity(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+		case errors.As(err, &pgErr) && pgErr.Code == uniqueViolation:
+			errhandler.Conflict(w, []byte(`{"error": "an entity with this path and key already exists"}`))
+		default:
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to copy entity")
+			errhandler.ServerError(w, errhandler.RespDBDataInsertFailure)
+		}
+		return
+	}
+
+	h.Logger.Info().
+		Str("source_id", source.ID.String()).
+		Str("entity_id", root.ID.String()).
+		Bool("recursive", req.Recursive).
+		Int("count", c.count).
+		Msg("Entity copied")
+
+	for _, payload := range c.created {
+		h.triggerAfter(r, reqID, hooks.HookAfterEntityCreate, payload)
+	}
+
+	response := map[string]interface{}{
+		"entity": root,
+		"count":  c.count,
+	}
+
+	setETag(w, root.Version)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// freeKey returns the first key for a copy that is not taken under the parent path
+func (c *copier) freeKey(ctx context.Context, parentPath, key string) (string, error) {
+	for attempt := 0; attempt < MaxCopyKeyAttempts; attempt++ {
+		candidate := tree.SuffixKey(key, attempt)
+
+		_, err := c.queries.GetEntityByPath(ctx, db.GetEntityByPathParams{
+			OPath: tree.ChildPath(parentPath, candidate),
+			OKey:  candidate,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return "", errCopyKeyTaken
+}
+
+// copy writes a copy of source with key under parent, then copies the children of source below it.
+// Every copy runs the create hooks, a before hook that fails vetoes the whole copy.
+func (c *copier) copy(ctx context.Context, source, parent db.Entity, key string) (db.Entity, error) {
+	var (
+		adapter adapters.EntityAdapter
+		data    map[string]interface{}
+		err     error
+	)
+	if source.HasData {
+		if adapter, data, err = c.readData(ctx, source); err != nil {
+			return db.Entity{}, err
+		}
+	}
+
+	params := db.CreateEntityParams{
+		EntityClass: source.EntityClass,
+		ParentID:    parent.ID,
+		OKey:        key,
+		OPath:       tree.ChildPath(parent.OPath, key),
+		OType:       source.OType,
+		Published:   false,
+		HasData:     false, // The data row, if any, flips this below
+		CreatedBy:   c.userID,
+		UpdatedBy:   c.userID,
+	}
+
+	payload := &hooks.EntityCreatePayload{
+		Class: params.EntityClass,
+		Entity: db.Entity{
+			EntityClass: params.EntityClass,
+			ParentID:    params.ParentID,
+			OKey:        params.OKey,
+			OPath:       params.OPath,
+			OType:       params.OType,
+			Published:   params.Published,
+			CreatedBy:   params.CreatedBy,
+			UpdatedBy:   params.UpdatedBy,
+		},
+		Data:   data,
+		UserID: c.userID,
+	}
+	if err := c.h.trigger(ctx, hooks.HookBeforeEntityCreate, payload); err != nil {
+		return db.Entity{}, err
+	}
+
+	entity, err := c.queries.CreateEntity(ctx, params)
+	if err != nil {
+		return entity, err
+	}
+	c.count++
+
+	if payload.Data != nil {
+		if adapter == nil {
+			if adapter, err = adapters.Get(source.EntityClass); err != nil {
+				return entity, err
+			}
+		}
+		if entity, err = c.writeData(ctx, adapter, entity, payload.Data); err != nil {
+			return entity, err
+		}
+	}
+	if source.EntityClass == tree.FolderClass {
+		err = c.queries.CopyEntityFolder(ctx, db.CopyEntityFolderParams{EntityID: entity.ID, SourceID: source.ID})
+		if err != nil {
+			return entity, err
+		}
+	}
+
+	payload.Entity = entity
+	c.created = append(c.created, payload)
+
+	// Keys of the children are unique under the source, so they are free under the fresh copy
+	for _, child := range c.children[source.ID] {
+		if _, err := c.copy(ctx, child, entity, child.OKey); err != nil {
+			return entity, err
+		}
+	}
+
+	return entity, nil
+}
+
+// readData reads the data row of source through the adapter of its class as the data of a write
+func (c *copier) readData(ctx context.Context, source db.Entity) (adapters.EntityAdapter, map[string]interface{}, error) {
+	adapter, err := adapters.Get(source.EntityClass)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	row, err := adapter.WithTx(c.tx).Read(ctx, source.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshot, err := versions.Snapshot(row, adapter.Columns())
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := versions.RestoreData(snapshot, adapter.Columns())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return adapter, data, nil
+}
+
+// writeData creates the data row of the copy entity
+func (c *copier) writeData(ctx context.Context, adapter adapters.EntityAdapter, entity db.Entity, data map[string]interface{}) (db.Entity, error) {
+	entity, result, err := writes.Data(ctx, c.tx, c.queries, adapter, entity, entity.Version, data, c.userID)
+	if err != nil {
+		return entity, err
+	}
+
+	return entity, c.h.recordVersion(ctx, c.queries, adapter, entity, result)
+}
@@ -2,25 +2,33 @@ package entities
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
 	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/audit"
 	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/tree"
+	"github.com/oriiyx/fritz/app/core/services/entities/writes"
 	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
 	validatorUtil "github.com/oriiyx/fritz/app/core/utils/validator"
 	db "github.com/oriiyx/fritz/database/generated"
 )
 
-// CreateEntityRequest - metadata only (no data field)
+// CreateEntityRequest - metadata with optional data, both are written in one transaction
 type CreateEntityRequest struct {
-	ParentID  *string `json:"parent_id,omitempty"`
-	Key       string  `json:"key" validate:"required,max=255"`
-	Path      string  `json:"path" validate:"required"`
-	Type      string  `json:"type,omitempty"`
-	Published bool    `json:"published"`
+	ParentID  *string                `json:"parent_id,omitempty"`
+	Key       string                 `json:"key" validate:"required,max=255"`
+	Path      string                 `json:"path,omitempty"`
+	Type      string                 `json:"type,omitempty"`
+	Published bool                   `json:"published"`
+	Data      map[string]interface{} `json:"data,omitempty"`
 }
 
 // TransitionEntityRequest - for saving actual entity data
@@ -28,7 +36,10 @@ type TransitionEntityRequest struct {
 	Data map[string]interface{} `json:"data" validate:"required"`
 }
 
-// CreateEntity creates a new entity instance (metadata only, no data yet)
+// CreateEntity creates a new entity instance. Without data only the metadata is created and the
+// data follows with TransitionEntity, with data the metadata and data row are created atomically.
+// The path is derived from the parent and the key, a given path must match it. Without a parent the
+// path names it, with neither the entity goes under the root.
 func (h *Handler) CreateEntity(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 	classID := chi.URLParam(r, DefinitionIDKey)
@@ -53,7 +64,7 @@ func (h *Handler) CreateEntity(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify adapter exists (validate class ID)
-	_, err := adapters.Get(classID)
+	adapter, err := adapters.Get(classID)
 	if err != nil {
 		h.Logger.Error().Err(err).Str("class_id", classID).Msg("Unknown entity class")
 		errhandler.BadRequest(w, []byte(`{"error": "unknown entity class"}`))
@@ -75,25 +86,88 @@ func (h *Handler) CreateEntity(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// TODO: Get user ID from session/context
-	var userID pgtype.UUID
+	parentID, path, err := tree.Locate(r.Context(), h.Queries, parentID, req.Key, req.Path)
+	if err != nil {
+		switch {
+		case errors.Is(err, tree.ErrParentNotFound):
+			errhandler.NotFound(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+		case errors.Is(err, tree.ErrPathMismatch), errors.Is(err, tree.ErrInvalidPath):
+			errhandler.UnprocessableEntity(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+		default:
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to load parent entity")
+			errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		}
+		return
+	}
+
+	userID := ctxUtil.GetUserID(r.Context())
 
-	// Create entity record in entities table ONLY (no data table entry yet)
 	entityParams := db.CreateEntityParams{
 		EntityClass: classID,
 		ParentID:    parentID,
 		OKey:        req.Key,
-		OPath:       req.Path,
+		OPath:       path,
 		OType:       entityType,
 		Published:   req.Published,
-		HasData:     false, // Mark as having no data yet
+		HasData:     false, // The data row, if any, flips this below
 		CreatedBy:   userID,
 		UpdatedBy:   userID,
 	}
 
-	entity, err := h.Queries.CreateEntity(r.Context(), entityParams)
+	payload := &hooks.EntityCreatePayload{
+		Class: classID,
+		Entity: db.Entity{
+			EntityClass: entityParams.EntityClass,
+			ParentID:    entityParams.ParentID,
+			OKey:        entityParams.OKey,
+			OPath:       entityParams.OPath,
+			OType:       entityParams.OType,
+			Published:   entityParams.Published,
+			CreatedBy:   entityParams.CreatedBy,
+			UpdatedBy:   entityParams.UpdatedBy,
+		},
+		Data:   req.Data,
+		UserID: userID,
+	}
+	if !h.triggerBefore(w, r, reqID, hooks.HookBeforeEntityCreate, payload) {
+		return
+	}
+	req.Data = payload.Data
+
+	var (
+		entity db.Entity
+		result interface{}
+	)
+	err = h.inTx(r.Context(), func(tx pgx.Tx, queries *db.Queries) error {
+		if err := tree.CheckChild(r.Context(), queries, parentID, classID); err != nil {
+			return err
+		}
+
+		var err error
+		entity, err = queries.CreateEntity(r.Context(), entityParams)
+		if err != nil {
+			return err
+		}
+
+		if req.Data != nil {
+			entity, result, err = writes.Data(r.Context(), tx, queries, adapter, entity, entity.Version, req.Data, userID)
+			if err != nil {
+				return err
+			}
+			return h.record(r.Context(), queries, adapter, audit.ActionCreate, nil, entity, result)
+		}
+
+		return writes.Audit(r.Context(), queries, adapter, audit.ActionCreate, nil, entity, nil, userID)
+	})
 	if err != nil {
-		h.Logger.Error().Err(err).Msg("Failed to create entity record")
+		if errors.Is(err, tree.ErrClassNotAllowed) {
+			errhandler.UnprocessableEntity(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+			return
+		}
+		if h.writeValidationError(w, reqID, err) {
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to create entity")
 		errhandler.ServerError(w, errhandler.RespDBDataInsertFailure)
 		return
 	}
@@ -102,12 +176,20 @@ func (h *Handler) CreateEntity(w http.ResponseWriter, r *http.Request) {
 		Str("entity_id", entity.ID.String()).
 		Str("class_id", classID).
 		Str("key", req.Key).
-		Msg("Entity metadata created successfully (no data yet)")
+		Bool("has_data", entity.HasData).
+		Msg("Entity created successfully")
+
+	payload.Entity = entity
+	h.triggerAfter(r, reqID, hooks.HookAfterEntityCreate, payload)
 
 	response := map[string]interface{}{
 		"entity": entity,
 	}
+	if req.Data != nil {
+		response["data"] = result
+	}
 
+	setETag(w, entity.Version)
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(response)
 }
@@ -137,6 +219,11 @@ func (h *Handler) TransitionEntity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expectedVersion, ok := h.requireIfMatch(w, r, reqID)
+	if !ok {
+		return
+	}
+
 	// Get the adapter for this entity class
 	adapter, err := adapters.Get(classID)
 	if err != nil {
@@ -169,56 +256,82 @@ func (h *Handler) TransitionEntity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if entity already has data (determines create vs update)
+	if !h.checkLock(w, r, reqID, entity.ID) {
+		return
+	}
+
+	if entity.Version != expectedVersion {
+		errhandler.PreconditionFailed(w, errhandler.RespEntityVersionConflict)
+		return
+	}
+
+	payload := &hooks.EntityUpdatePayload{
+		Class:  classID,
+		Entity: entity,
+		Data:   req.Data,
+		UserID: ctxUtil.GetUserID(r.Context()),
+		Draft:  entity.Published,
+	}
+	if !h.triggerBefore(w, r, reqID, hooks.HookBeforeEntityUpdate, payload) {
+		return
+	}
+	req.Data = payload.Data
+
+	// A published entity keeps serving its published content, the data goes to its draft
+	if entity.Published {
+		var draft db.EntityDraft
+		entity, draft, err = h.saveDraft(r.Context(), adapter, entity, expectedVersion, req.Data)
+		if err == nil {
+			payload.Entity = entity
+			h.triggerAfter(r, reqID, hooks.HookAfterEntityUpdate, payload)
+		}
+		h.writeDraft(w, reqID, entity, draft, err)
+		return
+	}
+
+	// Check if entity already has data (determines create vs update), the data row and the
+	// metadata are written together
 	var result interface{}
-	if !entity.HasData {
-		// First time saving - CREATE in data table
-		result, err = adapter.Create(r.Context(), entity.ID, req.Data)
+	err = h.inTx(r.Context(), func(tx pgx.Tx, queries *db.Queries) error {
+		before, err := audit.ReadEntityState(r.Context(), adapter.WithTx(tx), entity)
 		if err != nil {
-			h.Logger.Error().Err(err).Msg("Failed to create entity data")
-			errhandler.ServerError(w, errhandler.RespDBDataInsertFailure)
-			return
+			return err
 		}
 
-		// Update has_data flag
-		_, err = h.Queries.UpdateEntity(r.Context(), db.UpdateEntityParams{
-			ID:        entity.ID,
-			ParentID:  entity.ParentID,
-			OKey:      entity.OKey,
-			OPath:     entity.OPath,
-			Published: entity.Published,
-			HasData:   true, // Mark as having data now
-			UpdatedBy: entity.UpdatedBy,
-		})
+		// The first save creates the data row and flips has_data, later saves replace it
+		entity, result, err = writes.Data(r.Context(), tx, queries, adapter, entity, expectedVersion, req.Data, ctxUtil.GetUserID(r.Context()))
 		if err != nil {
-			h.Logger.Error().Err(err).Msg("Failed to update has_data flag")
-			// Non-fatal - data was saved, flag update failed
+			return err
 		}
 
-		h.Logger.Info().
-			Str("entity_id", entityID).
-			Str("class_id", classID).
-			Msg("Entity data created (first save)")
-	} else {
-		// Subsequent saves - UPDATE in data table
-		result, err = adapter.Update(r.Context(), entity.ID, req.Data)
-		if err != nil {
-			h.Logger.Error().Err(err).Msg("Failed to update entity data")
-			errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return h.record(r.Context(), queries, adapter, audit.ActionUpdate, before, entity, result)
+	})
+	if err != nil {
+		if h.writeValidationError(w, reqID, err) {
 			return
 		}
-
-		h.Logger.Info().
-			Str("entity_id", entityID).
-			Str("class_id", classID).
-			Msg("Entity data updated")
+		if h.writeVersionConflict(w, reqID, err) {
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to save entity data")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return
 	}
 
+	h.Logger.Info().
+		Str("entity_id", entityID).
+		Str("class_id", classID).
+		Msg("Entity data saved")
+
+	payload.Entity = entity
+	h.triggerAfter(r, reqID, hooks.HookAfterEntityUpdate, payload)
+
 	response := map[string]interface{}{
 		"entity": entity,
 		"data":   result,
 	}
 
+	setETag(w, entity.Version)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(response)
 }
@@ -2,20 +2,26 @@ package entities
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
 	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/entities/trash"
 	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
 	validatorUtil "github.com/oriiyx/fritz/app/core/utils/validator"
+	db "github.com/oriiyx/fritz/database/generated"
 )
 
 type DeleteEntityRequest struct {
 	ID string `json:"id" validate:"required"`
 }
 
-// DeleteEntity is an endpoint that handles deleting entity
+// DeleteEntity is an endpoint that moves an entity and its descendants to the trash, it returns
+// the trash item they can be restored from
 func (h *Handler) DeleteEntity(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
@@ -44,12 +50,51 @@ func (h *Handler) DeleteEntity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.Queries.DeleteEntity(r.Context(), entityID)
+	entity, err := h.Queries.GetEntityByID(r.Context(), entityID)
 	if err != nil {
-		h.Logger.Error().Err(err).Msg("Failed to delete entity record")
-		errhandler.ServerError(w, errhandler.RespDBDataInsertFailure)
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "entity not found"}`))
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to load entity")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	if !h.checkLock(w, r, reqID, entityID) {
 		return
 	}
 
+	payload := &hooks.EntityDeletePayload{
+		Class:  entity.EntityClass,
+		Entity: entity,
+		UserID: ctxUtil.GetUserID(r.Context()),
+	}
+	if !h.triggerBefore(w, r, reqID, hooks.HookBeforeEntityDelete, payload) {
+		return
+	}
+
+	var item db.TrashItem
+	err = h.inTx(r.Context(), func(tx pgx.Tx, queries *db.Queries) error {
+		var err error
+		item, err = trash.Trash(r.Context(), queries, entityID, payload.UserID)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, trash.ErrNotFound):
+			errhandler.NotFound(w, []byte(`{"error": "entity not found"}`))
+		case errors.Is(err, trash.ErrRootEntity):
+			errhandler.BadRequest(w, []byte(`{"error": "the root entity cannot be deleted"}`))
+		default:
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to move entity to the trash")
+			errhandler.ServerError(w, errhandler.RespDBDataRemoveFailure)
+		}
+		return
+	}
+
+	h.triggerAfter(r, reqID, hooks.HookAfterEntityDelete, payload)
+
 	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(item)
 }
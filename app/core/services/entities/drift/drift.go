@@ -0,0 +1,192 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/objects/definition_builder"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// Policies that decide what happens at startup when drift is detected
+const (
+	PolicyStrict = "strict" // refuse to start
+	PolicyWarn   = "warn"   // log warnings and continue
+	PolicyOff    = "off"    // skip the check entirely
+)
+
+// ValidatePolicy rejects anything but the known policies, a misspelled strict must not quietly warn
+func ValidatePolicy(policy string) error {
+	switch policy {
+	case PolicyStrict, PolicyWarn, PolicyOff:
+		return nil
+	}
+	return fmt.Errorf("unknown definitions drift policy %q, expected %s, %s or %s", policy, PolicyStrict, PolicyWarn, PolicyOff)
+}
+
+// baseColumns are created for entity tables and are not part of the definition, the search
+// vector is generated from the searchable components
+var baseColumns = map[string]bool{
+	"id":         true,
+	"entity_id":  true,
+	"created_at": true,
+	"updated_at": true,
+
+	definitions.SearchVectorColumn: true,
+}
+
+// ClassReport holds the drift findings for a single entity class
+type ClassReport struct {
+	ClassID     string   `json:"class_id"`
+	AdapterHash string   `json:"adapter_hash,omitempty"`
+	StoredHash  string   `json:"stored_hash,omitempty"`
+	Problems    []string `json:"problems"`
+}
+
+// Report is the result of comparing compiled adapters, stored definitions and live tables
+type Report struct {
+	Policy    string        `json:"policy"`
+	Drift     bool          `json:"drift"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Classes   []ClassReport `json:"classes"`
+}
+
+type Checker struct {
+	queries *db.Queries
+	policy  string
+}
+
+func NewChecker(queries *db.Queries, policy string) *Checker {
+	return &Checker{
+		queries: queries,
+		policy:  policy,
+	}
+}
+
+// Check compares every registered adapter with definition_schemas and information_schema.columns
+func (c *Checker) Check(ctx context.Context) (*Report, error) {
+	report := &Report{
+		Policy:    c.policy,
+		CheckedAt: time.Now().UTC(),
+		Classes:   make([]ClassReport, 0),
+	}
+
+	stored, err := c.queries.GetAllDefinitionSchemas(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load definition schemas: %w", err)
+	}
+
+	storedByID := make(map[string]db.DefinitionSchema, len(stored))
+	for _, schema := range stored {
+		storedByID[schema.ID] = schema
+	}
+
+	registered := adapters.Registered()
+	compiled := make(map[string]bool, len(registered))
+
+	for _, classID := range registered {
+		compiled[classID] = true
+
+		adapter, err := adapters.Get(classID)
+		if err != nil {
+			return nil, err
+		}
+
+		classReport := ClassReport{
+			ClassID:     classID,
+			AdapterHash: adapter.SchemaHash(),
+			Problems:    make([]string, 0),
+		}
+
+		schema, exists := storedByID[classID]
+		if !exists {
+			classReport.Problems = append(classReport.Problems, "adapter is compiled but no definition schema is stored")
+		} else {
+			classReport.StoredHash = schema.SchemaHash
+			if schema.SchemaHash != adapter.SchemaHash() {
+				classReport.Problems = append(classReport.Problems, "adapter schema hash does not match stored definition schema hash")
+			}
+		}
+
+		columnProblems, err := c.checkColumns(ctx, classID, adapter.Columns())
+		if err != nil {
+			return nil, err
+		}
+		classReport.Problems = append(classReport.Problems, columnProblems...)
+
+		report.addClass(classReport)
+	}
+
+	for _, schema := range stored {
+		if compiled[schema.ID] {
+			continue
+		}
+
+		report.addClass(ClassReport{
+			ClassID:    schema.ID,
+			StoredHash: schema.SchemaHash,
+			Problems:   []string{"definition schema is stored but no adapter is compiled into this binary"},
+		})
+	}
+
+	return report, nil
+}
+
+// checkColumns compares the columns an adapter expects with the live entity table
+func (c *Checker) checkColumns(ctx context.Context, classID string, expected []adapters.Column) ([]string, error) {
+	tableName := definition_builder.EntityTableName(classID)
+	problems := make([]string, 0)
+
+	live, err := c.queries.GetTableColumns(ctx, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load columns of %s: %w", tableName, err)
+	}
+
+	if len(live) == 0 {
+		return append(problems, fmt.Sprintf("table %s does not exist", tableName)), nil
+	}
+
+	liveByName := make(map[string]db.GetTableColumnsRow, len(live))
+	for _, column := range live {
+		liveByName[column.ColumnName] = column
+	}
+
+	expectedByName := make(map[string]bool, len(expected))
+	for _, column := range expected {
+		expectedByName[column.Name] = true
+
+		liveColumn, exists := liveByName[column.Name]
+		if !exists {
+			problems = append(problems, fmt.Sprintf("column %s is missing from table %s", column.Name, tableName))
+			continue
+		}
+
+		expectedUDT := definitions.DBType(column.DBType).UDTName()
+		if liveColumn.UdtName != expectedUDT {
+			problems = append(problems, fmt.Sprintf("column %s has type %s, adapter expects %s", column.Name, liveColumn.UdtName, expectedUDT))
+		}
+
+		if liveColumn.IsNullable != column.Nullable {
+			problems = append(problems, fmt.Sprintf("column %s nullability is %t, adapter expects %t", column.Name, liveColumn.IsNullable, column.Nullable))
+		}
+	}
+
+	for _, column := range live {
+		if baseColumns[column.ColumnName] || expectedByName[column.ColumnName] {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("column %s exists in table %s but is unknown to the adapter", column.ColumnName, tableName))
+	}
+
+	return problems, nil
+}
+
+func (r *Report) addClass(classReport ClassReport) {
+	if len(classReport.Problems) > 0 {
+		r.Drift = true
+	}
+	r.Classes = append(r.Classes, classReport)
+}
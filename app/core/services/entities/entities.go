@@ -8,6 +8,7 @@ import (
 	"github.com/oriiyx/fritz/app/core/api/base"
 	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
 	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
 	"github.com/oriiyx/fritz/app/core/services/objects/definition_builder"
 	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
 	validatorUtil "github.com/oriiyx/fritz/app/core/utils/validator"
@@ -33,6 +34,25 @@ func New(ctrl *base.HandlerController) *Handler {
 	}
 }
 
+// writeValidationError responds with 422 and the per-field messages when err is an adapter ValidationError
+func (h *Handler) writeValidationError(w http.ResponseWriter, reqID string, err error) bool {
+	validationErr, ok := adapters.AsValidationError(err)
+	if !ok {
+		return false
+	}
+
+	respBody, err := json.Marshal(validationErr)
+	if err != nil {
+		h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to marshal validation errors")
+		errhandler.ServerError(w, errhandler.RespJSONEncodeFailure)
+		return true
+	}
+
+	h.Logger.Warn().Str(l.KeyReqID, reqID).Err(validationErr).Msg("Entity data failed validation")
+	errhandler.UnprocessableEntity(w, respBody)
+	return true
+}
+
 type GetEntityDataRequest struct {
 	ID string `json:"id" validate:"required"`
 }
@@ -73,6 +93,7 @@ func (h *Handler) GetEntityData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, entity.Version)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(entity)
 }
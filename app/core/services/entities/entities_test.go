@@ -0,0 +1,53 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/oriiyx/fritz/app/core/api/base"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/rs/zerolog"
+)
+
+func newTestHandler() *Handler {
+	logger := zerolog.Nop()
+	return &Handler{HandlerController: &base.HandlerController{Logger: &logger}}
+}
+
+func TestWriteValidationError(t *testing.T) {
+	h := newTestHandler()
+
+	var validationErr adapters.ValidationError
+	validationErr.Add("title", "is required")
+
+	w := httptest.NewRecorder()
+	if !h.writeValidationError(w, "req", fmt.Errorf("failed to save: %w", &validationErr)) {
+		t.Fatal("expected a validation error to be written")
+	}
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+
+	var body struct {
+		Errors map[string][]string `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string][]string{"title": {"is required"}}; !reflect.DeepEqual(body.Errors, want) {
+		t.Errorf("expected errors %v, got %v", want, body.Errors)
+	}
+
+	w = httptest.NewRecorder()
+	if h.writeValidationError(w, "req", errors.New("connection refused")) {
+		t.Error("expected other errors to be left to the caller")
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected nothing to be written, got %s", w.Body.String())
+	}
+}
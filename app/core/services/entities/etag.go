@@ -0,0 +1,61 @@
+package entities
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+)
+
+var errIfMatchMissing = errors.New("if-match header missing")
+
+// setETag exposes the entity version so clients can send it back in If-Match
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// parseIfMatch reads the entity version a client expects to overwrite
+func parseIfMatch(r *http.Request) (int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, errIfMatchMissing
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// requireIfMatch writes 428 or 400 and returns false when the request carries no usable If-Match
+func (h *Handler) requireIfMatch(w http.ResponseWriter, r *http.Request, reqID string) (int64, bool) {
+	version, err := parseIfMatch(r)
+	if errors.Is(err, errIfMatchMissing) {
+		errhandler.PreconditionRequired(w, errhandler.RespIfMatchRequired)
+		return 0, false
+	}
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Invalid If-Match header")
+		errhandler.BadRequest(w, errhandler.RespInvalidIfMatch)
+		return 0, false
+	}
+
+	return version, true
+}
+
+// writeVersionConflict responds with 412 when a version checked write matched no rows
+func (h *Handler) writeVersionConflict(w http.ResponseWriter, reqID string, err error) bool {
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return false
+	}
+
+	h.Logger.Warn().Str(l.KeyReqID, reqID).Msg("Entity version conflict")
+	errhandler.PreconditionFailed(w, errhandler.RespEntityVersionConflict)
+	return true
+}
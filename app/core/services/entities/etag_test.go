@@ -0,0 +1,95 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	for header, want := range map[string]int64{
+		`3`:       3,
+		`"3"`:     3,
+		`W/"3"`:   3,
+		` "42" `:  42,
+		`W/"0"`:   0,
+		`"12345"`: 12345,
+	} {
+		r := httptest.NewRequest(http.MethodPut, "/", nil)
+		r.Header.Set("If-Match", header)
+
+		got, err := parseIfMatch(r)
+		if err != nil || got != want {
+			t.Errorf("%s: expected %d, got %d %v", header, want, got, err)
+		}
+	}
+}
+
+func TestRequireIfMatch(t *testing.T) {
+	h := newTestHandler()
+
+	for _, tc := range []struct {
+		header string
+		status int
+	}{
+		{"", http.StatusPreconditionRequired},
+		{"   ", http.StatusPreconditionRequired},
+		{"*", http.StatusBadRequest},
+		{`"abc"`, http.StatusBadRequest},
+		{`W/3"`, http.StatusBadRequest},
+		{`"3`, http.StatusBadRequest},
+	} {
+		r := httptest.NewRequest(http.MethodPut, "/", nil)
+		if tc.header != "" {
+			r.Header.Set("If-Match", tc.header)
+		}
+		w := httptest.NewRecorder()
+
+		if _, ok := h.requireIfMatch(w, r, "req"); ok {
+			t.Errorf("%q: expected the header to be rejected", tc.header)
+		}
+		if w.Code != tc.status {
+			t.Errorf("%q: expected status %d, got %d", tc.header, tc.status, w.Code)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodPut, "/", nil)
+	r.Header.Set("If-Match", `"7"`)
+	w := httptest.NewRecorder()
+	if version, ok := h.requireIfMatch(w, r, "req"); !ok || version != 7 {
+		t.Errorf("expected version 7, got %d %v", version, ok)
+	}
+}
+
+func TestWriteVersionConflict(t *testing.T) {
+	h := newTestHandler()
+
+	// A version checked write at a stale version matches no rows
+	w := httptest.NewRecorder()
+	if !h.writeVersionConflict(w, "req", fmt.Errorf("failed to update: %w", pgx.ErrNoRows)) {
+		t.Fatal("expected a version conflict to be written")
+	}
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected status %d, got %d", http.StatusPreconditionFailed, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	if h.writeVersionConflict(w, "req", errors.New("connection refused")) {
+		t.Error("expected other errors to be left to the caller")
+	}
+}
+
+func TestSetETag(t *testing.T) {
+	w := httptest.NewRecorder()
+	setETag(w, 5)
+
+	r := httptest.NewRequest(http.MethodPut, "/", nil)
+	r.Header.Set("If-Match", w.Header().Get("ETag"))
+	if version, err := parseIfMatch(r); err != nil || version != 5 {
+		t.Errorf("expected the ETag to round trip as version 5, got %d %v", version, err)
+	}
+}
@@ -0,0 +1,95 @@
+package entities
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/services/entities/export"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+)
+
+// ExportEntities is an endpoint that streams every entity of a class with its data as CSV, JSON
+// Lines or XLSX, as described in export.Parse
+func (h *Handler) ExportEntities(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+	classID := chi.URLParam(r, DefinitionIDKey)
+
+	definition, err := h.entityBuilder.LoadDefinitionByID(classID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("class_id", classID).Msg("Unknown entity class")
+		errhandler.BadRequest(w, []byte(`{"error": "unknown entity class"}`))
+		return
+	}
+
+	req, err := export.Parse(r.URL.Query())
+	if err != nil {
+		h.writeQueryError(w, err)
+		return
+	}
+
+	stmt, err := export.Build(definition, req)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("class_id", classID).Msg("Definition cannot be exported")
+		errhandler.ServerError(w, errhandler.RespProcessFailure)
+		return
+	}
+
+	out := &exportResponse{
+		ResponseWriter: w,
+		contentType:    req.Format.ContentType(),
+		filename:       fmt.Sprintf("%s.%s", classID, req.Format),
+	}
+	writer, err := export.NewWriter(req.Format, out)
+	if err != nil {
+		h.writeQueryError(w, err)
+		return
+	}
+
+	rows, err := export.Run(r.Context(), h.DB, stmt, writer)
+	if err != nil {
+		if !out.started {
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("class_id", classID).Msg("Failed to export entities")
+			errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+			return
+		}
+
+		// The status is already sent, aborting the connection tells the client the file is incomplete
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("class_id", classID).Int("rows", rows).Msg("Export aborted")
+		panic(http.ErrAbortHandler)
+	}
+
+	if !out.started {
+		// An empty CSV or JSON Lines export writes nothing, the headers are still due
+		out.start()
+	}
+
+	h.Logger.Info().Str(l.KeyReqID, reqID).Str("class_id", classID).Str("format", string(req.Format)).Int("rows", rows).Msg("Entities exported")
+}
+
+// exportResponse sends the file headers with the first bytes of the export, until then an error
+// can still be answered as JSON
+type exportResponse struct {
+	http.ResponseWriter
+
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (e *exportResponse) start() {
+	e.started = true
+	e.Header().Set("Content-Type", e.contentType)
+	e.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, e.filename))
+	e.WriteHeader(http.StatusOK)
+}
+
+func (e *exportResponse) Write(b []byte) (int, error) {
+	if !e.started {
+		e.start()
+	}
+
+	return e.ResponseWriter.Write(b)
+}
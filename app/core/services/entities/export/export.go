@@ -0,0 +1,223 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oriiyx/fritz/app/core/services/entities/query"
+	"github.com/oriiyx/fritz/app/core/services/objects/definition_builder"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// FetchSize is the number of rows read from the server-side cursor at a time, memory use of an
+// export is bounded by it regardless of the number of entities
+const FetchSize = 1000
+
+// cursorName names the server-side cursor, it only lives inside the export transaction
+const cursorName = "entity_export"
+
+// ErrInvalidExport wraps every error caused by the client's request rather than the database
+var ErrInvalidExport = errors.New("invalid export")
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidExport, fmt.Sprintf(format, args...))
+}
+
+// MetadataColumns are exported before the component columns of every row
+var MetadataColumns = []string{
+	"id", "parent_id", "o_key", "o_path", "published", "created_at", "updated_at", "version",
+}
+
+// Request is an export of every entity of a class, optionally restricted to a path subtree or
+// published state
+type Request struct {
+	Format     Format
+	PathPrefix string
+	Published  *bool
+}
+
+// Statement is a rendered export query with its positional arguments
+type Statement struct {
+	SQL  string
+	Args []interface{}
+
+	// Columns are the exported column names, metadata first and components in definition order
+	Columns []string
+	// Kinds holds the value kind of every component column, in order
+	Kinds []definitions.ValueKind
+}
+
+// Parse reads a Request from URL parameters:
+//
+//	format       csv, jsonl or xlsx, csv by default
+//	path_prefix  only entities whose o_path starts with the prefix
+//	published    only published or unpublished entities
+//	drafts       true includes unpublished entities, only published ones are exported otherwise
+func Parse(values url.Values) (Request, error) {
+	req := Request{
+		Format:     FormatCSV,
+		PathPrefix: values.Get("path_prefix"),
+	}
+
+	if format := values.Get("format"); format != "" {
+		req.Format = Format(format)
+		if !req.Format.Valid() {
+			return req, invalid("format must be one of csv, jsonl, xlsx")
+		}
+	}
+
+	published, err := query.ParseVisibility(values)
+	if err != nil {
+		return req, invalid("%s", err)
+	}
+	req.Published = published
+
+	return req, nil
+}
+
+// Build renders the export query of a definition. Rows are ordered by path and key so exports
+// of the same data are stable.
+func Build(definition *definitions.EntityDefinition, req Request) (*Statement, error) {
+	if err := definition.ValidateIdentifiers(); err != nil {
+		return nil, err
+	}
+
+	stmt := &Statement{Columns: append([]string{}, MetadataColumns...)}
+
+	selectList := append([]string{}, query.EntityColumns...)
+	for _, component := range definition.Layout.Components {
+		selectList = append(selectList, "d."+pgx.Identifier{component.Name}.Sanitize())
+		stmt.Columns = append(stmt.Columns, component.Name)
+		stmt.Kinds = append(stmt.Kinds, component.DBType.Kind())
+	}
+
+	stmt.Args = append(stmt.Args, definition.ID)
+	conditions := []string{"e.entity_class = $1::text", "e.deleted_at IS NULL"}
+
+	if req.PathPrefix != "" {
+		stmt.Args = append(stmt.Args, escapeLike(req.PathPrefix)+"%")
+		conditions = append(conditions, fmt.Sprintf("e.o_path LIKE $%d::text", len(stmt.Args)))
+	}
+	if req.Published != nil {
+		stmt.Args = append(stmt.Args, *req.Published)
+		conditions = append(conditions, fmt.Sprintf("e.published = $%d::boolean", len(stmt.Args)))
+	}
+
+	stmt.SQL = fmt.Sprintf(
+		"SELECT %s\nFROM entities e\nJOIN %s d ON d.entity_id = e.id\nWHERE %s\nORDER BY e.o_path, e.o_key, e.id",
+		strings.Join(selectList, ", "),
+		pgx.Identifier{definition_builder.EntityTableName(definition.ID)}.Sanitize(),
+		strings.Join(conditions, "\n  AND "),
+	)
+
+	return stmt, nil
+}
+
+// Beginner starts transactions, it is satisfied by *pgxpool.Pool and pgx.Tx
+type Beginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// Run streams every row of the statement into w and returns the number of rows written. The rows
+// are read through a server-side cursor inside a read-only snapshot, so the export is consistent
+// and never holds more than FetchSize rows in memory.
+func Run(ctx context.Context, conn Beginner, stmt *Statement, w Writer) (int, error) {
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin export: %w", err)
+	}
+	// Nothing is written, the transaction only scopes the cursor
+	defer tx.Rollback(context.WithoutCancel(ctx))
+
+	declare := fmt.Sprintf("DECLARE %s NO SCROLL CURSOR FOR\n%s", cursorName, stmt.SQL)
+	if _, err := tx.Exec(ctx, declare, stmt.Args...); err != nil {
+		return 0, fmt.Errorf("failed to declare export cursor: %w", err)
+	}
+
+	if err := w.WriteHeader(stmt.Columns); err != nil {
+		return 0, err
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM %s", FetchSize, cursorName)
+	written := 0
+	for {
+		n, err := fetchBatch(ctx, tx, fetch, stmt, w)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		if n < FetchSize {
+			break
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return written, err
+	}
+
+	return written, nil
+}
+
+// fetchBatch writes the next batch of the cursor and returns the number of rows it held
+func fetchBatch(ctx context.Context, tx pgx.Tx, fetch string, stmt *Statement, w Writer) (int, error) {
+	rows, err := tx.Query(ctx, fetch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch export rows: %w", err)
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var entity db.Entity
+		values := make([]interface{}, len(stmt.Kinds))
+
+		dest := query.EntityDest(&entity)
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return n, fmt.Errorf("failed to scan export row: %w", err)
+		}
+
+		if err := w.WriteRow(rowValues(entity, values, stmt.Kinds)); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, fmt.Errorf("failed to fetch export rows: %w", err)
+	}
+
+	return n, nil
+}
+
+// rowValues lays out an entity and its component values in the order of Statement.Columns
+func rowValues(entity db.Entity, components []interface{}, kinds []definitions.ValueKind) []interface{} {
+	row := make([]interface{}, 0, len(MetadataColumns)+len(components))
+	row = append(row,
+		normalize(entity.ID, definitions.KindOther),
+		normalize(entity.ParentID, definitions.KindOther),
+		entity.OKey,
+		entity.OPath,
+		entity.Published,
+		normalize(entity.CreatedAt, definitions.KindTimestamp),
+		normalize(entity.UpdatedAt, definitions.KindTimestamp),
+		entity.Version,
+	)
+
+	for i, value := range components {
+		row = append(row, normalize(value, kinds[i]))
+	}
+
+	return row
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
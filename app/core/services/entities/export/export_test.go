@@ -0,0 +1,162 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions/definitionstest"
+)
+
+func TestBuild(t *testing.T) {
+	req, err := Parse(url.Values{"format": {"jsonl"}, "path_prefix": {"/shop_1/"}, "published": {"true"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := Build(definitionstest.Product(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fragment := range []string{
+		`, d."title", d."stock"`,
+		`JOIN "entity_product" d ON d.entity_id = e.id`,
+		"e.entity_class = $1::text\n  AND e.deleted_at IS NULL\n  AND e.o_path LIKE $2::text\n  AND e.published = $3::boolean",
+		"ORDER BY e.o_path, e.o_key, e.id",
+	} {
+		if !strings.Contains(stmt.SQL, fragment) {
+			t.Errorf("expected SQL to contain %q, got:\n%s", fragment, stmt.SQL)
+		}
+	}
+
+	if stmt.Args[1] != `/shop\_1/%` {
+		t.Errorf("expected the path prefix to be escaped, got %v", stmt.Args[1])
+	}
+
+	expected := append(append([]string{}, MetadataColumns...), "title", "stock")
+	if strings.Join(stmt.Columns, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected columns %v, got %v", expected, stmt.Columns)
+	}
+
+	if _, err := Parse(url.Values{"format": {"xml"}}); err == nil {
+		t.Errorf("expected unknown formats to be rejected")
+	}
+}
+
+func testRows() [][]interface{} {
+	return [][]interface{}{
+		{"a", true, json.Number("12.50"), nil},
+		{"b, \"quoted\" & <tagged>", false, int32(3), map[string]interface{}{"k": "v"}},
+	}
+}
+
+func writeAll(t *testing.T, format Format) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteHeader([]string{"o_key", "published", "price", "extra"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range testRows() {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCSVWriter(t *testing.T) {
+	expected := "o_key,published,price,extra\n" +
+		"a,true,12.50,\n" +
+		"\"b, \"\"quoted\"\" & <tagged>\",false,3,\"{\"\"k\"\":\"\"v\"\"}\"\n"
+
+	if actual := string(writeAll(t, FormatCSV)); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestJSONLWriter(t *testing.T) {
+	expected := `{"o_key":"a","published":true,"price":12.50,"extra":null}` + "\n" +
+		`{"o_key":"b, \"quoted\" & <tagged>","published":false,"price":3,"extra":{"k":"v"}}` + "\n"
+
+	if actual := string(writeAll(t, FormatJSONL)); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	out := writeAll(t, FormatXLSX)
+
+	archive, err := zip.NewReader(bytes.NewReader(out), int64(len(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := make(map[string]string)
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(r)
+		parts[f.Name] = string(content)
+	}
+
+	for _, part := range xlsxParts {
+		if _, ok := parts[part.name]; !ok {
+			t.Errorf("expected part %s", part.name)
+		}
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, fragment := range []string{
+		`<row r="1"><c t="inlineStr"><is><t xml:space="preserve">o_key</t></is></c>`,
+		`<c t="b"><v>1</v></c><c><v>12.50</v></c><c/></row>`,
+		`b, &#34;quoted&#34; &amp; &lt;tagged&gt;`,
+		`<c><v>3</v></c>`,
+		`</row></sheetData></worksheet>`,
+	} {
+		if !strings.Contains(sheet, fragment) {
+			t.Errorf("expected sheet to contain %q, got:\n%s", fragment, sheet)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	id := pgtype.UUID{Bytes: [16]byte{0x7d, 0x0c}, Valid: true}
+	moment := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    interface{}
+		kind     definitions.ValueKind
+		expected interface{}
+	}{
+		{pgtype.UUID{}, definitions.KindOther, nil},
+		{id, definitions.KindOther, id.String()},
+		{moment, definitions.KindDate, "2026-03-04"},
+		{moment, definitions.KindTimestamp, "2026-03-04T00:00:00Z"},
+		{pgtype.Numeric{Int: big.NewInt(-105), Exp: -3, Valid: true}, definitions.KindFloat, json.Number("-0.105")},
+		{pgtype.Numeric{Int: big.NewInt(12), Exp: 2, Valid: true}, definitions.KindFloat, json.Number("1200")},
+		{pgtype.Numeric{}, definitions.KindFloat, nil},
+	}
+
+	for _, test := range tests {
+		if actual := normalize(test.value, test.kind); actual != test.expected {
+			t.Errorf("normalize(%#v): expected %#v, got %#v", test.value, test.expected, actual)
+		}
+	}
+}
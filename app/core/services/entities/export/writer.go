@@ -0,0 +1,228 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+)
+
+// Format is the file format of an export
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+	FormatXLSX  Format = "xlsx"
+)
+
+// Valid reports whether the format is supported
+func (f Format) Valid() bool {
+	return f == FormatCSV || f == FormatJSONL || f == FormatXLSX
+}
+
+// ContentType is the media type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Writer encodes exported rows. Rows hold the values produced by normalize, Close flushes
+// whatever the writer still buffers.
+type Writer interface {
+	WriteHeader(columns []string) error
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// NewWriter returns the writer of the format over w
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case FormatJSONL:
+		return &jsonlWriter{writer: bufio.NewWriter(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w), nil
+	default:
+		return nil, invalid("unsupported format %q", format)
+	}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func (c *csvWriter) WriteHeader(columns []string) error {
+	c.record = make([]string, len(columns))
+	return c.writer.Write(columns)
+}
+
+func (c *csvWriter) WriteRow(values []interface{}) error {
+	for i, value := range values {
+		c.record[i] = text(value)
+	}
+	return c.writer.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// jsonlWriter writes one object per row, keys keep the column order
+type jsonlWriter struct {
+	writer  *bufio.Writer
+	keys    [][]byte
+	value   bytes.Buffer
+	encoder *json.Encoder
+	line    []byte
+}
+
+func (j *jsonlWriter) WriteHeader(columns []string) error {
+	// Exported text is data, not HTML
+	j.encoder = json.NewEncoder(&j.value)
+	j.encoder.SetEscapeHTML(false)
+
+	j.keys = make([][]byte, len(columns))
+	for i, column := range columns {
+		key, err := j.encode(column)
+		if err != nil {
+			return err
+		}
+		j.keys[i] = bytes.Clone(key)
+	}
+	return nil
+}
+
+func (j *jsonlWriter) WriteRow(values []interface{}) error {
+	j.line = append(j.line[:0], '{')
+	for i, value := range values {
+		encoded, err := j.encode(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", j.keys[i], err)
+		}
+		if i > 0 {
+			j.line = append(j.line, ',')
+		}
+		j.line = append(j.line, j.keys[i]...)
+		j.line = append(j.line, ':')
+		j.line = append(j.line, encoded...)
+	}
+	j.line = append(j.line, '}', '\n')
+
+	_, err := j.writer.Write(j.line)
+	return err
+}
+
+// encode returns the JSON of value, the slice is only valid until the next call
+func (j *jsonlWriter) encode(value interface{}) ([]byte, error) {
+	j.value.Reset()
+	if err := j.encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(j.value.Bytes(), []byte("\n")), nil
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.writer.Flush()
+}
+
+// normalize turns a scanned value into a plain string, number, bool, JSON value or nil that
+// every writer can encode the same way
+func normalize(value interface{}, kind definitions.ValueKind) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case pgtype.UUID:
+		if !v.Valid {
+			return nil
+		}
+		return v.String()
+	case [16]byte:
+		return pgtype.UUID{Bytes: v, Valid: true}.String()
+	case pgtype.Timestamptz:
+		if !v.Valid {
+			return nil
+		}
+		return v.Time.UTC().Format(time.RFC3339Nano)
+	case time.Time:
+		if kind == definitions.KindDate {
+			return v.Format(time.DateOnly)
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	case pgtype.Numeric:
+		if !v.Valid || v.NaN {
+			return nil
+		}
+		return json.Number(numericString(v))
+	case []byte:
+		return string(v)
+	default:
+		return v
+	}
+}
+
+// numericString renders a numeric without losing precision
+func numericString(n pgtype.Numeric) string {
+	if n.Exp >= 0 {
+		return new(big.Int).Mul(n.Int, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n.Exp)), nil)).String()
+	}
+
+	digits := new(big.Int).Abs(n.Int).String()
+	scale := int(-n.Exp)
+	for len(digits) <= scale {
+		digits = "0" + digits
+	}
+
+	s := digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	if n.Int.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// text renders a normalized value as a single cell
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case int16:
+		return strconv.FormatInt(int64(v), 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
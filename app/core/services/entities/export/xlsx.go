@@ -0,0 +1,152 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MaxXLSXRows is the row limit of a spreadsheet, including the header
+const MaxXLSXRows = 1 << 20
+
+// xlsxParts are the fixed parts of a workbook with a single sheet
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Export" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// xlsxWriter streams a single sheet workbook. Strings are written inline rather than through a
+// shared string table so no row has to be kept until the end.
+type xlsxWriter struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	rows    int
+}
+
+func newXLSXWriter(w io.Writer) *xlsxWriter {
+	return &xlsxWriter{archive: zip.NewWriter(w)}
+}
+
+func (x *xlsxWriter) WriteHeader(columns []string) error {
+	for _, part := range xlsxParts {
+		f, err := x.archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+
+	f, err := x.archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	x.sheet = bufio.NewWriter(f)
+
+	_, err = x.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+	return x.WriteRow(header)
+}
+
+func (x *xlsxWriter) WriteRow(values []interface{}) error {
+	if x.rows >= MaxXLSXRows {
+		return fmt.Errorf("xlsx exports are limited to %d rows, use csv or jsonl", MaxXLSXRows-1)
+	}
+	x.rows++
+
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.rows)
+	for _, value := range values {
+		if err := x.writeCell(value); err != nil {
+			return err
+		}
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxWriter) writeCell(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		_, err := x.sheet.WriteString(`<c/>`)
+		return err
+	case bool:
+		b := "0"
+		if v {
+			b = "1"
+		}
+		_, err := x.sheet.WriteString(`<c t="b"><v>` + b + `</v></c>`)
+		return err
+	case int16, int32, int64, float32, float64:
+		_, err := x.sheet.WriteString(`<c><v>` + text(v) + `</v></c>`)
+		return err
+	case json.Number:
+		// Numbers beyond float precision, such as long numerics, stay exact as text
+		if fitsFloat(v.String()) {
+			_, err := x.sheet.WriteString(`<c><v>` + v.String() + `</v></c>`)
+			return err
+		}
+	}
+
+	if _, err := x.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`); err != nil {
+		return err
+	}
+	if err := xml.EscapeText(x.sheet, []byte(text(value))); err != nil {
+		return err
+	}
+	_, err := x.sheet.WriteString(`</t></is></c>`)
+	return err
+}
+
+// maxSignificantDigits is the precision spreadsheets keep for numbers
+const maxSignificantDigits = 15
+
+// fitsFloat reports whether a decimal string survives being stored as a spreadsheet number
+func fitsFloat(s string) bool {
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return false
+	}
+
+	digits := strings.TrimLeft(strings.NewReplacer("-", "", ".", "").Replace(s), "0")
+	return len(digits) <= maxSignificantDigits
+}
+
+func (x *xlsxWriter) Close() error {
+	if x.sheet != nil {
+		if _, err := x.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+			return err
+		}
+		if err := x.sheet.Flush(); err != nil {
+			return err
+		}
+	}
+	return x.archive.Close()
+}
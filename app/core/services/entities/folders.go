@@ -0,0 +1,411 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/trash"
+	"github.com/oriiyx/fritz/app/core/services/entities/tree"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// CreateFolderRequest - a folder under a parent, the path is derived from the parent
+type CreateFolderRequest struct {
+	ParentID       string   `json:"parent_id" validate:"required,uuid"`
+	Key            string   `json:"key" validate:"required,max=255"`
+	Description    *string  `json:"description,omitempty"`
+	Icon           *string  `json:"icon,omitempty" validate:"omitempty,max=255"`
+	AllowedClasses []string `json:"allowed_classes,omitempty"`
+}
+
+// UpdateFolderRequest - the folder metadata to change, omitted fields are kept
+type UpdateFolderRequest struct {
+	Description    *string   `json:"description,omitempty"`
+	Icon           *string   `json:"icon,omitempty" validate:"omitempty,max=255"`
+	AllowedClasses *[]string `json:"allowed_classes,omitempty"`
+}
+
+// MoveFolderRequest - the new parent of a folder
+type MoveFolderRequest struct {
+	ParentID string `json:"parent_id" validate:"required,uuid"`
+}
+
+type FolderResponse struct {
+	Entity db.Entity       `json:"entity"`
+	Folder db.EntityFolder `json:"folder"`
+}
+
+// CreateFolder is an endpoint that creates a plain folder. Folders have no definition and no data
+// row, they are published on creation and carry an optional description, icon and the classes
+// their children may have.
+func (h *Handler) CreateFolder(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	var req CreateFolderRequest
+	if !h.decodeRequest(w, r, reqID, &req) {
+		return
+	}
+
+	if err := checkAllowedClasses(req.AllowedClasses); err != nil {
+		errhandler.UnprocessableEntity(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+		return
+	}
+
+	var parentID pgtype.UUID
+	if err := parentID.Scan(req.ParentID); err != nil {
+		errhandler.BadRequest(w, []byte(`{"error": "invalid parent_id"}`))
+		return
+	}
+
+	parent, err := h.Queries.GetEntityByID(r.Context(), parentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "parent not found"}`))
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to load parent")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	userID := ctxUtil.GetUserID(r.Context())
+
+	entityParams := db.CreateEntityParams{
+		EntityClass: tree.FolderClass,
+		ParentID:    parent.ID,
+		OKey:        req.Key,
+		OPath:       tree.ChildPath(parent.OPath, req.Key),
+		OType:       tree.FolderType,
+		Published:   true,
+		CreatedBy:   userID,
+		UpdatedBy:   userID,
+	}
+
+	// Folders have no data row, their payloads carry no data
+	payload := &hooks.EntityCreatePayload{
+		Class: tree.FolderClass,
+		Entity: db.Entity{
+			EntityClass: entityParams.EntityClass,
+			ParentID:    entityParams.ParentID,
+			OKey:        entityParams.OKey,
+			OPath:       entityParams.OPath,
+			OType:       entityParams.OType,
+			Published:   entityParams.Published,
+			CreatedBy:   entityParams.CreatedBy,
+			UpdatedBy:   entityParams.UpdatedBy,
+		},
+		UserID: userID,
+	}
+	if !h.triggerBefore(w, r, reqID, hooks.HookBeforeEntityCreate, payload) {
+		return
+	}
+
+	var response FolderResponse
+	err = h.inTx(r.Context(), func(tx pgx.Tx, queries *db.Queries) error {
+		if err := tree.CheckChild(r.Context(), queries, parent.ID, tree.FolderClass); err != nil {
+			return err
+		}
+
+		var err error
+		response.Entity, err = queries.CreateEntity(r.Context(), entityParams)
+		if err != nil {
+			return err
+		}
+
+		response.Folder, err = queries.UpsertEntityFolder(r.Context(), db.UpsertEntityFolderParams{
+			EntityID:       response.Entity.ID,
+			Description:    optionalText(req.Description),
+			Icon:           optionalText(req.Icon),
+			AllowedClasses: allowedClasses(req.AllowedClasses),
+		})
+		if err != nil {
+			return err
+		}
+
+		after, err := folderState(response.Entity, response.Folder)
+		if err != nil {
+			return err
+		}
+		return audit.RecordEntity(r.Context(), queries, audit.ActionCreate, response.Entity.ID, nil, after)
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, tree.ErrClassNotAllowed):
+			errhandler.UnprocessableEntity(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+		case errors.As(err, &pgErr) && pgErr.Code == uniqueViolation:
+			errhandler.Conflict(w, []byte(`{"error": "an entity with this path and key already exists"}`))
+		default:
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to create folder")
+			errhandler.ServerError(w, errhandler.RespDBDataInsertFailure)
+		}
+		return
+	}
+
+	h.Logger.Info().
+		Str("entity_id", response.Entity.ID.String()).
+		Str("path", response.Entity.OPath).
+		Msg("Folder created")
+
+	payload.Entity = response.Entity
+	h.triggerAfter(r, reqID, hooks.HookAfterEntityCreate, payload)
+
+	setETag(w, response.Entity.Version)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// GetFolder is an endpoint that returns a folder with its metadata, the root and other entities of
+// type folder included
+func (h *Handler) GetFolder(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	entity, folder, ok := h.loadFolder(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	setETag(w, entity.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(FolderResponse{Entity: entity, Folder: folder})
+}
+
+// UpdateFolder is an endpoint that changes the description, icon or allowed classes of a folder.
+// Narrowing the allowed classes does not touch children that are already in the folder.
+func (h *Handler) UpdateFolder(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	var req UpdateFolderRequest
+	if !h.decodeRequest(w, r, reqID, &req) {
+		return
+	}
+
+	entity, folder, ok := h.loadFolder(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	if !h.checkLock(w, r, reqID, entity.ID) {
+		return
+	}
+
+	params := db.UpsertEntityFolderParams{
+		EntityID:       entity.ID,
+		Description:    folder.Description,
+		Icon:           folder.Icon,
+		AllowedClasses: allowedClasses(folder.AllowedClasses),
+	}
+	if req.Description != nil {
+		params.Description = optionalText(req.Description)
+	}
+	if req.Icon != nil {
+		params.Icon = optionalText(req.Icon)
+	}
+	if req.AllowedClasses != nil {
+		if err := checkAllowedClasses(*req.AllowedClasses); err != nil {
+			errhandler.UnprocessableEntity(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+			return
+		}
+		params.AllowedClasses = allowedClasses(*req.AllowedClasses)
+	}
+
+	before, err := folderState(entity, folder)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to read folder state")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	payload := &hooks.EntityUpdatePayload{
+		Class:   entity.EntityClass,
+		Entity:  entity,
+		UserID:  ctxUtil.GetUserID(r.Context()),
+		Partial: true,
+	}
+	if !h.triggerBefore(w, r, reqID, hooks.HookBeforeEntityUpdate, payload) {
+		return
+	}
+
+	err = h.inTx(r.Context(), func(tx pgx.Tx, queries *db.Queries) error {
+		var err error
+		folder, err = queries.UpsertEntityFolder(r.Context(), params)
+		if err != nil {
+			return err
+		}
+
+		after, err := folderState(entity, folder)
+		if err != nil {
+			return err
+		}
+		return audit.RecordEntity(r.Context(), queries, audit.ActionUpdate, entity.ID, before, after)
+	})
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to update folder")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return
+	}
+
+	h.triggerAfter(r, reqID, hooks.HookAfterEntityUpdate, payload)
+
+	setETag(w, entity.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(FolderResponse{Entity: entity, Folder: folder})
+}
+
+// RenameFolder is an endpoint that changes the key of a folder, the paths of its descendants follow
+func (h *Handler) RenameFolder(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	var req RenameEntityRequest
+	if !h.decodeRequest(w, r, reqID, &req) {
+		return
+	}
+
+	h.relocate(w, r, reqID, tree.FolderClass, func(entity db.Entity) (pgtype.UUID, string) {
+		return entity.ParentID, req.Key
+	})
+}
+
+// MoveFolder is an endpoint that moves a folder with its descendants under another parent, which
+// must allow folders
+func (h *Handler) MoveFolder(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	var req MoveFolderRequest
+	if !h.decodeRequest(w, r, reqID, &req) {
+		return
+	}
+
+	var parentID pgtype.UUID
+	if err := parentID.Scan(req.ParentID); err != nil {
+		errhandler.BadRequest(w, []byte(`{"error": "invalid parent_id"}`))
+		return
+	}
+
+	h.relocate(w, r, reqID, tree.FolderClass, func(entity db.Entity) (pgtype.UUID, string) {
+		return parentID, entity.OKey
+	})
+}
+
+// DeleteFolder is an endpoint that moves a folder and its descendants to the trash, it returns the
+// trash item they can be restored from
+func (h *Handler) DeleteFolder(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	entity, ok := h.loadEntityOfClass(w, r, reqID, tree.FolderClass)
+	if !ok {
+		return
+	}
+
+	if !h.checkLock(w, r, reqID, entity.ID) {
+		return
+	}
+
+	payload := &hooks.EntityDeletePayload{
+		Class:  entity.EntityClass,
+		Entity: entity,
+		UserID: ctxUtil.GetUserID(r.Context()),
+	}
+	if !h.triggerBefore(w, r, reqID, hooks.HookBeforeEntityDelete, payload) {
+		return
+	}
+
+	var item db.TrashItem
+	err := h.inTx(r.Context(), func(tx pgx.Tx, queries *db.Queries) error {
+		var err error
+		item, err = trash.Trash(r.Context(), queries, entity.ID, payload.UserID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, trash.ErrNotFound) {
+			errhandler.NotFound(w, []byte(`{"error": "entity not found"}`))
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to move folder to the trash")
+		errhandler.ServerError(w, errhandler.RespDBDataRemoveFailure)
+		return
+	}
+
+	h.triggerAfter(r, reqID, hooks.HookAfterEntityDelete, payload)
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(item)
+}
+
+// loadFolder reads the entity of the URL, which must be of type folder, with its metadata. A
+// folder without metadata has the default metadata that allows every class.
+func (h *Handler) loadFolder(w http.ResponseWriter, r *http.Request, reqID string) (db.Entity, db.EntityFolder, bool) {
+	var entityID pgtype.UUID
+	if err := entityID.Scan(chi.URLParam(r, EntityIDKey)); err != nil {
+		errhandler.BadRequest(w, []byte(`{"error": "invalid entity_id"}`))
+		return db.Entity{}, db.EntityFolder{}, false
+	}
+
+	entity, err := h.Queries.GetEntityByID(r.Context(), entityID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "folder not found"}`))
+			return entity, db.EntityFolder{}, false
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to load folder")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return entity, db.EntityFolder{}, false
+	}
+	if entity.OType != tree.FolderType {
+		errhandler.BadRequest(w, []byte(`{"error": "entity is not a folder"}`))
+		return entity, db.EntityFolder{}, false
+	}
+
+	folder, err := h.Queries.GetEntityFolder(r.Context(), entity.ID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		folder = db.EntityFolder{EntityID: entity.ID, AllowedClasses: []string{}}
+	case err != nil:
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to load folder metadata")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return entity, folder, false
+	}
+
+	return entity, folder, true
+}
+
+// checkAllowedClasses reports a class that is neither a folder nor has a definition adapter
+func checkAllowedClasses(classes []string) error {
+	for _, class := range classes {
+		if class == tree.FolderClass {
+			continue
+		}
+		if _, err := adapters.Get(class); err != nil {
+			return fmt.Errorf("unknown entity class %q in allowed_classes", class)
+		}
+	}
+	return nil
+}
+
+// allowedClasses keeps the column non-null, no allowed classes allows every class
+func allowedClasses(classes []string) []string {
+	if classes == nil {
+		return []string{}
+	}
+	return classes
+}
+
+// optionalText stores an empty value as null
+func optionalText(value *string) pgtype.Text {
+	if value == nil || *value == "" {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *value, Valid: true}
+}
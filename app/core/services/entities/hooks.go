@@ -0,0 +1,75 @@
+package entities
+
+import (
+	"fmt"
+	"net/http"
+
+	"context"
+	"errors"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+)
+
+// triggerBefore runs the callbacks of a before hook. An error of a callback vetoes the operation,
+// it responds with 422 and the message of the error.
+func (h *Handler) triggerBefore(w http.ResponseWriter, r *http.Request, reqID, name string, payload interface{}) bool {
+	if h.Hooks == nil {
+		return true
+	}
+
+	err := h.Hooks.Trigger(r.Context(), name, payload)
+	if err == nil {
+		return true
+	}
+
+	h.Logger.Info().Err(err).Str(l.KeyReqID, reqID).Str("hook", name).Msg("Operation vetoed by hook")
+	errhandler.UnprocessableEntity(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+	return false
+}
+
+// triggerAfter runs the callbacks of an after hook. The operation is committed by then, so an
+// error of a callback is logged and the response stays successful.
+func (h *Handler) triggerAfter(r *http.Request, reqID, name string, payload interface{}) {
+	if h.Hooks == nil {
+		return
+	}
+
+	if err := h.Hooks.Trigger(r.Context(), name, payload); err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("hook", name).Msg("Hook failed after the operation")
+	}
+}
+
+// vetoError is the error of a before hook that ran inside a transaction, it rolls the transaction
+// back and is answered like a veto of triggerBefore
+type vetoError struct {
+	err error
+}
+
+func (e *vetoError) Error() string { return e.err.Error() }
+
+func (e *vetoError) Unwrap() error { return e.err }
+
+// trigger runs the callbacks of a before hook for a write inside a transaction, the error of a
+// callback is returned as a *vetoError
+func (h *Handler) trigger(ctx context.Context, name string, payload interface{}) error {
+	if h.Hooks == nil {
+		return nil
+	}
+
+	if err := h.Hooks.Trigger(ctx, name, payload); err != nil {
+		return &vetoError{err: err}
+	}
+	return nil
+}
+
+// writeVetoError responds with 422 when err is the veto of a hook, it reports whether it did
+func (h *Handler) writeVetoError(w http.ResponseWriter, reqID string, err error) bool {
+	var veto *vetoError
+	if !errors.As(err, &veto) {
+		return false
+	}
+
+	h.Logger.Info().Err(veto.err).Str(l.KeyReqID, reqID).Msg("Operation vetoed by hook")
+	errhandler.UnprocessableEntity(w, []byte(fmt.Sprintf(`{"error": %q}`, veto.err.Error())))
+	return true
+}
@@ -0,0 +1,59 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/services/entities/query"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+)
+
+// ListEntities is an endpoint that lists entities of a class with their data,
+// filtered, sorted and paged by keyset as described in query.Parse
+func (h *Handler) ListEntities(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+	classID := chi.URLParam(r, DefinitionIDKey)
+
+	definition, err := h.entityBuilder.LoadDefinitionByID(classID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("class_id", classID).Msg("Unknown entity class")
+		errhandler.BadRequest(w, []byte(`{"error": "unknown entity class"}`))
+		return
+	}
+
+	builder, err := query.NewBuilder(definition)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("class_id", classID).Msg("Definition cannot be queried")
+		errhandler.ServerError(w, errhandler.RespProcessFailure)
+		return
+	}
+
+	q, err := query.Parse(r.URL.Query())
+	if err != nil {
+		h.writeQueryError(w, err)
+		return
+	}
+
+	page, err := builder.Run(r.Context(), h.DB, q)
+	if err != nil {
+		if errors.Is(err, query.ErrInvalidQuery) {
+			h.writeQueryError(w, err)
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("class_id", classID).Msg("Failed to list entities")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+func (h *Handler) writeQueryError(w http.ResponseWriter, err error) {
+	respBody, _ := json.Marshal(errhandler.Error{Error: err.Error()})
+	errhandler.BadRequest(w, respBody)
+}
@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/services/entities/locks"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+)
+
+// checkLock lets a write through unless another user holds the edit lock of the entity, it
+// responds with 423 and the lock otherwise
+func (h *Handler) checkLock(w http.ResponseWriter, r *http.Request, reqID string, entityID pgtype.UUID) bool {
+	err := locks.Check(r.Context(), h.Queries, entityID, ctxUtil.GetUserID(r.Context()))
+	if err == nil {
+		return true
+	}
+	if locks.WriteLocked(w, err) {
+		return false
+	}
+
+	h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to read entity lock")
+	errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+	return false
+}
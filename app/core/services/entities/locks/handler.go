@@ -0,0 +1,220 @@
+package locks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/base"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+const EntityIDKey = "entity_id"
+
+type Handler struct {
+	*base.HandlerController
+}
+
+func New(ctrl *base.HandlerController) *Handler {
+	return &Handler{
+		HandlerController: ctrl,
+	}
+}
+
+type LockResponse struct {
+	// Lock is null while nobody holds a live lock on the entity
+	Lock *db.GetEntityLockRow `json:"lock"`
+}
+
+// Get is an endpoint that returns the live edit lock of an entity with the user holding it
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	entityID, ok := parseEntityID(w, r)
+	if !ok {
+		return
+	}
+
+	h.writeLock(w, r, reqID, entityID, http.StatusOK)
+}
+
+// Acquire is an endpoint that opens an entity for editing in the session of the request. The lock
+// is free, expired or already held by the same user, otherwise the response is 423 with the lock
+// that blocks it. The lock expires after the lock TTL unless it is renewed with Renew.
+func (h *Handler) Acquire(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+	session := ctxUtil.GetSession(r.Context())
+
+	entityID, ok := parseEntityID(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.Queries.GetEntityByID(r.Context(), entityID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "entity not found"}`))
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to load entity")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	_, err := h.Queries.AcquireEntityLock(r.Context(), db.AcquireEntityLockParams{
+		EntityID:  entityID,
+		UserID:    session.UserIdentityID,
+		SessionID: session.ID,
+		ExpiresAt: Expiry(time.Now(), h.Conf.Locks.TTL),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Another user holds a live lock, report it unless it expired in the meantime
+		if err := Check(r.Context(), h.Queries, entityID, session.UserIdentityID); WriteLocked(w, err) {
+			return
+		}
+		errhandler.Conflict(w, []byte(`{"error": "the lock changed hands, try again"}`))
+		return
+	}
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to acquire entity lock")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return
+	}
+
+	h.writeLock(w, r, reqID, entityID, http.StatusOK)
+}
+
+// Renew is an endpoint for the heartbeat of the session holding the lock of an entity, it extends
+// the lock by the lock TTL. A lock that was broken or taken over after it expired answers 409.
+func (h *Handler) Renew(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+	session := ctxUtil.GetSession(r.Context())
+
+	entityID, ok := parseEntityID(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := h.Queries.RenewEntityLock(r.Context(), db.RenewEntityLockParams{
+		EntityID:  entityID,
+		SessionID: session.ID,
+		ExpiresAt: Expiry(time.Now(), h.Conf.Locks.TTL),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		errhandler.Conflict(w, []byte(`{"error": "the lock is not held by this session"}`))
+		return
+	}
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to renew entity lock")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return
+	}
+
+	h.writeLock(w, r, reqID, entityID, http.StatusOK)
+}
+
+// Release is an endpoint that gives up the lock of an entity. The user holding the lock releases
+// it, administrators break the locks of other users.
+func (h *Handler) Release(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+	userID := ctxUtil.GetUserID(r.Context())
+
+	entityID, ok := parseEntityID(w, r)
+	if !ok {
+		return
+	}
+
+	lock, err := h.Queries.GetEntityLock(r.Context(), entityID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to read entity lock")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	broken := !HeldBy(lock, userID)
+	if broken {
+		user, err := h.Queries.GetUserByID(r.Context(), userID)
+		if err != nil {
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to load user")
+			errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+			return
+		}
+		if !user.IsAdmin {
+			errhandler.Forbidden(w, []byte(`{"error": "only administrators can break the locks of other users"}`))
+			return
+		}
+
+		h.Logger.Warn().
+			Str(l.KeyReqID, reqID).
+			Str("entity_id", entityID.String()).
+			Str("holder_id", lock.UserID.String()).
+			Str("user_id", userID.String()).
+			Msg("Entity lock broken")
+	}
+
+	err = pgx.BeginFunc(r.Context(), h.DB, func(tx pgx.Tx) error {
+		queries := h.Queries.WithTx(tx)
+		if !broken {
+			// Only the lock of the user is released, it may have expired and been taken since it was read
+			_, err := queries.ReleaseEntityLock(r.Context(), db.ReleaseEntityLockParams{EntityID: entityID, UserID: userID})
+			return err
+		}
+		if _, err := queries.BreakEntityLock(r.Context(), entityID); err != nil {
+			return err
+		}
+
+		before, err := audit.ObjectState(map[string]interface{}{
+			"holder_id":  lock.UserID,
+			"expires_at": lock.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+		return audit.RecordEntity(r.Context(), queries, audit.ActionBreakLock, entityID, before, nil)
+	})
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to release entity lock")
+		errhandler.ServerError(w, errhandler.RespDBDataRemoveFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeLock responds with the live lock of the entity, or a null lock when there is none
+func (h *Handler) writeLock(w http.ResponseWriter, r *http.Request, reqID string, entityID pgtype.UUID, status int) {
+	var response LockResponse
+
+	lock, err := h.Queries.GetEntityLock(r.Context(), entityID)
+	switch {
+	case err == nil:
+		response.Lock = &lock
+	case !errors.Is(err, pgx.ErrNoRows):
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to read entity lock")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func parseEntityID(w http.ResponseWriter, r *http.Request) (pgtype.UUID, bool) {
+	var entityID pgtype.UUID
+	if err := entityID.Scan(chi.URLParam(r, EntityIDKey)); err != nil {
+		errhandler.BadRequest(w, []byte(`{"error": "invalid entity_id"}`))
+		return entityID, false
+	}
+	return entityID, true
+}
@@ -0,0 +1,73 @@
+package locks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+var ErrLocked = errors.New("entity is locked by another user")
+
+// LockedError is returned for a write to an entity another user holds the edit lock of
+type LockedError struct {
+	Lock db.GetEntityLockRow
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("entity is locked by %s until %s", e.Lock.UserEmail, e.Lock.ExpiresAt.Time.UTC().Format(time.RFC3339))
+}
+
+func (e *LockedError) Is(target error) bool {
+	return target == ErrLocked
+}
+
+// Check returns a LockedError when a user other than userID holds a live lock on the entity.
+// Writes without a user are rejected by every lock.
+func Check(ctx context.Context, queries *db.Queries, entityID, userID pgtype.UUID) error {
+	lock, err := queries.GetEntityLock(ctx, entityID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !HeldBy(lock, userID) {
+		return &LockedError{Lock: lock}
+	}
+
+	return nil
+}
+
+// HeldBy reports whether the lock belongs to userID, any session of the user may write
+func HeldBy(lock db.GetEntityLockRow, userID pgtype.UUID) bool {
+	return userID.Valid && lock.UserID == userID
+}
+
+// Expiry is the expiry of a lock taken or renewed at now
+func Expiry(now time.Time, ttl time.Duration) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: now.Add(ttl), Valid: true}
+}
+
+// WriteLocked responds with 423 and the lock that blocks the write when err is a LockedError
+func WriteLocked(w http.ResponseWriter, err error) bool {
+	var lockedErr *LockedError
+	if !errors.As(err, &lockedErr) {
+		return false
+	}
+
+	respBody, _ := json.Marshal(map[string]interface{}{
+		"error": lockedErr.Error(),
+		"lock":  lockedErr.Lock,
+	})
+	errhandler.Locked(w, respBody)
+	return true
+}
@@ -0,0 +1,90 @@
+package locks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+func uuid(b byte) pgtype.UUID {
+	return pgtype.UUID{Bytes: [16]byte{b}, Valid: true}
+}
+
+func TestHeldBy(t *testing.T) {
+	lock := db.GetEntityLockRow{UserID: uuid(1)}
+
+	tests := []struct {
+		userID pgtype.UUID
+		want   bool
+	}{
+		{userID: uuid(1), want: true},
+		{userID: uuid(2), want: false},
+		{userID: pgtype.UUID{}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := HeldBy(lock, tt.userID); got != tt.want {
+			t.Errorf("HeldBy(%v) = %v, want %v", tt.userID, got, tt.want)
+		}
+	}
+
+	// A lock without a holder is never held by a request without a user
+	if HeldBy(db.GetEntityLockRow{}, pgtype.UUID{}) {
+		t.Error("HeldBy matched an anonymous request")
+	}
+}
+
+func TestLockedError(t *testing.T) {
+	until := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := fmt.Errorf("save: %w", &LockedError{Lock: db.GetEntityLockRow{
+		UserID:    uuid(1),
+		UserEmail: "editor@example.com",
+		ExpiresAt: pgtype.Timestamptz{Time: until, Valid: true},
+	}})
+
+	if !errors.Is(err, ErrLocked) {
+		t.Errorf("errors.Is(%v, ErrLocked) = false", err)
+	}
+	if want := "save: entity is locked by editor@example.com until 2026-01-02T03:04:05Z"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestWriteLocked(t *testing.T) {
+	w := httptest.NewRecorder()
+	if WriteLocked(w, errors.New("other")) {
+		t.Fatal("WriteLocked handled an unrelated error")
+	}
+
+	w = httptest.NewRecorder()
+	if !WriteLocked(w, &LockedError{Lock: db.GetEntityLockRow{UserEmail: "editor@example.com"}}) {
+		t.Fatal("WriteLocked did not handle a LockedError")
+	}
+	if w.Code != http.StatusLocked {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusLocked)
+	}
+
+	var body struct {
+		Lock db.GetEntityLockRow `json:"lock"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Lock.UserEmail != "editor@example.com" {
+		t.Errorf("lock user_email = %q", body.Lock.UserEmail)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if got := Expiry(now, 2*time.Minute); !got.Valid || !got.Time.Equal(now.Add(2*time.Minute)) {
+		t.Errorf("Expiry = %v", got)
+	}
+}
@@ -0,0 +1,155 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/services/entities/tree"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	validatorUtil "github.com/oriiyx/fritz/app/core/utils/validator"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// MoveEntityRequest - the new parent and optionally a new key for the moved entity
+type MoveEntityRequest struct {
+	ParentID string `json:"parent_id" validate:"required"`
+	Key      string `json:"key,omitempty" validate:"omitempty,max=255"`
+}
+
+// RenameEntityRequest - the new key, the entity stays under its parent
+type RenameEntityRequest struct {
+	Key string `json:"key" validate:"required,max=255"`
+}
+
+// MoveEntity is an endpoint that moves an entity under another parent. The path is derived from
+// the parent and the paths of all descendants are rewritten in the same transaction.
+func (h *Handler) MoveEntity(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	var req MoveEntityRequest
+	if !h.decodeRequest(w, r, reqID, &req) {
+		return
+	}
+
+	var parentID pgtype.UUID
+	if err := parentID.Scan(req.ParentID); err != nil {
+		errhandler.BadRequest(w, []byte(`{"error": "invalid parent_id"}`))
+		return
+	}
+
+	h.relocate(w, r, reqID, chi.URLParam(r, DefinitionIDKey), func(entity db.Entity) (pgtype.UUID, string) {
+		if req.Key != "" {
+			return parentID, req.Key
+		}
+		return parentID, entity.OKey
+	})
+}
+
+// RenameEntity is an endpoint that changes the key of an entity, its path and the paths of all
+// descendants follow in the same transaction
+func (h *Handler) RenameEntity(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	var req RenameEntityRequest
+	if !h.decodeRequest(w, r, reqID, &req) {
+		return
+	}
+
+	h.relocate(w, r, reqID, chi.URLParam(r, DefinitionIDKey), func(entity db.Entity) (pgtype.UUID, string) {
+		return entity.ParentID, req.Key
+	})
+}
+
+// decodeRequest decodes and validates the request body into req, it writes the error response and
+// returns false when the request is invalid
+func (h *Handler) decodeRequest(w http.ResponseWriter, r *http.Request, reqID string, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to decode request")
+		errhandler.BadRequest(w, errhandler.RespInvalidRequestBody)
+		return false
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to marshal validation errors")
+			errhandler.ServerError(w, errhandler.RespJSONEncodeFailure)
+			return false
+		}
+		errhandler.ValidationErrors(w, respBody)
+		return false
+	}
+
+	return true
+}
+
+// relocate moves the entity of the URL, which must be of class, to the parent and key target picks
+// for it at the If-Match version
+func (h *Handler) relocate(w http.ResponseWriter, r *http.Request, reqID, class string, target func(entity db.Entity) (pgtype.UUID, string)) {
+	expectedVersion, ok := h.requireIfMatch(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	entity, ok := h.loadEntityOfClass(w, r, reqID, class)
+	if !ok {
+		return
+	}
+
+	if !h.checkLock(w, r, reqID, entity.ID) {
+		return
+	}
+
+	if entity.Version != expectedVersion {
+		errhandler.PreconditionFailed(w, errhandler.RespEntityVersionConflict)
+		return
+	}
+
+	parentID, key := target(entity)
+
+	var descendants int64
+	err := h.inTx(r.Context(), func(tx pgx.Tx, queries *db.Queries) error {
+		var err error
+		entity, descendants, err = tree.Move(r.Context(), queries, entity, parentID, key, expectedVersion, ctxUtil.GetUserID(r.Context()))
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, tree.ErrPathConflict):
+			errhandler.Conflict(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+		case errors.Is(err, tree.ErrParentNotFound):
+			errhandler.NotFound(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+		case errors.Is(err, tree.ErrCycle), errors.Is(err, tree.ErrRootEntity), errors.Is(err, tree.ErrClassNotAllowed):
+			errhandler.UnprocessableEntity(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+		default:
+			if h.writeVersionConflict(w, reqID, err) {
+				return
+			}
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to move entity")
+			errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		}
+		return
+	}
+
+	h.Logger.Info().
+		Str("entity_id", entity.ID.String()).
+		Str("path", entity.OPath).
+		Int64("descendants", descendants).
+		Msg("Entity moved")
+
+	response := map[string]interface{}{
+		"entity":      entity,
+		"descendants": descendants,
+	}
+
+	setETag(w, entity.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,168 @@
+package entities
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/writes"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	validatorUtil "github.com/oriiyx/fritz/app/core/utils/validator"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// PatchEntityRequest - only the data keys that should change, null clears a value
+type PatchEntityRequest struct {
+	Data map[string]interface{} `json:"data" validate:"required"`
+}
+
+// PatchEntity is an endpoint that partially updates entity data
+func (h *Handler) PatchEntity(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+	classID := chi.URLParam(r, DefinitionIDKey)
+	entityID := chi.URLParam(r, EntityIDKey)
+
+	var req PatchEntityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to decode request")
+		errhandler.BadRequest(w, errhandler.RespInvalidRequestBody)
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to marshal validation errors")
+			errhandler.ServerError(w, errhandler.RespJSONEncodeFailure)
+			return
+		}
+		errhandler.ValidationErrors(w, respBody)
+		return
+	}
+
+	expectedVersion, ok := h.requireIfMatch(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	// Get the adapter for this entity class
+	adapter, err := adapters.Get(classID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("class_id", classID).Msg("Unknown entity class")
+		errhandler.BadRequest(w, []byte(`{"error": "unknown entity class"}`))
+		return
+	}
+
+	var entityUUID pgtype.UUID
+	if err := entityUUID.Scan(entityID); err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Invalid entity_id")
+		errhandler.BadRequest(w, []byte(`{"error": "invalid entity_id"}`))
+		return
+	}
+
+	entity, err := h.Queries.GetEntityByID(r.Context(), entityUUID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("entity_id", entityID).Msg("Entity not found")
+		errhandler.BadRequest(w, []byte(`{"error": "entity not found"}`))
+		return
+	}
+
+	if entity.EntityClass != classID {
+		h.Logger.Error().
+			Str("expected_class", classID).
+			Str("actual_class", entity.EntityClass).
+			Msg("Entity class mismatch")
+		errhandler.BadRequest(w, []byte(`{"error": "entity class mismatch"}`))
+		return
+	}
+
+	if !h.checkLock(w, r, reqID, entity.ID) {
+		return
+	}
+
+	// A patch needs an existing data row to apply to
+	if !entity.HasData {
+		errhandler.BadRequest(w, []byte(`{"error": "entity has no data yet, use transition to create it"}`))
+		return
+	}
+
+	if entity.Published && entity.Version != expectedVersion {
+		errhandler.PreconditionFailed(w, errhandler.RespEntityVersionConflict)
+		return
+	}
+
+	payload := &hooks.EntityUpdatePayload{
+		Class:   classID,
+		Entity:  entity,
+		Data:    req.Data,
+		UserID:  ctxUtil.GetUserID(r.Context()),
+		Partial: true,
+		Draft:   entity.Published,
+	}
+	if !h.triggerBefore(w, r, reqID, hooks.HookBeforeEntityUpdate, payload) {
+		return
+	}
+	req.Data = payload.Data
+
+	// A published entity keeps serving its published content, the patch goes to its draft
+	if entity.Published {
+		var draft db.EntityDraft
+		entity, draft, err = h.patchDraft(r.Context(), adapter, entity, expectedVersion, req.Data)
+		if err == nil {
+			payload.Entity = entity
+			h.triggerAfter(r, reqID, hooks.HookAfterEntityUpdate, payload)
+		}
+		h.writeDraft(w, reqID, entity, draft, err)
+		return
+	}
+
+	var result interface{}
+	err = h.inTx(r.Context(), func(tx pgx.Tx, queries *db.Queries) error {
+		before, err := audit.ReadEntityState(r.Context(), adapter.WithTx(tx), entity)
+		if err != nil {
+			return err
+		}
+
+		entity, result, err = writes.Patch(r.Context(), tx, queries, adapter, entity, expectedVersion, req.Data, ctxUtil.GetUserID(r.Context()))
+		if err != nil {
+			return err
+		}
+
+		return h.record(r.Context(), queries, adapter, audit.ActionUpdate, before, entity, result)
+	})
+	if err != nil {
+		if h.writeValidationError(w, reqID, err) {
+			return
+		}
+		if h.writeVersionConflict(w, reqID, err) {
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to patch entity data")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return
+	}
+
+	h.Logger.Info().
+		Str("entity_id", entityID).
+		Str("class_id", classID).
+		Msg("Entity data patched")
+
+	payload.Entity = entity
+	h.triggerAfter(r, reqID, hooks.HookAfterEntityUpdate, payload)
+
+	response := map[string]interface{}{
+		"entity": entity,
+		"data":   result,
+	}
+
+	setETag(w, entity.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
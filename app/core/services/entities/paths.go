@@ -0,0 +1,131 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/query"
+	"github.com/oriiyx/fritz/app/core/services/entities/tree"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+type BreadcrumbResponse struct {
+	Items []db.GetEntityPathRow `json:"items"`
+}
+
+// GetEntityByPath is an endpoint that resolves an entity by its path, like
+// /entities/by-path?path=/products/shoes/runner-1, and reads its data through the adapter of its
+// class. Only published entities resolve unless drafts=true is given, which also includes the
+// pending draft.
+func (h *Handler) GetEntityByPath(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	path, key, err := tree.SplitPath(r.URL.Query().Get("path"))
+	if err != nil {
+		errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+		return
+	}
+
+	published, err := query.ParseVisibility(r.URL.Query())
+	if err != nil {
+		errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+		return
+	}
+	drafts := published == nil || !*published
+
+	var entity db.Entity
+	if path == tree.RootPath {
+		var rootID pgtype.UUID
+		_ = rootID.Scan(tree.RootEntityID)
+		entity, err = h.Queries.GetEntityByID(r.Context(), rootID)
+	} else {
+		entity, err = h.Queries.GetEntityByPath(r.Context(), db.GetEntityByPathParams{OPath: path, OKey: key})
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "entity not found"}`))
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("path", path).Msg("Failed to resolve entity path")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	if published != nil && entity.Published != *published {
+		errhandler.NotFound(w, []byte(`{"error": "entity not found"}`))
+		return
+	}
+
+	response := map[string]interface{}{
+		"entity": entity,
+		"data":   nil,
+	}
+
+	if entity.HasData {
+		adapter, err := adapters.Get(entity.EntityClass)
+		if err != nil {
+			h.Logger.Error().Err(err).Str("class_id", entity.EntityClass).Msg("Unknown entity class")
+			errhandler.ServerError(w, errhandler.RespProcessFailure)
+			return
+		}
+
+		if response["data"], err = adapter.Read(r.Context(), entity.ID); err != nil {
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to read entity data")
+			errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+			return
+		}
+	}
+
+	if drafts {
+		var draft *DraftResponse
+		pending, err := h.Queries.GetEntityDraft(r.Context(), entity.ID)
+		switch {
+		case err == nil:
+			draft = newDraftResponse(pending)
+		case !errors.Is(err, pgx.ErrNoRows):
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to read entity draft")
+			errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+			return
+		}
+		response["draft"] = draft
+	}
+
+	setETag(w, entity.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// GetEntityBreadcrumb is an endpoint that returns the ancestor chain of an entity from the root
+// down to the entity itself
+func (h *Handler) GetEntityBreadcrumb(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	var entityID pgtype.UUID
+	if err := entityID.Scan(chi.URLParam(r, EntityIDKey)); err != nil {
+		errhandler.BadRequest(w, []byte(`{"error": "invalid entity_id"}`))
+		return
+	}
+
+	items, err := h.Queries.GetEntityPath(r.Context(), entityID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to read entity breadcrumb")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+	if len(items) == 0 {
+		errhandler.NotFound(w, []byte(`{"error": "entity not found"}`))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(BreadcrumbResponse{Items: items})
+}
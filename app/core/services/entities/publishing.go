@@ -0,0 +1,415 @@
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/publishing"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	validatorUtil "github.com/oriiyx/fritz/app/core/utils/validator"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+const ScheduleIDKey = "schedule_id"
+
+// DraftResponse is a draft with its data as JSON rather than bytes
+type DraftResponse struct {
+	db.EntityDraft
+	Data json.RawMessage `json:"data"`
+}
+
+func newDraftResponse(draft db.EntityDraft) *DraftResponse {
+	return &DraftResponse{EntityDraft: draft, Data: draft.Data}
+}
+
+type SaveDraftRequest struct {
+	Data map[string]interface{} `json:"data" validate:"required"`
+}
+
+type CreateScheduleRequest struct {
+	Action string    `json:"action" validate:"required,oneof=publish unpublish"`
+	RunAt  time.Time `json:"run_at" validate:"required"`
+}
+
+// GetDraft is an endpoint that returns the pending draft of an entity
+func (h *Handler) GetDraft(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	entity, _, ok := h.loadEntity(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	draft, err := h.Queries.GetEntityDraft(r.Context(), entity.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "entity has no draft"}`))
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to load entity draft")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(newDraftResponse(draft))
+}
+
+// SaveDraft is an endpoint that stores data as the draft of an entity without changing what
+// consumers see, publish makes it the published content. The draft advances the entity version,
+// the If-Match header carries the version the client expects to overwrite.
+func (h *Handler) SaveDraft(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	var req SaveDraftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to decode request")
+		errhandler.BadRequest(w, errhandler.RespInvalidRequestBody)
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to marshal validation errors")
+			errhandler.ServerError(w, errhandler.RespJSONEncodeFailure)
+			return
+		}
+		errhandler.ValidationErrors(w, respBody)
+		return
+	}
+
+	expectedVersion, ok := h.requireIfMatch(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	entity, adapter, ok := h.loadEntity(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	if !h.checkLock(w, r, reqID, entity.ID) {
+		return
+	}
+
+	entity, draft, err := h.saveDraft(r.Context(), adapter, entity, expectedVersion, req.Data)
+	h.writeDraft(w, reqID, entity, draft, err)
+}
+
+// DiscardDraft is an endpoint that drops the pending draft of an entity
+func (h *Handler) DiscardDraft(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	entity, _, ok := h.loadEntity(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	if !h.checkLock(w, r, reqID, entity.ID) {
+		return
+	}
+
+	err := h.inTx(r.Context(), func(tx pgx.Tx, queries *db.Queries) error {
+		draft, err := queries.GetEntityDraft(r.Context(), entity.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := queries.DeleteEntityDraft(r.Context(), entity.ID); err != nil {
+			return err
+		}
+
+		before, err := audit.DraftState(draft.Data)
+		if err != nil {
+			return err
+		}
+		return audit.RecordEntity(r.Context(), queries, audit.ActionDiscardDraft, entity.ID, before, nil)
+	})
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to discard entity draft")
+		errhandler.ServerError(w, errhandler.RespDBDataRemoveFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PublishEntity is an endpoint that makes the draft of an entity, if it has one, its published
+// content and publishes the entity
+func (h *Handler) PublishEntity(w http.ResponseWriter, r *http.Request) {
+	h.setPublished(w, r, true)
+}
+
+// UnpublishEntity is an endpoint that hides an entity from consumers, its draft is kept
+func (h *Handler) UnpublishEntity(w http.ResponseWriter, r *http.Request) {
+	h.setPublished(w, r, false)
+}
+
+func (h *Handler) setPublished(w http.ResponseWriter, r *http.Request, published bool) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	expectedVersion, ok := h.requireIfMatch(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	entity, adapter, ok := h.loadEntity(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	if !h.checkLock(w, r, reqID, entity.ID) {
+		return
+	}
+
+	if entity.Version != expectedVersion {
+		errhandler.PreconditionFailed(w, errhandler.RespEntityVersionConflict)
+		return
+	}
+
+	userID := ctxUtil.GetUserID(r.Context())
+	previous := entity.Version
+
+	beforeHook, afterHook := hooks.HookBeforeEntityUnpublish, hooks.HookAfterEntityUnpublish
+	if published {
+		beforeHook, afterHook = hooks.HookBeforeEntityPublish, hooks.HookAfterEntityPublish
+	}
+
+	payload := &hooks.EntityPublishPayload{Class: entity.EntityClass, Entity: entity, UserID: userID}
+	if !h.triggerBefore(w, r, reqID, beforeHook, payload) {
+		return
+	}
+
+	var result interface{}
+	err := h.inTx(r.Context(), func(tx pgx.Tx, queries *db.Queries) error {
+		before, err := audit.ReadEntityState(r.Context(), adapter.WithTx(tx), entity)
+		if err != nil {
+			return err
+		}
+
+		if published {
+			entity, result, err = publishing.Publish(r.Context(), tx, queries, adapter, entity, expectedVersion, userID)
+		} else {
+			entity, err = publishing.Unpublish(r.Context(), queries, entity, expectedVersion, userID)
+			if err == nil && entity.HasData {
+				result, err = adapter.WithTx(tx).Read(r.Context(), entity.ID)
+			}
+		}
+		if err != nil || entity.Version == previous {
+			return err
+		}
+
+		action := audit.ActionUnpublish
+		if published {
+			action = audit.ActionPublish
+		}
+		return h.record(r.Context(), queries, adapter, action, before, entity, result)
+	})
+	if err != nil {
+		if h.writeValidationError(w, reqID, err) {
+			return
+		}
+		if h.writeVersionConflict(w, reqID, err) {
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Bool("published", published).Msg("Failed to change entity publication")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return
+	}
+
+	h.Logger.Info().
+		Str("entity_id", entity.ID.String()).
+		Bool("published", published).
+		Msg("Entity publication changed")
+
+	if entity.Version != previous {
+		payload.Entity = entity
+		h.triggerAfter(r, reqID, afterHook, payload)
+	}
+
+	response := map[string]interface{}{
+		"entity": entity,
+		"data":   result,
+	}
+
+	setETag(w, entity.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// ListSchedules is an endpoint that lists the publish schedules of an entity by run time
+func (h *Handler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	entity, _, ok := h.loadEntity(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	schedules, err := h.Queries.ListPublishSchedules(r.Context(), entity.ID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to list publish schedules")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(schedules)
+}
+
+// CreateSchedule is an endpoint that schedules a publish or unpublish of an entity at a future
+// time, the background scheduler runs it once it is due
+func (h *Handler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	var req CreateScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to decode request")
+		errhandler.BadRequest(w, errhandler.RespInvalidRequestBody)
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to marshal validation errors")
+			errhandler.ServerError(w, errhandler.RespJSONEncodeFailure)
+			return
+		}
+		errhandler.ValidationErrors(w, respBody)
+		return
+	}
+
+	if !req.RunAt.After(time.Now()) {
+		errhandler.BadRequest(w, []byte(`{"error": "run_at must be in the future"}`))
+		return
+	}
+
+	entity, _, ok := h.loadEntity(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.Queries.CreatePublishSchedule(r.Context(), db.CreatePublishScheduleParams{
+		EntityID:  entity.ID,
+		Action:    req.Action,
+		RunAt:     pgtype.Timestamptz{Time: req.RunAt, Valid: true},
+		CreatedBy: ctxUtil.GetUserID(r.Context()),
+	})
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to create publish schedule")
+		errhandler.ServerError(w, errhandler.RespDBDataInsertFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(schedule)
+}
+
+// CancelSchedule is an endpoint that cancels a publish schedule that has not run yet
+func (h *Handler) CancelSchedule(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	entity, _, ok := h.loadEntity(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	var scheduleID pgtype.UUID
+	if err := scheduleID.Scan(chi.URLParam(r, ScheduleIDKey)); err != nil {
+		errhandler.BadRequest(w, []byte(`{"error": "invalid schedule_id"}`))
+		return
+	}
+
+	schedule, err := h.Queries.CancelPublishSchedule(r.Context(), db.CancelPublishScheduleParams{
+		ID:       scheduleID,
+		EntityID: entity.ID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "no pending schedule found"}`))
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to cancel publish schedule")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(schedule)
+}
+
+// patchDraft applies a patch to the pending draft of an entity, or to its published data when
+// there is no draft yet, and stores the result as the draft at the expected version
+func (h *Handler) patchDraft(ctx context.Context, adapter adapters.EntityAdapter, entity db.Entity, version int64, patch map[string]interface{}) (db.Entity, db.EntityDraft, error) {
+	return h.writeDraftData(ctx, adapter, entity, version, patch, true)
+}
+
+// saveDraft stores data as the draft of an entity at the expected version and records the change
+// of its pending data
+func (h *Handler) saveDraft(ctx context.Context, adapter adapters.EntityAdapter, entity db.Entity, version int64, data map[string]interface{}) (db.Entity, db.EntityDraft, error) {
+	return h.writeDraftData(ctx, adapter, entity, version, data, false)
+}
+
+func (h *Handler) writeDraftData(ctx context.Context, adapter adapters.EntityAdapter, entity db.Entity, version int64, data map[string]interface{}, partial bool) (db.Entity, db.EntityDraft, error) {
+	var draft db.EntityDraft
+	err := h.inTx(ctx, func(tx pgx.Tx, queries *db.Queries) error {
+		adapter := adapter.WithTx(tx)
+
+		if partial {
+			pending, err := publishing.PendingData(ctx, queries, adapter, entity)
+			if err != nil {
+				return err
+			}
+			for key, value := range data {
+				pending[key] = value
+			}
+			data = pending
+		}
+
+		var err error
+		entity, draft, err = publishing.SaveAuditedDraft(ctx, queries, adapter, entity, version, data, ctxUtil.GetUserID(ctx))
+		return err
+	})
+	return entity, draft, err
+}
+
+// writeDraft responds with the entity and its saved draft, a published entity keeps serving its
+// published content until the draft is published
+func (h *Handler) writeDraft(w http.ResponseWriter, reqID string, entity db.Entity, draft db.EntityDraft, err error) {
+	if err != nil {
+		if h.writeValidationError(w, reqID, err) {
+			return
+		}
+		if h.writeVersionConflict(w, reqID, err) {
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to save entity draft")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return
+	}
+
+	response := map[string]interface{}{
+		"entity": entity,
+		"draft":  newDraftResponse(draft),
+	}
+
+	setETag(w, entity.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,206 @@
+package publishing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/versions"
+	"github.com/oriiyx/fritz/app/core/services/entities/writes"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// Schedule actions
+const (
+	ActionPublish   = "publish"
+	ActionUnpublish = "unpublish"
+)
+
+// Schedule statuses
+const (
+	StatusPending   = "pending"
+	StatusDone      = "done"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// SaveDraft validates data as a full save would and stores it as the draft of an entity, replacing
+// any earlier draft. The published content is not touched but the draft claims the next version of
+// the entity, so saves against a stale version match no rows and fail with pgx.ErrNoRows. It
+// returns the entity at its new version with the draft.
+func SaveDraft(ctx context.Context, queries *db.Queries, adapter adapters.EntityAdapter, entity db.Entity, version int64, data map[string]interface{}, userID pgtype.UUID) (db.Entity, db.EntityDraft, error) {
+	if err := adapter.Validate(data); err != nil {
+		return entity, db.EntityDraft{}, err
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return entity, db.EntityDraft{}, err
+	}
+
+	entity, err = queries.ClaimEntityVersion(ctx, db.ClaimEntityVersionParams{ID: entity.ID, Version: version, UpdatedBy: userID})
+	if err != nil {
+		return entity, db.EntityDraft{}, err
+	}
+
+	draft, err := queries.UpsertEntityDraft(ctx, db.UpsertEntityDraftParams{
+		EntityID:    entity.ID,
+		EntityClass: entity.EntityClass,
+		Data:        encoded,
+		BaseVersion: entity.Version,
+		UpdatedBy:   userID,
+	})
+	return entity, draft, err
+}
+
+// SaveAuditedDraft stores data as the draft of an entity like SaveDraft and records the change of
+// its pending data in the audit log. The queries and adapter must be bound to the same transaction.
+func SaveAuditedDraft(ctx context.Context, queries *db.Queries, adapter adapters.EntityAdapter, entity db.Entity, version int64, data map[string]interface{}, userID pgtype.UUID) (db.Entity, db.EntityDraft, error) {
+	before, err := pendingState(ctx, queries, adapter, entity)
+	if err != nil {
+		return entity, db.EntityDraft{}, err
+	}
+
+	entity, draft, err := SaveDraft(ctx, queries, adapter, entity, version, data, userID)
+	if err != nil {
+		return entity, draft, err
+	}
+
+	after, err := audit.DraftState(draft.Data)
+	if err != nil {
+		return entity, draft, err
+	}
+	return entity, draft, audit.RecordEntity(ctx, queries, audit.ActionSaveDraft, entity.ID, before, after)
+}
+
+// PendingData is the data a new draft of an entity starts from, its earlier draft or else its
+// published data
+func PendingData(ctx context.Context, queries *db.Queries, adapter adapters.EntityAdapter, entity db.Entity) (map[string]interface{}, error) {
+	draft, err := queries.GetEntityDraft(ctx, entity.ID)
+	switch {
+	case err == nil:
+		return DraftData(draft)
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	}
+
+	if !entity.HasData {
+		return map[string]interface{}{}, nil
+	}
+
+	snapshot, err := publishedSnapshot(ctx, adapter, entity)
+	if err != nil {
+		return nil, err
+	}
+	return versions.RestoreData(snapshot, adapter.Columns())
+}
+
+// pendingState is the audited state of the data a new draft replaces, the earlier draft or else
+// the published data
+func pendingState(ctx context.Context, queries *db.Queries, adapter adapters.EntityAdapter, entity db.Entity) (audit.State, error) {
+	draft, err := queries.GetEntityDraft(ctx, entity.ID)
+	switch {
+	case err == nil:
+		return audit.DraftState(draft.Data)
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	}
+
+	if !entity.HasData {
+		return audit.State{}, nil
+	}
+
+	snapshot, err := publishedSnapshot(ctx, adapter, entity)
+	if err != nil {
+		return nil, err
+	}
+	return audit.DraftState(snapshot)
+}
+
+func publishedSnapshot(ctx context.Context, adapter adapters.EntityAdapter, entity db.Entity) ([]byte, error) {
+	row, err := adapter.Read(ctx, entity.ID)
+	if err != nil {
+		return nil, err
+	}
+	return versions.Snapshot(row, adapter.Columns())
+}
+
+// DraftData decodes the data of a draft for an adapter write
+func DraftData(draft db.EntityDraft) (map[string]interface{}, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(draft.Data, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Publish writes the draft of an entity, when it has one, through the adapter and publishes the
+// entity. version is the entity version the caller expects, writes match no rows and fail with
+// pgx.ErrNoRows when the entity moved on. It returns the entity with its published data row, nil
+// when the entity has no data. The queries must be bound to tx.
+func Publish(ctx context.Context, tx pgx.Tx, queries *db.Queries, adapter adapters.EntityAdapter, entity db.Entity, version int64, userID pgtype.UUID) (db.Entity, interface{}, error) {
+	var result interface{}
+
+	draft, err := queries.GetEntityDraft(ctx, entity.ID)
+	switch {
+	case err == nil:
+		data, err := DraftData(draft)
+		if err != nil {
+			return entity, nil, err
+		}
+
+		entity, result, err = writes.Data(ctx, tx, queries, adapter, entity, version, data, userID)
+		if err != nil {
+			return entity, nil, err
+		}
+		version = entity.Version
+
+		if err := queries.DeleteEntityDraft(ctx, entity.ID); err != nil {
+			return entity, nil, err
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return entity, nil, err
+	}
+
+	entity, err = setPublished(ctx, queries, entity, version, true, userID)
+	if err != nil {
+		return entity, nil, err
+	}
+
+	if result == nil && entity.HasData {
+		if result, err = adapter.WithTx(tx).Read(ctx, entity.ID); err != nil {
+			return entity, nil, err
+		}
+	}
+
+	return entity, result, nil
+}
+
+// Unpublish hides an entity from consumers at the expected version, its draft is kept
+func Unpublish(ctx context.Context, queries *db.Queries, entity db.Entity, version int64, userID pgtype.UUID) (db.Entity, error) {
+	return setPublished(ctx, queries, entity, version, false, userID)
+}
+
+func setPublished(ctx context.Context, queries *db.Queries, entity db.Entity, version int64, published bool, userID pgtype.UUID) (db.Entity, error) {
+	if entity.Version != version {
+		return entity, pgx.ErrNoRows
+	}
+	if entity.Published == published {
+		return entity, nil
+	}
+
+	return queries.UpdateEntity(ctx, db.UpdateEntityParams{
+		ID:        entity.ID,
+		ParentID:  entity.ParentID,
+		OKey:      entity.OKey,
+		OPath:     entity.OPath,
+		Published: published,
+		HasData:   entity.HasData,
+		UpdatedBy: userID,
+		Version:   version,
+	})
+}
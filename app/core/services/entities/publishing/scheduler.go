@@ -0,0 +1,211 @@
+package publishing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/kernel"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/versions"
+	"github.com/oriiyx/fritz/app/core/services/entities/writes"
+	db "github.com/oriiyx/fritz/database/generated"
+	"github.com/rs/zerolog"
+)
+
+// Beginner starts transactions, it is satisfied by *pgxpool.Pool and pgx.Tx
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Scheduler runs due publish and unpublish schedules
+type Scheduler struct {
+	conn     Beginner
+	queries  *db.Queries
+	logger   *zerolog.Logger
+	hooks    *kernel.Hooks
+	interval time.Duration
+	policy   versions.Policy
+}
+
+// NewScheduler creates the scheduler, the publish and unpublish hooks of hooks run for every
+// schedule it executes. Nil runs none.
+func NewScheduler(conn Beginner, queries *db.Queries, logger *zerolog.Logger, hooks *kernel.Hooks, interval time.Duration, policy versions.Policy) *Scheduler {
+	return &Scheduler{
+		conn:     conn,
+		queries:  queries,
+		logger:   logger,
+		hooks:    hooks,
+		interval: interval,
+		policy:   policy,
+	}
+}
+
+// Run executes due schedules right away and then every interval until ctx is done. A zero
+// interval disables the scheduler.
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		s.logger.Info().Msg("Publish scheduler is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		executed, err := s.RunDue(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error().Err(err).Int("executed", executed).Msg("Failed to run publish schedules")
+		} else if executed > 0 {
+			s.logger.Info().Int("executed", executed).Msg("Ran publish schedules")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue executes every schedule due at now, each in its own transaction, and returns how many
+// ran. A schedule that cannot be executed is marked failed with the reason.
+func (s *Scheduler) RunDue(ctx context.Context, now time.Time) (int, error) {
+	executed := 0
+	for {
+		ran, err := s.runNext(ctx, pgtype.Timestamptz{Time: now, Valid: true})
+		if err != nil || !ran {
+			return executed, err
+		}
+		executed++
+	}
+}
+
+// runNext claims the oldest due schedule and executes it, it returns false when none is due
+func (s *Scheduler) runNext(ctx context.Context, now pgtype.Timestamptz) (bool, error) {
+	var (
+		ran   bool
+		after *afterHook
+	)
+	err := pgx.BeginFunc(ctx, s.conn, func(tx pgx.Tx) error {
+		queries := s.queries.WithTx(tx)
+
+		schedule, err := queries.ClaimDuePublishSchedule(ctx, now)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ran = true
+
+		// The action runs in a savepoint so a failure can still be recorded on the schedule
+		params := db.FinishPublishScheduleParams{ID: schedule.ID, Status: StatusDone}
+		execErr := pgx.BeginFunc(ctx, tx, func(sp pgx.Tx) error {
+			var err error
+			after, err = s.execute(ctx, sp, s.queries.WithTx(sp), schedule)
+			return err
+		})
+		if execErr != nil {
+			after = nil
+			params.Status = StatusFailed
+			params.Error = pgtype.Text{String: execErr.Error(), Valid: true}
+			s.logger.Warn().Err(execErr).
+				Str("schedule_id", schedule.ID.String()).
+				Str("entity_id", schedule.EntityID.String()).
+				Str("action", schedule.Action).
+				Msg("Publish schedule failed")
+		}
+
+		return queries.FinishPublishSchedule(ctx, params)
+	})
+
+	// The change is committed by now, a failing after hook is only logged
+	if err == nil && after != nil {
+		if hookErr := s.trigger(ctx, after.name, after.payload); hookErr != nil {
+			s.logger.Error().Err(hookErr).Str("hook", after.name).Msg("Hook failed after the publish schedule")
+		}
+	}
+
+	return ran, err
+}
+
+// afterHook is the after hook of an executed schedule, it runs once the schedule is committed
+type afterHook struct {
+	name    string
+	payload interface{}
+}
+
+// trigger runs the callbacks of a hook
+func (s *Scheduler) trigger(ctx context.Context, name string, payload interface{}) error {
+	if s.hooks == nil {
+		return nil
+	}
+	return s.hooks.Trigger(ctx, name, payload)
+}
+
+// execute runs the action of a schedule and returns the after hook of the change, nil when the
+// entity already was in the requested state. A before hook that fails vetoes the schedule.
+func (s *Scheduler) execute(ctx context.Context, tx pgx.Tx, queries *db.Queries, schedule db.PublishSchedule) (*afterHook, error) {
+	entity, err := queries.GetEntityByID(ctx, schedule.EntityID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New("entity not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	adapter, err := adapters.Get(entity.EntityClass)
+	if err != nil {
+		return nil, err
+	}
+
+	var beforeHook, afterName, action string
+	switch schedule.Action {
+	case ActionPublish:
+		beforeHook, afterName, action = hooks.HookBeforeEntityPublish, hooks.HookAfterEntityPublish, audit.ActionPublish
+	case ActionUnpublish:
+		beforeHook, afterName, action = hooks.HookBeforeEntityUnpublish, hooks.HookAfterEntityUnpublish, audit.ActionUnpublish
+	default:
+		return nil, fmt.Errorf("unknown action %q", schedule.Action)
+	}
+
+	// The user who scheduled the change is its author, the scheduler has no request
+	payload := &hooks.EntityPublishPayload{Class: entity.EntityClass, Entity: entity, UserID: schedule.CreatedBy}
+	if err := s.trigger(ctx, beforeHook, payload); err != nil {
+		return nil, err
+	}
+
+	before, err := audit.ReadEntityState(ctx, adapter.WithTx(tx), entity)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := entity.Version
+
+	var data interface{}
+	if schedule.Action == ActionPublish {
+		entity, data, err = Publish(ctx, tx, queries, adapter, entity, entity.Version, schedule.CreatedBy)
+	} else {
+		entity, err = Unpublish(ctx, queries, entity, entity.Version, schedule.CreatedBy)
+		if err == nil && entity.HasData {
+			data, err = adapter.WithTx(tx).Read(ctx, entity.ID)
+		}
+	}
+	if err != nil || entity.Version == previous {
+		// Nothing changed when the entity already was in the requested state
+		return nil, err
+	}
+
+	if err := writes.Record(ctx, queries, adapter, action, before, entity, data, schedule.CreatedBy, s.policy); err != nil {
+		return nil, err
+	}
+
+	payload.Entity = entity
+	return &afterHook{name: afterName, payload: payload}, nil
+}
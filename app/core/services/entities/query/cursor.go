@@ -0,0 +1,171 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+)
+
+// cursor is the keyset position after the last row of a page, it is only valid for the sort it was built with
+type cursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+	ID     string            `json:"id"`
+}
+
+// sortSignature identifies a sort so a cursor cannot be reused with a different one
+func sortSignature(sortFields []SortField) string {
+	parts := make([]string, 0, len(sortFields))
+	for _, field := range sortFields {
+		if field.Desc {
+			parts = append(parts, "-"+field.Field)
+		} else {
+			parts = append(parts, field.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// EncodeCursor builds the cursor for the row with the given sort values and entity id
+func EncodeCursor(sortFields []SortField, values []interface{}, id pgtype.UUID) (string, error) {
+	c := cursor{
+		Sort:   sortSignature(sortFields),
+		Values: make([]json.RawMessage, 0, len(values)),
+		ID:     id.String(),
+	}
+
+	for _, value := range values {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode cursor value: %w", err)
+		}
+		c.Values = append(c.Values, raw)
+	}
+
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+func decodeCursor(encoded string) (*cursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalid("malformed cursor")
+	}
+
+	var c cursor
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, invalid("malformed cursor")
+	}
+
+	return &c, nil
+}
+
+// cursorCondition renders the keyset predicate "row comes after the cursor" for the sort,
+// nulls sort last in both directions and e.id breaks ties
+func (b *Builder) cursorCondition(sb *statementBuilder, encoded string, sortFields []SortField, sortColumns []column) (string, error) {
+	c, err := decodeCursor(encoded)
+	if err != nil {
+		return "", err
+	}
+	if c.Sort != sortSignature(sortFields) || len(c.Values) != len(sortColumns) {
+		return "", invalid("cursor does not match the requested sort")
+	}
+
+	var id pgtype.UUID
+	if err := id.Scan(c.ID); err != nil {
+		return "", invalid("malformed cursor")
+	}
+
+	values := make([]interface{}, len(sortColumns))
+	for i, col := range sortColumns {
+		value, err := cursorValue(col.kind, c.Values[i])
+		if err != nil {
+			return "", err
+		}
+		values[i] = value
+	}
+
+	var alternatives []string
+	for i := 0; i <= len(sortColumns); i++ {
+		// Nothing sorts after a null, it is always last
+		if i < len(sortColumns) && values[i] == nil {
+			continue
+		}
+
+		var terms []string
+		for j := 0; j < i; j++ {
+			if values[j] == nil {
+				terms = append(terms, sortColumns[j].expr+" IS NULL")
+			} else {
+				terms = append(terms, fmt.Sprintf("%s = %s", sortColumns[j].expr, sb.arg(values[j], sortColumns[j].cast)))
+			}
+		}
+
+		if i == len(sortColumns) {
+			terms = append(terms, "e.id > "+sb.arg(id, "uuid"))
+		} else {
+			operator := ">"
+			if sortFields[i].Desc {
+				operator = "<"
+			}
+			terms = append(terms, fmt.Sprintf("(%s %s %s OR %s IS NULL)",
+				sortColumns[i].expr, operator, sb.arg(values[i], sortColumns[i].cast), sortColumns[i].expr))
+		}
+
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", nil
+}
+
+// cursorValue decodes a cursor value for a column of the given kind, null stays nil
+func cursorValue(kind definitions.ValueKind, raw json.RawMessage) (interface{}, error) {
+	if string(raw) == "null" {
+		return nil, nil
+	}
+
+	switch kind {
+	case definitions.KindText:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, invalid("malformed cursor")
+		}
+		return s, nil
+	case definitions.KindInteger:
+		n, err := strconv.ParseInt(string(raw), 10, 64)
+		if err != nil {
+			return nil, invalid("malformed cursor")
+		}
+		return n, nil
+	case definitions.KindFloat:
+		f, err := strconv.ParseFloat(string(raw), 64)
+		if err != nil {
+			return nil, invalid("malformed cursor")
+		}
+		return f, nil
+	case definitions.KindDate, definitions.KindTimestamp:
+		var t time.Time
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return nil, invalid("malformed cursor")
+		}
+		return t, nil
+	case definitions.KindBoolean:
+		var v bool
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, invalid("malformed cursor")
+		}
+		return v, nil
+	default:
+		return nil, invalid("cannot page by a field of this type")
+	}
+}
@@ -0,0 +1,113 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Parse reads a Query from URL parameters:
+//
+//	filter=<field>:<op>[:<value>]  repeatable, in and between take comma separated values
+//	sort=<field>,-<field>          a leading dash sorts descending
+//	limit, cursor, published, drafts, path_prefix, parent_id
+//
+// Only published entities are listed unless drafts=true, see ParseVisibility.
+func Parse(values url.Values) (Query, error) {
+	var q Query
+
+	for _, raw := range values["filter"] {
+		parts := strings.SplitN(raw, ":", 3)
+		if len(parts) < 2 || parts[0] == "" {
+			return q, invalid("filter %q must look like field:op:value", raw)
+		}
+
+		filter := Filter{Field: parts[0], Op: Operator(parts[1])}
+		if len(parts) == 3 {
+			switch filter.Op {
+			case OpIn, OpBetween:
+				filter.Values = strings.Split(parts[2], ",")
+			default:
+				filter.Values = []string{parts[2]}
+			}
+		}
+
+		q.Filters = append(q.Filters, filter)
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			if strings.HasPrefix(field, "-") {
+				q.Sort = append(q.Sort, SortField{Field: field[1:], Desc: true})
+			} else {
+				q.Sort = append(q.Sort, SortField{Field: field})
+			}
+		}
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return q, invalid("limit must be a positive integer")
+		}
+		q.Limit = n
+	}
+
+	q.Cursor = values.Get("cursor")
+	q.PathPrefix = values.Get("path_prefix")
+
+	published, err := ParseVisibility(values)
+	if err != nil {
+		return q, invalid("%s", err)
+	}
+	q.Published = published
+
+	if parentID := values.Get("parent_id"); parentID != "" {
+		var id pgtype.UUID
+		if err := id.Scan(parentID); err != nil {
+			return q, invalid("parent_id must be a UUID")
+		}
+		q.ParentID = &id
+	}
+
+	return q, nil
+}
+
+// ParseVisibility reads the published filter shared by the read endpoints. Consumers only see
+// published entities, drafts=true includes unpublished ones and published then narrows the
+// result either way. Errors are plain messages for the caller to wrap.
+func ParseVisibility(values url.Values) (*bool, error) {
+	drafts := false
+	if value := values.Get("drafts"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New("drafts must be true or false")
+		}
+		drafts = b
+	}
+
+	if value := values.Get("published"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New("published must be true or false")
+		}
+		if !b && !drafts {
+			return nil, errors.New("unpublished entities are only visible with drafts=true")
+		}
+		return &b, nil
+	}
+
+	if drafts {
+		return nil, nil
+	}
+
+	published := true
+	return &published, nil
+}
@@ -0,0 +1,408 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/services/objects/definition_builder"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 1000
+)
+
+// Operator is a filter comparison, only the operators allowed for a column's value kind are accepted
+type Operator string
+
+const (
+	OpEq       Operator = "eq"
+	OpNeq      Operator = "neq"
+	OpIn       Operator = "in"
+	OpGt       Operator = "gt"
+	OpGte      Operator = "gte"
+	OpLt       Operator = "lt"
+	OpLte      Operator = "lte"
+	OpBetween  Operator = "between"
+	OpContains Operator = "contains"
+	OpPrefix   Operator = "prefix"
+	OpIsNull   Operator = "is_null"
+	OpNotNull  Operator = "not_null"
+)
+
+var comparisonOperators = map[Operator]string{
+	OpEq:  "=",
+	OpNeq: "<>",
+	OpGt:  ">",
+	OpGte: ">=",
+	OpLt:  "<",
+	OpLte: "<=",
+}
+
+var allowedOperators = map[definitions.ValueKind]map[Operator]bool{
+	definitions.KindText: {
+		OpEq: true, OpNeq: true, OpIn: true, OpContains: true, OpPrefix: true, OpIsNull: true, OpNotNull: true,
+	},
+	definitions.KindInteger: {
+		OpEq: true, OpNeq: true, OpIn: true, OpGt: true, OpGte: true, OpLt: true, OpLte: true, OpBetween: true, OpIsNull: true, OpNotNull: true,
+	},
+	definitions.KindFloat: {
+		OpEq: true, OpNeq: true, OpIn: true, OpGt: true, OpGte: true, OpLt: true, OpLte: true, OpBetween: true, OpIsNull: true, OpNotNull: true,
+	},
+	definitions.KindDate: {
+		OpEq: true, OpNeq: true, OpIn: true, OpGt: true, OpGte: true, OpLt: true, OpLte: true, OpBetween: true, OpIsNull: true, OpNotNull: true,
+	},
+	definitions.KindTimestamp: {
+		OpEq: true, OpNeq: true, OpGt: true, OpGte: true, OpLt: true, OpLte: true, OpBetween: true, OpIsNull: true, OpNotNull: true,
+	},
+	definitions.KindBoolean: {
+		OpEq: true, OpNeq: true, OpIsNull: true, OpNotNull: true,
+	},
+}
+
+// ErrInvalidQuery wraps every error caused by the client's query rather than the database
+var ErrInvalidQuery = errors.New("invalid query")
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidQuery, fmt.Sprintf(format, args...))
+}
+
+// Filter restricts a component column, Values holds the raw operands of the operator
+type Filter struct {
+	Field  string   `json:"field"`
+	Op     Operator `json:"op"`
+	Values []string `json:"values,omitempty"`
+}
+
+// SortField orders by a component column or one of the sortable entity columns
+type SortField struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc"`
+}
+
+// Query describes a list request for a single entity class
+type Query struct {
+	Filters    []Filter
+	Sort       []SortField
+	Limit      int
+	Cursor     string
+	Published  *bool
+	PathPrefix string
+	ParentID   *pgtype.UUID
+}
+
+// column is a resolved, already quoted column expression
+type column struct {
+	name      string
+	expr      string
+	cast      string
+	kind      definitions.ValueKind
+	component bool
+}
+
+// EntityColumns is the select list of the entities table aliased as e, it scans through EntityDest
+var EntityColumns = []string{
+	"e.id", "e.entity_class", "e.parent_id", "e.o_key", "e.o_path", "e.o_type", "e.published",
+	"e.has_data", "e.created_at", "e.updated_at", "e.created_by", "e.updated_by", "e.version",
+	"e.sort_index",
+}
+
+// sortableEntityColumns may be used in sort next to component columns, components win on name clashes
+var sortableEntityColumns = map[string]column{
+	"o_key":      {name: "o_key", expr: "e.o_key", cast: "text", kind: definitions.KindText},
+	"o_path":     {name: "o_path", expr: "e.o_path", cast: "text", kind: definitions.KindText},
+	"created_at": {name: "created_at", expr: "e.created_at", cast: "timestamptz", kind: definitions.KindTimestamp},
+	"updated_at": {name: "updated_at", expr: "e.updated_at", cast: "timestamptz", kind: definitions.KindTimestamp},
+	"sort_index": {name: "sort_index", expr: "e.sort_index", cast: "integer", kind: definitions.KindInteger},
+}
+
+// Statement is a rendered query with its positional arguments
+type Statement struct {
+	SQL  string
+	Args []interface{}
+
+	// Components lists the component columns selected after the entity columns, in order
+	Components []string
+	// Sort is the effective sort, including the defaults, used to build the next cursor
+	Sort  []SortField
+	Limit int
+}
+
+// Builder renders list queries for one entity definition
+type Builder struct {
+	definition *definitions.EntityDefinition
+	columns    map[string]column
+	order      []string
+}
+
+func NewBuilder(definition *definitions.EntityDefinition) (*Builder, error) {
+	if err := definition.ValidateIdentifiers(); err != nil {
+		return nil, err
+	}
+
+	b := &Builder{
+		definition: definition,
+		columns:    make(map[string]column, len(definition.Layout.Components)),
+	}
+
+	for _, component := range definition.Layout.Components {
+		b.columns[component.Name] = column{
+			name:      component.Name,
+			expr:      "d." + pgx.Identifier{component.Name}.Sanitize(),
+			cast:      argumentCast(component.DBType),
+			kind:      component.DBType.Kind(),
+			component: true,
+		}
+		b.order = append(b.order, component.Name)
+	}
+
+	return b, nil
+}
+
+// statementBuilder collects positional arguments while the SQL is rendered
+type statementBuilder struct {
+	args []interface{}
+}
+
+func (s *statementBuilder) arg(value interface{}, cast string) string {
+	s.args = append(s.args, value)
+	if cast == "" {
+		return fmt.Sprintf("$%d", len(s.args))
+	}
+	return fmt.Sprintf("$%d::%s", len(s.args), cast)
+}
+
+// Build renders the list query, every value is passed as an argument and every identifier is quoted
+func (b *Builder) Build(q Query) (*Statement, error) {
+	sb := &statementBuilder{}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		return nil, invalid("limit must be at most %d", MaxLimit)
+	}
+
+	sortFields := q.Sort
+	if len(sortFields) == 0 {
+		sortFields = []SortField{{Field: "created_at"}}
+	}
+
+	sortColumns := make([]column, 0, len(sortFields))
+	for _, field := range sortFields {
+		col, ok := b.sortColumn(field.Field)
+		if !ok {
+			return nil, invalid("cannot sort by unknown field %q", field.Field)
+		}
+		sortColumns = append(sortColumns, col)
+	}
+
+	// Trashed entities are only reachable through the trash
+	conditions := []string{"e.entity_class = " + sb.arg(b.definition.ID, "text"), "e.deleted_at IS NULL"}
+
+	if q.Published != nil {
+		conditions = append(conditions, "e.published = "+sb.arg(*q.Published, "boolean"))
+	}
+	if q.PathPrefix != "" {
+		conditions = append(conditions, "e.o_path LIKE "+sb.arg(escapeLike(q.PathPrefix)+"%", "text"))
+	}
+	if q.ParentID != nil {
+		conditions = append(conditions, "e.parent_id = "+sb.arg(*q.ParentID, "uuid"))
+	}
+
+	for _, filter := range q.Filters {
+		condition, err := b.filterCondition(sb, filter)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if q.Cursor != "" {
+		condition, err := b.cursorCondition(sb, q.Cursor, sortFields, sortColumns)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	selectList := append([]string{}, EntityColumns...)
+	for _, name := range b.order {
+		selectList = append(selectList, b.columns[name].expr)
+	}
+
+	orderBy := make([]string, 0, len(sortColumns)+1)
+	for i, col := range sortColumns {
+		direction := "ASC"
+		if sortFields[i].Desc {
+			direction = "DESC"
+		}
+		orderBy = append(orderBy, fmt.Sprintf("%s %s NULLS LAST", col.expr, direction))
+	}
+	orderBy = append(orderBy, "e.id ASC")
+
+	sql := fmt.Sprintf(
+		"SELECT %s\nFROM entities e\nJOIN %s d ON d.entity_id = e.id\nWHERE %s\nORDER BY %s\nLIMIT %d",
+		strings.Join(selectList, ", "),
+		pgx.Identifier{definition_builder.EntityTableName(b.definition.ID)}.Sanitize(),
+		strings.Join(conditions, "\n  AND "),
+		strings.Join(orderBy, ", "),
+		// One extra row tells whether another page exists
+		limit+1,
+	)
+
+	return &Statement{
+		SQL:        sql,
+		Args:       sb.args,
+		Components: b.order,
+		Sort:       sortFields,
+		Limit:      limit,
+	}, nil
+}
+
+func (b *Builder) sortColumn(name string) (column, bool) {
+	if col, ok := b.columns[name]; ok {
+		return col, true
+	}
+	col, ok := sortableEntityColumns[name]
+	return col, ok
+}
+
+func (b *Builder) filterCondition(sb *statementBuilder, filter Filter) (string, error) {
+	col, ok := b.columns[filter.Field]
+	if !ok {
+		return "", invalid("cannot filter by unknown field %q", filter.Field)
+	}
+	if !allowedOperators[col.kind][filter.Op] {
+		return "", invalid("operator %q is not supported for field %q", filter.Op, filter.Field)
+	}
+
+	switch filter.Op {
+	case OpIsNull:
+		return col.expr + " IS NULL", nil
+	case OpNotNull:
+		return col.expr + " IS NOT NULL", nil
+	}
+
+	if len(filter.Values) == 0 {
+		return "", invalid("operator %q on field %q needs a value", filter.Op, filter.Field)
+	}
+
+	switch filter.Op {
+	case OpIn:
+		placeholders := make([]string, 0, len(filter.Values))
+		for _, raw := range filter.Values {
+			value, err := parseValue(col.kind, raw)
+			if err != nil {
+				return "", invalid("field %q: %s", filter.Field, err)
+			}
+			placeholders = append(placeholders, sb.arg(value, col.cast))
+		}
+		return fmt.Sprintf("%s IN (%s)", col.expr, strings.Join(placeholders, ", ")), nil
+
+	case OpBetween:
+		if len(filter.Values) != 2 {
+			return "", invalid("operator between on field %q needs exactly two values", filter.Field)
+		}
+		low, err := parseValue(col.kind, filter.Values[0])
+		if err != nil {
+			return "", invalid("field %q: %s", filter.Field, err)
+		}
+		high, err := parseValue(col.kind, filter.Values[1])
+		if err != nil {
+			return "", invalid("field %q: %s", filter.Field, err)
+		}
+		return fmt.Sprintf("%s BETWEEN %s AND %s", col.expr, sb.arg(low, col.cast), sb.arg(high, col.cast)), nil
+
+	case OpContains:
+		return fmt.Sprintf("%s ILIKE %s", col.expr, sb.arg("%"+escapeLike(filter.Values[0])+"%", "text")), nil
+
+	case OpPrefix:
+		return fmt.Sprintf("%s LIKE %s", col.expr, sb.arg(escapeLike(filter.Values[0])+"%", "text")), nil
+	}
+
+	if len(filter.Values) != 1 {
+		return "", invalid("operator %q on field %q needs exactly one value", filter.Op, filter.Field)
+	}
+
+	value, err := parseValue(col.kind, filter.Values[0])
+	if err != nil {
+		return "", invalid("field %q: %s", filter.Field, err)
+	}
+
+	return fmt.Sprintf("%s %s %s", col.expr, comparisonOperators[filter.Op], sb.arg(value, col.cast)), nil
+}
+
+// argumentCast picks the type arguments are cast to when compared with a column of the given type
+func argumentCast(dbType definitions.DBType) string {
+	switch dbType.Kind() {
+	case definitions.KindText:
+		// char would truncate the argument to the column length
+		return "text"
+	case definitions.KindInteger:
+		return "bigint"
+	case definitions.KindFloat:
+		return string(dbType.Base())
+	case definitions.KindDate:
+		return "date"
+	case definitions.KindTimestamp:
+		return "timestamptz"
+	case definitions.KindBoolean:
+		return "boolean"
+	default:
+		return ""
+	}
+}
+
+// parseValue converts a raw filter operand into the Go type matching the column kind
+func parseValue(kind definitions.ValueKind, raw string) (interface{}, error) {
+	switch kind {
+	case definitions.KindText:
+		return raw, nil
+	case definitions.KindInteger:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", raw)
+		}
+		return n, nil
+	case definitions.KindFloat:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", raw)
+		}
+		return f, nil
+	case definitions.KindDate:
+		t, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a date in YYYY-MM-DD format", raw)
+		}
+		return t, nil
+	case definitions.KindTimestamp:
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an RFC3339 timestamp", raw)
+		}
+		return t, nil
+	case definitions.KindBoolean:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", raw)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("values of this type cannot be filtered")
+	}
+}
+
+// escapeLike makes every character of value match literally in a LIKE pattern
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
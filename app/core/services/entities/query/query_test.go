@@ -0,0 +1,195 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions/definitionstest"
+)
+
+func testDefinition() *definitions.EntityDefinition {
+	return definitionstest.Product(
+		definitions.DataComponent{Type: definitions.ComponentInput, Name: "sku", DBType: "varchar(64)", Mandatory: true},
+		definitions.DataComponent{Type: definitions.ComponentFloat8, Name: "price", DBType: definitions.DataTypeFloat8},
+		definitions.DataComponent{Type: definitions.ComponentDate, Name: "launch_date", DBType: definitions.DataTypeDate},
+	)
+}
+
+func TestBuild(t *testing.T) {
+	b, err := NewBuilder(testDefinition())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		params   string
+		contains []string
+		args     int
+		err      bool
+	}{
+		{
+			name:     "defaults",
+			params:   "",
+			contains: []string{`JOIN "entity_product" d`, "e.deleted_at IS NULL", "e.published = $2::boolean", "ORDER BY e.created_at ASC NULLS LAST, e.id ASC", "LIMIT 51"},
+			args:     2,
+		},
+		{
+			name:     "comparison and in",
+			params:   "drafts=true&filter=stock:gte:10&filter=sku:in:a,b,c&sort=-price,sku&limit=10",
+			contains: []string{`d."stock" >= $2::bigint`, `d."sku" IN ($3::text, $4::text, $5::text)`, `d."price" DESC NULLS LAST, d."sku" ASC NULLS LAST`, "LIMIT 11"},
+			args:     5,
+		},
+		{
+			name:     "contains escapes like wildcards",
+			params:   "drafts=true&filter=sku:contains:50%25_off",
+			contains: []string{`d."sku" ILIKE $2::text`},
+			args:     2,
+		},
+		{
+			name:     "entity filters",
+			params:   "published=true&path_prefix=/products/&parent_id=00000000-0000-0000-0000-000000000001",
+			contains: []string{"e.published = $2::boolean", "e.o_path LIKE $3::text", "e.parent_id = $4::uuid"},
+			args:     4,
+		},
+		{
+			name:     "null checks take no value",
+			params:   "drafts=true&filter=launch_date:is_null",
+			contains: []string{`d."launch_date" IS NULL`},
+			args:     1,
+		},
+		{name: "unpublished without drafts", params: "published=false", err: true},
+		{name: "unknown field", params: "filter=nope:eq:1", err: true},
+		{name: "operator not allowed for type", params: "filter=stock:contains:1", err: true},
+		{name: "value of wrong type", params: "filter=stock:eq:ten", err: true},
+		{name: "between needs two values", params: "filter=price:between:1", err: true},
+		{name: "unknown sort field", params: "sort=nope", err: true},
+		{name: "raw sql in field", params: "filter=sku%3BDROP%20TABLE%20entities:eq:1", err: true},
+		{name: "limit too large", params: "limit=100000", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			q, err := Parse(values)
+			var stmt *Statement
+			if err == nil {
+				stmt, err = b.Build(q)
+			}
+
+			if tt.err {
+				if !errors.Is(err, ErrInvalidQuery) {
+					t.Fatalf("expected an invalid query error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, fragment := range tt.contains {
+				if !strings.Contains(stmt.SQL, fragment) {
+					t.Errorf("expected SQL to contain %q, got:\n%s", fragment, stmt.SQL)
+				}
+			}
+			if len(stmt.Args) != tt.args {
+				t.Errorf("expected %d args, got %d: %v", tt.args, len(stmt.Args), stmt.Args)
+			}
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	b, err := NewBuilder(testDefinition())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var id pgtype.UUID
+	if err := id.Scan("00000000-0000-0000-0000-000000000002"); err != nil {
+		t.Fatal(err)
+	}
+
+	sortFields := []SortField{{Field: "price", Desc: true}, {Field: "launch_date"}}
+	cursor, err := EncodeCursor(sortFields, []interface{}{9.5, nil}, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := b.Build(Query{Sort: sortFields, Cursor: cursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `(((d."price" < $2::float8 OR d."price" IS NULL)) OR (d."price" = $3::float8 AND d."launch_date" IS NULL AND e.id > $4::uuid))`
+	if !strings.Contains(stmt.SQL, expected) {
+		t.Fatalf("expected keyset condition %s, got:\n%s", expected, stmt.SQL)
+	}
+
+	// A cursor is bound to the sort it was created for
+	if _, err := b.Build(Query{Sort: []SortField{{Field: "price"}}, Cursor: cursor}); !errors.Is(err, ErrInvalidQuery) {
+		t.Fatalf("expected cursor with a different sort to be rejected, got %v", err)
+	}
+
+	timestampCursor, err := EncodeCursor([]SortField{{Field: "created_at"}}, []interface{}{time.Now()}, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Build(Query{Cursor: timestampCursor}); err != nil {
+		t.Fatalf("expected default sort cursor to be accepted, got %v", err)
+	}
+}
+
+func TestParseVisibility(t *testing.T) {
+	cases := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{name: "published by default", query: "", want: "true"},
+		{name: "drafts show everything", query: "drafts=true", want: "any"},
+		{name: "drafts only unpublished", query: "drafts=true&published=false", want: "false"},
+		{name: "explicit published", query: "published=true", want: "true"},
+		{name: "unpublished without drafts", query: "published=false", wantErr: true},
+		{name: "invalid drafts", query: "drafts=maybe", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			published, err := ParseVisibility(values)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := "any"
+			if published != nil {
+				got = strconv.FormatBool(*published)
+			}
+			if got != tc.want {
+				t.Errorf("expected visibility %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
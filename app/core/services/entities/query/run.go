@@ -0,0 +1,122 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// Item is a single entity with its component data
+type Item struct {
+	Entity db.Entity              `json:"entity"`
+	Data   map[string]interface{} `json:"data"`
+}
+
+// Page is one page of a list query
+type Page struct {
+	Items      []Item `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// Run builds and executes the list query
+func (b *Builder) Run(ctx context.Context, conn db.DBTX, q Query) (*Page, error) {
+	stmt, err := b.Build(q)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, stmt.SQL, stmt.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list entities: %w", err)
+	}
+	defer rows.Close()
+
+	page := &Page{Items: make([]Item, 0)}
+
+	for rows.Next() {
+		var item Item
+		values := make([]interface{}, len(stmt.Components))
+
+		dest := EntityDest(&item.Entity)
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan entity row: %w", err)
+		}
+
+		item.Data = make(map[string]interface{}, len(stmt.Components))
+		for i, name := range stmt.Components {
+			item.Data[name] = values[i]
+		}
+
+		page.Items = append(page.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list entities: %w", err)
+	}
+
+	if len(page.Items) > stmt.Limit {
+		page.Items = page.Items[:stmt.Limit]
+		page.HasMore = true
+
+		last := page.Items[len(page.Items)-1]
+		page.NextCursor, err = EncodeCursor(stmt.Sort, b.sortValues(stmt.Sort, last), last.Entity.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// EntityDest returns the scan targets matching EntityColumns
+func EntityDest(entity *db.Entity) []interface{} {
+	return []interface{}{
+		&entity.ID,
+		&entity.EntityClass,
+		&entity.ParentID,
+		&entity.OKey,
+		&entity.OPath,
+		&entity.OType,
+		&entity.Published,
+		&entity.HasData,
+		&entity.CreatedAt,
+		&entity.UpdatedAt,
+		&entity.CreatedBy,
+		&entity.UpdatedBy,
+		&entity.Version,
+		&entity.SortIndex,
+	}
+}
+
+// sortValues extracts the values of the sort columns from a returned row
+func (b *Builder) sortValues(sortFields []SortField, item Item) []interface{} {
+	values := make([]interface{}, 0, len(sortFields))
+
+	for _, field := range sortFields {
+		col, _ := b.sortColumn(field.Field)
+		if col.component {
+			values = append(values, item.Data[field.Field])
+			continue
+		}
+
+		switch field.Field {
+		case "o_key":
+			values = append(values, item.Entity.OKey)
+		case "o_path":
+			values = append(values, item.Entity.OPath)
+		case "created_at":
+			values = append(values, item.Entity.CreatedAt.Time)
+		case "updated_at":
+			values = append(values, item.Entity.UpdatedAt.Time)
+		case "sort_index":
+			values = append(values, item.Entity.SortIndex)
+		}
+	}
+
+	return values
+}
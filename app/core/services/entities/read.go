@@ -2,9 +2,11 @@ package entities
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
 	l "github.com/oriiyx/fritz/app/core/api/common/log"
@@ -15,9 +17,12 @@ import (
 
 type ReadEntityRequest struct {
 	ID string `json:"id" validate:"required"`
+	// Draft opts in to unpublished entities and includes the pending draft in the response
+	Draft bool `json:"draft"`
 }
 
-// ReadEntity is an endpoint that handles reading entity
+// ReadEntity is an endpoint that handles reading entity, consumers only see published entities
+// unless they ask for drafts
 func (h *Handler) ReadEntity(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 	classID := chi.URLParam(r, DefinitionIDKey)
@@ -57,11 +62,20 @@ func (h *Handler) ReadEntity(w http.ResponseWriter, r *http.Request) {
 
 	entity, err := h.Queries.GetEntityByID(r.Context(), entityID)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "entity not found"}`))
+			return
+		}
 		h.Logger.Error().Err(err).Msg("Failed to read entity record")
 		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
 		return
 	}
 
+	if !entity.Published && !req.Draft {
+		errhandler.NotFound(w, []byte(`{"error": "entity not found"}`))
+		return
+	}
+
 	// Create the entity data using the adapter
 	result, err := adapter.Read(r.Context(), entity.ID)
 	if err != nil {
@@ -77,6 +91,21 @@ func (h *Handler) ReadEntity(w http.ResponseWriter, r *http.Request) {
 		"data":   result,
 	}
 
+	if req.Draft {
+		var draft *DraftResponse
+		pending, err := h.Queries.GetEntityDraft(r.Context(), entity.ID)
+		switch {
+		case err == nil:
+			draft = newDraftResponse(pending)
+		case !errors.Is(err, pgx.ErrNoRows):
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to read entity draft")
+			errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+			return
+		}
+		response["draft"] = draft
+	}
+
+	setETag(w, entity.Version)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(response)
 }
@@ -2,12 +2,16 @@ package entities
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
 	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/audit"
 	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
 	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
 	validatorUtil "github.com/oriiyx/fritz/app/core/utils/validator"
@@ -15,16 +19,18 @@ import (
 )
 
 type SaveEntityRequest struct {
-	ID        string                 `json:"id" validate:"required"`
-	ParentID  *string                `json:"parent_id,omitempty"`
-	Key       string                 `json:"key" validate:"required,max=255"`
-	Path      string                 `json:"path" validate:"required"`
-	Type      string                 `json:"type,omitempty"`
-	Published bool                   `json:"published"`
-	Data      map[string]interface{} `json:"data" validate:"required"`
+	ID       string                 `json:"id" validate:"required"`
+	ParentID *string                `json:"parent_id,omitempty"`
+	Key      string                 `json:"key" validate:"required,max=255"`
+	Path     string                 `json:"path" validate:"required"`
+	Type     string                 `json:"type,omitempty"`
+	Data     map[string]interface{} `json:"data" validate:"required"`
 }
 
-// SaveEntity is an endpoint that handles saving entity
+// SaveEntity is an endpoint that handles saving entity. Saves to a published entity store the
+// data as its draft, publish makes the draft live. The parent, key and path must match the entity,
+// they change through MoveEntity and RenameEntity. A save keeps the publication state, it only
+// changes through PublishEntity and UnpublishEntity.
 func (h *Handler) SaveEntity(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 	classID := chi.URLParam(r, DefinitionIDKey)
@@ -48,6 +54,11 @@ func (h *Handler) SaveEntity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expectedVersion, ok := h.requireIfMatch(w, r, reqID)
+	if !ok {
+		return
+	}
+
 	// Get the adapter for this entity class
 	adapter, err := adapters.Get(classID)
 	if err != nil {
@@ -72,42 +83,115 @@ func (h *Handler) SaveEntity(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// TODO: Get user ID from session/context
-	var userID pgtype.UUID
+	current, err := h.Queries.GetEntityByID(r.Context(), entityID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "entity not found"}`))
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to load entity")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	if !h.checkLock(w, r, reqID, current.ID) {
+		return
+	}
+
+	// The position in the tree only changes through move and rename, which keep the paths of the
+	// subtree consistent
+	if parentID != current.ParentID || req.Key != current.OKey || req.Path != current.OPath {
+		errhandler.UnprocessableEntity(w, []byte(`{"error": "parent_id, key and path can only be changed with move or rename"}`))
+		return
+	}
+
+	if current.Published && current.Version != expectedVersion {
+		errhandler.PreconditionFailed(w, errhandler.RespEntityVersionConflict)
+		return
+	}
+
+	userID := ctxUtil.GetUserID(r.Context())
+
+	payload := &hooks.EntityUpdatePayload{
+		Class:  current.EntityClass,
+		Entity: current,
+		Data:   req.Data,
+		UserID: userID,
+		Draft:  current.Published,
+	}
+	if !h.triggerBefore(w, r, reqID, hooks.HookBeforeEntityUpdate, payload) {
+		return
+	}
+	req.Data = payload.Data
+
+	// A published entity keeps serving its published content, the data goes to its draft
+	if current.Published {
+		entity, draft, err := h.saveDraft(r.Context(), adapter, current, expectedVersion, req.Data)
+		if err == nil {
+			payload.Entity = entity
+			h.triggerAfter(r, reqID, hooks.HookAfterEntityUpdate, payload)
+		}
+		h.writeDraft(w, reqID, entity, draft, err)
+		return
+	}
 
-	// Create entity record in entities table
+	// Update entity record in entities table at the version the data write claims
 	entityParams := db.UpdateEntityParams{
 		ID:        entityID,
 		ParentID:  parentID,
 		OKey:      req.Key,
 		OPath:     req.Path,
-		Published: req.Published,
+		Published: current.Published,
 		HasData:   true,
 		UpdatedBy: userID,
+		Version:   expectedVersion + 1,
 	}
 
-	entity, err := h.Queries.UpdateEntity(r.Context(), entityParams)
-	if err != nil {
-		h.Logger.Error().Err(err).Msg("Failed to update entity record")
-		errhandler.ServerError(w, errhandler.RespDBDataInsertFailure)
-		return
-	}
+	var (
+		entity db.Entity
+		result interface{}
+	)
+	err = h.inTx(r.Context(), func(tx pgx.Tx, queries *db.Queries) error {
+		before, err := audit.ReadEntityState(r.Context(), adapter.WithTx(tx), current)
+		if err != nil {
+			return err
+		}
 
-	// Create the entity data using the adapter
-	result, err := adapter.Update(r.Context(), entity.ID, req.Data)
+		// Write the data first, it claims the next version so a concurrent save fails here
+		// before any metadata is touched
+		result, err = adapter.WithTx(tx).Update(r.Context(), entityID, expectedVersion, req.Data)
+		if err != nil {
+			return err
+		}
+
+		entity, err = queries.UpdateEntity(r.Context(), entityParams)
+		if err != nil {
+			return err
+		}
+
+		return h.record(r.Context(), queries, adapter, audit.ActionUpdate, before, entity, result)
+	})
 	if err != nil {
-		h.Logger.Error().Err(err).Msg("Failed to update entity data")
-		// Rollback: delete the entity record
-		// TODO: Consider using transactions
-		errhandler.ServerError(w, errhandler.RespDBDataInsertFailure)
+		if h.writeValidationError(w, reqID, err) {
+			return
+		}
+		if h.writeVersionConflict(w, reqID, err) {
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to save entity")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
 		return
 	}
 
+	payload.Entity = entity
+	h.triggerAfter(r, reqID, hooks.HookAfterEntityUpdate, payload)
+
 	response := map[string]interface{}{
 		"entity": entity,
 		"data":   result,
 	}
 
+	setETag(w, entity.Version)
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(response)
 }
@@ -0,0 +1,64 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/services/entities/search"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+)
+
+// SearchEntities is an endpoint that runs a ranked full-text search over the searchable
+// components of one, several or all entity classes, as described in search.Parse
+func (h *Handler) SearchEntities(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	req, err := search.Parse(r.URL.Query())
+	if err != nil {
+		h.writeQueryError(w, err)
+		return
+	}
+
+	var defs []*definitions.EntityDefinition
+	if len(req.Classes) > 0 {
+		for _, classID := range req.Classes {
+			definition, err := h.entityBuilder.LoadDefinitionByID(classID)
+			if err != nil {
+				h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("class_id", classID).Msg("Unknown entity class")
+				errhandler.BadRequest(w, []byte(`{"error": "unknown entity class"}`))
+				return
+			}
+			defs = append(defs, definition)
+		}
+	} else {
+		all, err := h.entityBuilder.LoadDefinitionsFromEntityFiles()
+		if err != nil {
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to load definitions")
+			errhandler.ServerError(w, errhandler.RespProcessFailure)
+			return
+		}
+		for _, definition := range all {
+			if len(definition.SearchableComponents()) > 0 {
+				defs = append(defs, definition)
+			}
+		}
+	}
+
+	results, err := search.Run(r.Context(), h.DB, defs, req)
+	if err != nil {
+		if errors.Is(err, search.ErrInvalidSearch) {
+			h.writeQueryError(w, err)
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to search entities")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(results)
+}
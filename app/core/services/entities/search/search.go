@@ -0,0 +1,227 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oriiyx/fritz/app/core/services/entities/query"
+	"github.com/oriiyx/fritz/app/core/services/objects/definition_builder"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	db "github.com/oriiyx/fritz/database/generated"
+	"html"
+)
+
+const (
+	DefaultLimit   = 20
+	MaxLimit       = 100
+	MaxOffset      = 1000
+	MaxQueryLength = 256
+)
+
+// Matches in snippets are delimited by control characters that never reach clients, highlight
+// escapes the text and only then turns them into <mark> tags
+const (
+	startSel = "\x01"
+	stopSel  = "\x02"
+
+	headlineOptions = "StartSel=" + startSel + ", StopSel=" + stopSel + ", MaxWords=35, MinWords=15, MaxFragments=2"
+)
+
+var markReplacer = strings.NewReplacer(startSel, "<mark>", stopSel, "</mark>")
+
+// highlight renders a headline as HTML, the stored text is escaped so only the match markers are
+// markup
+func highlight(headline string) string {
+	return markReplacer.Replace(html.EscapeString(headline))
+}
+
+// ErrInvalidSearch wraps every error caused by the client's request rather than the database
+var ErrInvalidSearch = errors.New("invalid search")
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidSearch, fmt.Sprintf(format, args...))
+}
+
+// Request is a ranked full-text search over one or more entity classes
+type Request struct {
+	Text      string
+	Classes   []string
+	Published *bool
+	Limit     int
+	Offset    int
+}
+
+// Hit is a matching entity with its rank and a highlighted snippet of the searchable text, the
+// snippet is HTML with the matches in <mark> tags
+type Hit struct {
+	Entity  db.Entity `json:"entity"`
+	Rank    float32   `json:"rank"`
+	Snippet string    `json:"snippet"`
+}
+
+// Results is one page of search hits ordered by rank
+type Results struct {
+	Items   []Hit `json:"items"`
+	HasMore bool  `json:"has_more"`
+}
+
+// Statement is a rendered search query with its positional arguments
+type Statement struct {
+	SQL   string
+	Args  []interface{}
+	Limit int
+}
+
+// Parse reads a Request from URL parameters:
+//
+//	q        search text in websearch syntax: words, "quoted phrases", or, -excluded
+//	class    repeatable, restricts the search to the given classes, all searchable classes otherwise
+//	published, drafts, limit, offset
+func Parse(values url.Values) (Request, error) {
+	req := Request{
+		Text:    strings.TrimSpace(values.Get("q")),
+		Classes: values["class"],
+		Limit:   DefaultLimit,
+	}
+
+	if req.Text == "" {
+		return req, invalid("q is required")
+	}
+	if len(req.Text) > MaxQueryLength {
+		return req, invalid("q must be at most %d characters", MaxQueryLength)
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > MaxLimit {
+			return req, invalid("limit must be between 1 and %d", MaxLimit)
+		}
+		req.Limit = n
+	}
+
+	if offset := values.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 || n > MaxOffset {
+			return req, invalid("offset must be between 0 and %d", MaxOffset)
+		}
+		req.Offset = n
+	}
+
+	published, err := query.ParseVisibility(values)
+	if err != nil {
+		return req, invalid("%s", err)
+	}
+	req.Published = published
+
+	return req, nil
+}
+
+// Build renders one ranked branch per searchable definition and merges them by rank.
+// Each branch is limited on its own so snippets are only computed for rows that can make the page.
+func Build(defs []*definitions.EntityDefinition, req Request) (*Statement, error) {
+	if req.Limit <= 0 {
+		req.Limit = DefaultLimit
+	}
+
+	stmt := &Statement{Limit: req.Limit}
+	stmt.Args = append(stmt.Args, req.Text, req.Offset+req.Limit+1)
+
+	var published string
+	if req.Published != nil {
+		stmt.Args = append(stmt.Args, *req.Published)
+		published = fmt.Sprintf(" AND e.published = $%d::boolean", len(stmt.Args))
+	}
+
+	var branches []string
+	for _, def := range defs {
+		components := def.SearchableComponents()
+		if len(components) == 0 {
+			return nil, invalid("entity class %q has no searchable components", def.ID)
+		}
+		if err := def.ValidateIdentifiers(); err != nil {
+			return nil, fmt.Errorf("definition %q cannot be searched: %w", def.ID, err)
+		}
+
+		config := definitions.QuoteLiteral(def.TextSearchConfig()) + "::regconfig"
+
+		document := make([]string, 0, len(components))
+		for _, component := range components {
+			document = append(document, "d."+pgx.Identifier{component.Name}.Sanitize())
+		}
+
+		vector := "d." + pgx.Identifier{definitions.SearchVectorColumn}.Sanitize()
+
+		branches = append(branches, fmt.Sprintf(
+			"(SELECT %s, ts_rank(%s, q.query) AS rank, ts_headline(%s, concat_ws(' ', %s), q.query, %s) AS snippet\n"+
+				"FROM entities e\n"+
+				"JOIN %s d ON d.entity_id = e.id\n"+
+				"CROSS JOIN websearch_to_tsquery(%s, $1::text) AS q(query)\n"+
+				"WHERE %s @@ q.query AND e.deleted_at IS NULL%s\n"+
+				"ORDER BY rank DESC, e.id\n"+
+				"LIMIT $2)",
+			strings.Join(query.EntityColumns, ", "),
+			vector,
+			config,
+			strings.Join(document, ", "),
+			definitions.QuoteLiteral(headlineOptions),
+			pgx.Identifier{definition_builder.EntityTableName(def.ID)}.Sanitize(),
+			config,
+			vector,
+			published,
+		))
+	}
+
+	if len(branches) == 0 {
+		return nil, invalid("there are no searchable entity classes")
+	}
+
+	stmt.Args = append(stmt.Args, req.Limit+1, req.Offset)
+	stmt.SQL = fmt.Sprintf("SELECT * FROM (\n%s\n) AS hits\nORDER BY rank DESC, id\nLIMIT $%d OFFSET $%d",
+		strings.Join(branches, "\nUNION ALL\n"),
+		len(stmt.Args)-1,
+		len(stmt.Args),
+	)
+
+	return stmt, nil
+}
+
+// Run builds and executes the search
+func Run(ctx context.Context, conn db.DBTX, defs []*definitions.EntityDefinition, req Request) (*Results, error) {
+	stmt, err := Build(defs, req)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, stmt.SQL, stmt.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search entities: %w", err)
+	}
+	defer rows.Close()
+
+	results := &Results{Items: make([]Hit, 0)}
+
+	for rows.Next() {
+		var hit Hit
+		dest := append(query.EntityDest(&hit.Entity), &hit.Rank, &hit.Snippet)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan search hit: %w", err)
+		}
+		hit.Snippet = highlight(hit.Snippet)
+		results.Items = append(results.Items, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search entities: %w", err)
+	}
+
+	if len(results.Items) > stmt.Limit {
+		results.Items = results.Items[:stmt.Limit]
+		results.HasMore = true
+	}
+
+	return results, nil
+}
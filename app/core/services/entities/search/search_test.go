@@ -0,0 +1,84 @@
+package search
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+)
+
+func testDefinition(id string, searchable bool) *definitions.EntityDefinition {
+	return &definitions.EntityDefinition{
+		ID:           id,
+		Name:         "Product",
+		SearchConfig: "english",
+		Layout: definitions.Layout{
+			Type: "default",
+			Components: []definitions.DataComponent{
+				{Type: definitions.ComponentInput, Name: "title", DBType: "varchar(255)", Searchable: searchable, SearchWeight: definitions.SearchWeightA},
+				{Type: definitions.ComponentTextarea, Name: "description", DBType: definitions.DataTypeText, Searchable: searchable},
+			},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	values, _ := url.ParseQuery(`q="red shoes" -boots&published=true&limit=10&offset=20`)
+	req, err := Parse(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := Build([]*definitions.EntityDefinition{testDefinition("product", true), testDefinition("article", true)}, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fragment := range []string{
+		`JOIN "entity_product" d ON d.entity_id = e.id`,
+		`JOIN "entity_article" d ON d.entity_id = e.id`,
+		`websearch_to_tsquery('english'::regconfig, $1::text)`,
+		`concat_ws(' ', d."title", d."description")`,
+		`d."search_vector" @@ q.query AND e.deleted_at IS NULL AND e.published = $3::boolean`,
+		"UNION ALL",
+		"ORDER BY rank DESC, id\nLIMIT $4 OFFSET $5",
+	} {
+		if !strings.Contains(stmt.SQL, fragment) {
+			t.Errorf("expected SQL to contain %q, got:\n%s", fragment, stmt.SQL)
+		}
+	}
+
+	// Each branch fetches enough rows to fill the page after the offset
+	expectedArgs := []interface{}{`"red shoes" -boots`, 31, true, 11, 20}
+	if len(stmt.Args) != len(expectedArgs) {
+		t.Fatalf("expected args %v, got %v", expectedArgs, stmt.Args)
+	}
+	for i := range expectedArgs {
+		if stmt.Args[i] != expectedArgs[i] {
+			t.Errorf("expected arg %d to be %v, got %v", i+1, expectedArgs[i], stmt.Args[i])
+		}
+	}
+
+	if _, err := Build([]*definitions.EntityDefinition{testDefinition("product", false)}, req); !errors.Is(err, ErrInvalidSearch) {
+		t.Fatalf("expected a class without searchable components to be rejected, got %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, params := range []string{"", "q=%20", "q=a&limit=0", "q=a&limit=1000", "q=a&offset=-1", "q=" + strings.Repeat("a", MaxQueryLength+1)} {
+		values, _ := url.ParseQuery(params)
+		if _, err := Parse(values); !errors.Is(err, ErrInvalidSearch) {
+			t.Errorf("expected %q to be rejected, got %v", params, err)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	headline := `<script>alert("x")</script> a ` + startSel + `red` + stopSel + ` & ` + startSel + `shoe` + stopSel
+	want := `&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; a <mark>red</mark> &amp; <mark>shoe</mark>`
+	if got := highlight(headline); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,257 @@
+package trash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/base"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/entities/tree"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	validatorUtil "github.com/oriiyx/fritz/app/core/utils/validator"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+const (
+	TrashIDKey = "trash_id"
+
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+type Handler struct {
+	*base.HandlerController
+}
+
+func New(ctrl *base.HandlerController) *Handler {
+	return &Handler{
+		HandlerController: ctrl,
+	}
+}
+
+type ListResponse struct {
+	Items  []db.TrashItem `json:"items"`
+	Total  int64          `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+type RestoreRequest struct {
+	// ParentID restores under another parent instead of the original one
+	ParentID string `json:"parent_id,omitempty" validate:"omitempty,uuid"`
+}
+
+// List is an endpoint that lists trash items, newest first. class restricts the items to one
+// entity class and q searches their keys and paths.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+	values := r.URL.Query()
+
+	params := db.ListTrashItemsParams{RowLimit: DefaultListLimit}
+	if class := values.Get("class"); class != "" {
+		params.EntityClass = pgtype.Text{String: class, Valid: true}
+	}
+	if q := strings.TrimSpace(values.Get("q")); q != "" {
+		params.Search = pgtype.Text{String: "%" + escapeLike(q) + "%", Valid: true}
+	}
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > MaxListLimit {
+			errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": "limit must be between 1 and %d"}`, MaxListLimit)))
+			return
+		}
+		params.RowLimit = int32(n)
+	}
+	if offset := values.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			errhandler.BadRequest(w, []byte(`{"error": "offset must be a non-negative integer"}`))
+			return
+		}
+		params.RowOffset = int32(n)
+	}
+
+	items, err := h.Queries.ListTrashItems(r.Context(), params)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to list trash items")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	total, err := h.Queries.CountTrashItems(r.Context(), db.CountTrashItemsParams{
+		EntityClass: params.EntityClass,
+		Search:      params.Search,
+	})
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to count trash items")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ListResponse{
+		Items:  items,
+		Total:  total,
+		Limit:  int(params.RowLimit),
+		Offset: int(params.RowOffset),
+	})
+}
+
+// Get is an endpoint that returns a single trash item
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	item, ok := h.loadItem(w, r)
+	if !ok {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(item)
+}
+
+// Restore is an endpoint that brings a trash item back to its original parent, or to parent_id
+// when the body has one, and returns the restored entity
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	var req RestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to decode request")
+		errhandler.BadRequest(w, errhandler.RespInvalidRequestBody)
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to marshal validation errors")
+			errhandler.ServerError(w, errhandler.RespJSONEncodeFailure)
+			return
+		}
+		errhandler.ValidationErrors(w, respBody)
+		return
+	}
+
+	item, ok := h.loadItem(w, r)
+	if !ok {
+		return
+	}
+
+	var parentID pgtype.UUID
+	if req.ParentID != "" {
+		if err := parentID.Scan(req.ParentID); err != nil {
+			errhandler.BadRequest(w, []byte(`{"error": "invalid parent_id"}`))
+			return
+		}
+	}
+
+	// The entity comes back like it was created, the delete hooks fired when it went to the trash.
+	// The data is restored as it was, the payload carries none.
+	payload := &hooks.EntityCreatePayload{
+		Class: item.EntityClass,
+		Entity: db.Entity{
+			ID:          item.EntityID,
+			EntityClass: item.EntityClass,
+			ParentID:    item.ParentID,
+			OKey:        item.OKey,
+			OPath:       item.OPath,
+		},
+		UserID: ctxUtil.GetUserID(r.Context()),
+	}
+	if h.Hooks != nil {
+		if err := h.Hooks.Trigger(r.Context(), hooks.HookBeforeEntityCreate, payload); err != nil {
+			h.Logger.Info().Err(err).Str(l.KeyReqID, reqID).Str("hook", hooks.HookBeforeEntityCreate).Msg("Operation vetoed by hook")
+			errhandler.UnprocessableEntity(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+			return
+		}
+	}
+
+	var entity db.Entity
+	err := pgx.BeginFunc(r.Context(), h.DB, func(tx pgx.Tx) error {
+		var err error
+		entity, err = Restore(r.Context(), h.Queries.WithTx(tx), item, parentID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrParentUnavailable) || errors.Is(err, ErrPathConflict) || errors.Is(err, tree.ErrClassNotAllowed) {
+			writeError(w, err)
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("trash_id", item.ID.String()).Msg("Failed to restore trash item")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return
+	}
+
+	if h.Hooks != nil {
+		payload.Entity = entity
+		if err := h.Hooks.Trigger(r.Context(), hooks.HookAfterEntityCreate, payload); err != nil {
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("hook", hooks.HookAfterEntityCreate).Msg("Hook failed after the operation")
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(entity)
+}
+
+// Purge is an endpoint that permanently deletes a trash item before its retention runs out
+func (h *Handler) Purge(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	item, ok := h.loadItem(w, r)
+	if !ok {
+		return
+	}
+
+	err := pgx.BeginFunc(r.Context(), h.DB, func(tx pgx.Tx) error {
+		return Purge(r.Context(), h.Queries.WithTx(tx), item)
+	})
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("trash_id", item.ID.String()).Msg("Failed to purge trash item")
+		errhandler.ServerError(w, errhandler.RespDBDataRemoveFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadItem reads the trash item from the URL, it writes the error response and returns false
+// when there is none
+func (h *Handler) loadItem(w http.ResponseWriter, r *http.Request) (db.TrashItem, bool) {
+	var id pgtype.UUID
+	if err := id.Scan(chi.URLParam(r, TrashIDKey)); err != nil {
+		errhandler.BadRequest(w, []byte(`{"error": "invalid trash_id"}`))
+		return db.TrashItem{}, false
+	}
+
+	item, err := h.Queries.GetTrashItem(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "trash item not found"}`))
+			return item, false
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, ctxUtil.RequestID(r.Context())).Msg("Failed to load trash item")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return item, false
+	}
+
+	return item, true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	respBody, _ := json.Marshal(errhandler.Error{Error: err.Error()})
+	errhandler.BadRequest(w, respBody)
+}
+
+// escapeLike escapes the ILIKE wildcards of a search term
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,104 @@
+package trash
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/oriiyx/fritz/database/generated"
+	"github.com/rs/zerolog"
+)
+
+// PurgeBatchSize bounds how many expired items are loaded at once
+const PurgeBatchSize = 100
+
+// Purger permanently deletes trash items once they are older than the retention
+type Purger struct {
+	conn      Beginner
+	queries   *db.Queries
+	logger    *zerolog.Logger
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewPurger(conn Beginner, queries *db.Queries, logger *zerolog.Logger, retention, interval time.Duration) *Purger {
+	return &Purger{
+		conn:      conn,
+		queries:   queries,
+		logger:    logger,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Run purges expired items right away and then every interval until ctx is done. A zero
+// retention or interval disables the purge.
+func (p *Purger) Run(ctx context.Context) {
+	if p.retention <= 0 || p.interval <= 0 {
+		p.logger.Info().Msg("Trash purge is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.PurgeExpired(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			p.logger.Error().Err(err).Int("purged", purged).Msg("Failed to purge expired trash")
+		} else if purged > 0 {
+			p.logger.Info().Int("purged", purged).Dur("retention", p.retention).Msg("Purged expired trash")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeExpired deletes every item trashed longer than the retention before now, each in its own
+// transaction, and returns how many were purged. An item that cannot be purged is logged and
+// skipped, it is tried again on the next run. The error is set when the items cannot be listed.
+func (p *Purger) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	params := db.ListExpiredTrashItemsParams{
+		DeletedBefore: pgtype.Timestamptz{Time: now.Add(-p.retention), Valid: true},
+		RowLimit:      PurgeBatchSize,
+	}
+
+	purged := 0
+	for {
+		items, err := p.queries.ListExpiredTrashItems(ctx, params)
+		if err != nil {
+			return purged, fmt.Errorf("failed to list expired trash: %w", err)
+		}
+
+		for _, item := range items {
+			if err := ctx.Err(); err != nil {
+				return purged, err
+			}
+
+			err := pgx.BeginFunc(ctx, p.conn, func(tx pgx.Tx) error {
+				return Purge(ctx, p.queries.WithTx(tx), item)
+			})
+			if err != nil {
+				p.logger.Error().Err(err).
+					Str("trash_id", item.ID.String()).
+					Str("entity_id", item.EntityID.String()).
+					Msg("Failed to purge expired trash item")
+				continue
+			}
+			purged++
+		}
+
+		if len(items) < PurgeBatchSize {
+			return purged, nil
+		}
+
+		last := items[len(items)-1]
+		params.AfterDeletedAt, params.AfterID = last.DeletedAt, last.ID
+	}
+}
@@ -0,0 +1,168 @@
+package trash
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	"github.com/oriiyx/fritz/app/core/services/entities/tree"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// uniqueViolation is the postgres error code of a unique constraint violation
+const uniqueViolation = "23505"
+
+var (
+	ErrNotFound          = errors.New("entity not found")
+	ErrRootEntity        = errors.New("the root entity cannot be deleted")
+	ErrParentUnavailable = errors.New("the parent to restore to is deleted or does not exist")
+	ErrPathConflict      = errors.New("an entity with this path and key already exists")
+)
+
+// Beginner starts transactions, it is satisfied by *pgxpool.Pool and pgx.Tx
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Trash moves a live entity and its live descendants into a new trash item, recording who deleted
+// them. Class data stays in the class tables with its entity and is hidden and restored with it.
+// The queries must be bound to a transaction.
+func Trash(ctx context.Context, queries *db.Queries, entityID, deletedBy pgtype.UUID) (db.TrashItem, error) {
+	if tree.IsRoot(entityID) {
+		return db.TrashItem{}, ErrRootEntity
+	}
+
+	entity, err := queries.GetEntityByID(ctx, entityID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.TrashItem{}, ErrNotFound
+	}
+	if err != nil {
+		return db.TrashItem{}, err
+	}
+
+	item, err := queries.CreateTrashItem(ctx, db.CreateTrashItemParams{
+		EntityID:    entity.ID,
+		EntityClass: entity.EntityClass,
+		OKey:        entity.OKey,
+		OPath:       entity.OPath,
+		ParentID:    entity.ParentID,
+		DeletedBy:   deletedBy,
+	})
+	if err != nil {
+		return item, err
+	}
+
+	count, err := queries.TrashEntityTree(ctx, db.TrashEntityTreeParams{
+		EntityID:  entity.ID,
+		DeletedBy: deletedBy,
+		TrashID:   item.ID,
+	})
+	if err != nil {
+		return item, err
+	}
+
+	item, err = queries.SetTrashItemCount(ctx, db.SetTrashItemCountParams{ID: item.ID, EntityCount: int32(count)})
+	if err != nil {
+		return item, err
+	}
+
+	after, err := audit.ObjectState(map[string]interface{}{"trash_id": item.ID, "entity_count": item.EntityCount})
+	if err != nil {
+		return item, err
+	}
+	return item, audit.RecordEntity(ctx, queries, audit.ActionDelete, entity.ID, nil, after)
+}
+
+// Restore brings the entities of a trash item back and removes the item. Without a parentID they
+// return to their original parent, otherwise the subtree is moved under parentID. Either way the
+// parent has to be live and paths follow its current path. The queries must be bound to a
+// transaction.
+func Restore(ctx context.Context, queries *db.Queries, item db.TrashItem, parentID pgtype.UUID) (db.Entity, error) {
+	if !parentID.Valid {
+		parentID = item.ParentID
+	}
+
+	if parentID.Valid {
+		if parentID == item.EntityID {
+			return db.Entity{}, errors.New("an entity cannot be its own parent")
+		}
+
+		parent, err := queries.GetEntityByID(ctx, parentID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Entity{}, ErrParentUnavailable
+		}
+		if err != nil {
+			return db.Entity{}, err
+		}
+
+		if parentID != item.ParentID {
+			if err := tree.CheckChild(ctx, queries, parentID, item.EntityClass); err != nil {
+				return db.Entity{}, err
+			}
+		}
+
+		path := tree.ChildPath(parent.OPath, item.OKey)
+		if parentID != item.ParentID || path != item.OPath {
+			_, err = queries.RelocateTrashedTree(ctx, db.RelocateTrashedTreeParams{
+				NewPath:  path,
+				OldPath:  item.OPath,
+				RootID:   item.EntityID,
+				ParentID: parentID,
+				TrashID:  item.ID,
+			})
+			if err != nil {
+				return db.Entity{}, err
+			}
+		}
+	}
+
+	if _, err := queries.RestoreTrashItem(ctx, item.ID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return db.Entity{}, ErrPathConflict
+		}
+		return db.Entity{}, err
+	}
+
+	if err := queries.DeleteTrashItem(ctx, item.ID); err != nil {
+		return db.Entity{}, err
+	}
+
+	entity, err := queries.GetEntityByID(ctx, item.EntityID)
+	if err != nil {
+		return entity, err
+	}
+
+	before, err := audit.ObjectState(map[string]interface{}{"trash_id": item.ID, "parent_id": item.ParentID, "o_path": item.OPath})
+	if err != nil {
+		return entity, err
+	}
+	after, err := audit.ObjectState(map[string]interface{}{"parent_id": entity.ParentID, "o_path": entity.OPath})
+	if err != nil {
+		return entity, err
+	}
+	return entity, audit.RecordEntity(ctx, queries, audit.ActionRestore, entity.ID, before, after)
+}
+
+// Purge permanently deletes the entities of a trash item. Descendants, class data and the item
+// itself cascade with the root entity.
+func Purge(ctx context.Context, queries *db.Queries, item db.TrashItem) error {
+	if err := queries.DeleteEntity(ctx, item.EntityID); err != nil {
+		return err
+	}
+
+	before, err := audit.ObjectState(map[string]interface{}{
+		"trash_id":     item.ID,
+		"entity_class": item.EntityClass,
+		"o_path":       item.OPath,
+		"o_key":        item.OKey,
+		"entity_count": item.EntityCount,
+	})
+	if err != nil {
+		return err
+	}
+	return audit.RecordEntity(ctx, queries, audit.ActionPurge, item.EntityID, before, nil)
+}
@@ -2,6 +2,8 @@ package tree
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/jackc/pgx/v5/pgtype"
@@ -9,24 +11,34 @@ import (
 	l "github.com/oriiyx/fritz/app/core/api/common/log"
 	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
 	validatorUtil "github.com/oriiyx/fritz/app/core/utils/validator"
-	db "github.com/oriiyx/fritz/database/generated"
 )
 
+// ChildrenParams selects a page of children. Pages continue with the next_cursor of the previous
+// page, offset is kept for clients that page by position and cannot be combined with a cursor.
 type ChildrenParams struct {
 	Limit        uint   `json:"limit" validate:"required,gt=0,lte=1000"`
 	Offset       int    `json:"offset" validate:"gte=0"`
+	Cursor       string `json:"cursor,omitempty"`
 	ParentID     string `json:"parent_id" validate:"required,uuid"`
 	DefinitionID string `json:"definition_id,omitempty"`
+	Type         string `json:"o_type,omitempty" validate:"omitempty,oneof=folder object variant"`
+	Published    *bool  `json:"published,omitempty"`
+	Search       string `json:"q,omitempty" validate:"max=255"`
+	Sort         string `json:"sort,omitempty" validate:"omitempty,oneof=default key created_at updated_at index"`
+	Desc         bool   `json:"desc"`
 }
 
 type GetChildrenResponse struct {
-	Items   []db.GetEntityChildrenRow `json:"items"`
-	Total   int64                     `json:"total"`
-	Limit   int                       `json:"limit"`
-	Offset  int                       `json:"offset"`
-	HasMore bool                      `json:"has_more"`
+	Items      []Child `json:"items"`
+	Total      int64   `json:"total"`
+	Limit      int     `json:"limit"`
+	Offset     int     `json:"offset"`
+	HasMore    bool    `json:"has_more"`
+	NextCursor string  `json:"next_cursor,omitempty"`
 }
 
+// Children is an endpoint that lists the live children of a node, filtered by class, type,
+// published state and a text filter on the key, in one of the child sorts
 func (h *Handler) Children(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
@@ -48,6 +60,11 @@ func (h *Handler) Children(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Cursor != "" && req.Offset > 0 {
+		errhandler.BadRequest(w, []byte(`{"error": "cursor and offset cannot be combined"}`))
+		return
+	}
+
 	var parentID pgtype.UUID
 	if err := parentID.Scan(req.ParentID); err != nil {
 		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Invalid entity id")
@@ -55,23 +72,82 @@ func (h *Handler) Children(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	children, err := h.Queries.GetEntityChildren(r.Context(), db.GetEntityChildrenParams{
-		ParentID: parentID,
-		Limit:    int32(req.Limit),
-		Offset:   int32(req.Offset),
-	})
+	q := ChildrenQuery{
+		ParentID:  parentID,
+		Class:     req.DefinitionID,
+		Type:      req.Type,
+		Published: req.Published,
+		Search:    req.Search,
+		Sort:      req.Sort,
+		Desc:      req.Desc,
+		Limit:     int(req.Limit),
+		Offset:    req.Offset,
+		Cursor:    req.Cursor,
+	}
+
+	sql, args, err := BuildChildren(q)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCursor) {
+			errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+			return
+		}
+		h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to build tree children query")
+		errhandler.ServerError(w, errhandler.RespProcessFailure)
+		return
+	}
+
+	rows, err := h.DB.Query(r.Context(), sql, args...)
 	if err != nil {
 		h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Str("parent_id", req.ParentID).Uint("limit", req.Limit).Int("offset", req.Offset).Msg("Failed to fetch tree children")
 		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
 		return
 	}
 
-	total, err := h.Queries.GetEntityChildrenCount(r.Context(), parentID)
-	_ = json.NewEncoder(w).Encode(GetChildrenResponse{
-		Items:   children,
-		Total:   total,
-		Limit:   int(req.Limit),
-		Offset:  req.Offset,
-		HasMore: (int64(req.Offset) + int64(req.Limit)) < total,
-	})
+	children := []Child{}
+	for rows.Next() {
+		var child Child
+		if err := rows.Scan(child.Dest()...); err != nil {
+			rows.Close()
+			h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to scan tree child")
+			errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+			return
+		}
+		children = append(children, child)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to fetch tree children")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	countSQL, countArgs := BuildChildrenCount(q)
+	var total int64
+	if err := h.DB.QueryRow(r.Context(), countSQL, countArgs...).Scan(&total); err != nil {
+		h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to count tree children")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	response := GetChildrenResponse{
+		Items:  children,
+		Total:  total,
+		Limit:  q.Limit,
+		Offset: q.Offset,
+	}
+
+	// The query reads one row past the page to tell whether another page follows
+	if len(children) > q.Limit {
+		response.Items = children[:q.Limit]
+		response.HasMore = true
+		response.NextCursor, err = NextChildrenCursor(q, response.Items[q.Limit-1])
+		if err != nil {
+			h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to encode tree children cursor")
+			errhandler.ServerError(w, errhandler.RespJSONEncodeFailure)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
 }
@@ -0,0 +1,319 @@
+package tree
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Sorts of the children of a node, every sort breaks ties by id so pages are stable
+const (
+	// SortDefault lists folders, then objects, then variants, each by key
+	SortDefault   = "default"
+	SortKey       = "key"
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+	// SortIndex follows the manual sort index of the siblings, then the key
+	SortIndex = "index"
+)
+
+var ErrInvalidCursor = errors.New("malformed cursor or cursor of another sort")
+
+// typeRank orders folders before objects before variants
+const typeRank = "CASE e.o_type WHEN 'folder' THEN 1 WHEN 'object' THEN 2 WHEN 'variant' THEN 3 ELSE 4 END"
+
+// sortKey is a non-null expression of a sort with the cast of its cursor argument
+type sortKey struct {
+	expr string
+	cast string
+}
+
+var childSorts = map[string][]sortKey{
+	SortDefault:   {{expr: typeRank, cast: "integer"}, {expr: "e.o_key", cast: "text"}},
+	SortKey:       {{expr: "e.o_key", cast: "text"}},
+	SortCreatedAt: {{expr: "e.created_at", cast: "timestamptz"}},
+	SortUpdatedAt: {{expr: "e.updated_at", cast: "timestamptz"}},
+	SortIndex:     {{expr: "e.sort_index", cast: "integer"}, {expr: "e.o_key", cast: "text"}},
+}
+
+// childColumns is the select list of a Child, in scan order. The children count is the one the
+// entity triggers keep, so a page does not count the children of each of its rows. The lock
+// columns are null unless a user holds a live edit lock, see childJoins.
+const childColumns = `e.id, e.entity_class, e.parent_id, e.o_key, e.o_path, e.o_type, e.published, e.sort_index,
+       e.created_at, e.updated_at,
+       e.children_count > 0 AS has_children, e.children_count,
+       l.user_id AS locked_by, u.email AS locked_by_email, l.expires_at AS locked_until`
+
+// childJoins adds the live edit lock of a child and the user holding it
+const childJoins = `LEFT JOIN entity_locks l ON l.entity_id = e.id AND l.expires_at > NOW()
+         LEFT JOIN users u ON u.id = l.user_id`
+
+// ChildrenQuery selects a page of the live children of a node. Cursor continues after the last row
+// of the previous page of the same sort, Offset is only for clients that page by position.
+type ChildrenQuery struct {
+	ParentID  pgtype.UUID
+	Class     string
+	Type      string
+	Published *bool
+	Search    string
+	Sort      string
+	Desc      bool
+	Limit     int
+	Offset    int
+	Cursor    string
+}
+
+// Child is a node of the tree view
+type Child struct {
+	ID            pgtype.UUID        `json:"id"`
+	EntityClass   string             `json:"entity_class"`
+	ParentID      pgtype.UUID        `json:"parent_id"`
+	OKey          string             `json:"o_key"`
+	OPath         string             `json:"o_path"`
+	OType         string             `json:"o_type"`
+	Published     bool               `json:"published"`
+	SortIndex     int32              `json:"sort_index"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	HasChildren   bool               `json:"has_children"`
+	ChildrenCount int32              `json:"children_count"`
+	LockedBy      pgtype.UUID        `json:"locked_by"`
+	LockedByEmail pgtype.Text        `json:"locked_by_email"`
+	LockedUntil   pgtype.Timestamptz `json:"locked_until"`
+}
+
+// Dest returns the scan targets matching the select list of BuildChildren
+func (c *Child) Dest() []interface{} {
+	return []interface{}{
+		&c.ID, &c.EntityClass, &c.ParentID, &c.OKey, &c.OPath, &c.OType, &c.Published, &c.SortIndex,
+		&c.CreatedAt, &c.UpdatedAt, &c.HasChildren, &c.ChildrenCount,
+		&c.LockedBy, &c.LockedByEmail, &c.LockedUntil,
+	}
+}
+
+// childCursor is the keyset position after the last child of a page
+type childCursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+	ID     string            `json:"id"`
+}
+
+// sortSignature identifies a sort and direction so a cursor cannot be reused with another one
+func sortSignature(sort string, desc bool) string {
+	if desc {
+		return "-" + sort
+	}
+	return sort
+}
+
+func effectiveSort(sort string) string {
+	if sort == "" {
+		return SortDefault
+	}
+	return sort
+}
+
+// BuildChildren renders the page query of q, it selects one row more than the limit so the caller
+// can tell whether another page follows
+func BuildChildren(q ChildrenQuery) (string, []interface{}, error) {
+	sort := effectiveSort(q.Sort)
+	keys, ok := childSorts[sort]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown sort %q", q.Sort)
+	}
+
+	var args []interface{}
+	conditions := childConditions(q, &args)
+
+	if q.Cursor != "" {
+		condition, err := cursorCondition(q.Cursor, sortSignature(sort, q.Desc), keys, q.Desc, &args)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	direction := ""
+	if q.Desc {
+		direction = " DESC"
+	}
+	order := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		order = append(order, key.expr+direction)
+	}
+	order = append(order, "e.id"+direction)
+
+	args = append(args, q.Limit+1)
+	sql := fmt.Sprintf("SELECT %s\nFROM entities e\n         %s\nWHERE %s\nORDER BY %s\nLIMIT $%d",
+		childColumns, childJoins, strings.Join(conditions, "\n  AND "), strings.Join(order, ", "), len(args))
+
+	if q.Offset > 0 {
+		args = append(args, q.Offset)
+		sql += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	return sql, args, nil
+}
+
+// BuildChildrenCount renders the count of all children matching the filters of q
+func BuildChildrenCount(q ChildrenQuery) (string, []interface{}) {
+	var args []interface{}
+	conditions := childConditions(q, &args)
+
+	return "SELECT COUNT(*)\nFROM entities e\nWHERE " + strings.Join(conditions, "\n  AND "), args
+}
+
+// NextChildrenCursor builds the cursor continuing after last in the sort of q
+func NextChildrenCursor(q ChildrenQuery, last Child) (string, error) {
+	sort := effectiveSort(q.Sort)
+
+	var values []interface{}
+	switch sort {
+	case SortDefault:
+		values = []interface{}{rank(last.OType), last.OKey}
+	case SortKey:
+		values = []interface{}{last.OKey}
+	case SortCreatedAt:
+		values = []interface{}{last.CreatedAt.Time}
+	case SortUpdatedAt:
+		values = []interface{}{last.UpdatedAt.Time}
+	case SortIndex:
+		values = []interface{}{last.SortIndex, last.OKey}
+	default:
+		return "", fmt.Errorf("unknown sort %q", q.Sort)
+	}
+
+	c := childCursor{Sort: sortSignature(sort, q.Desc), ID: last.ID.String()}
+	for _, value := range values {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		c.Values = append(c.Values, raw)
+	}
+
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+func childConditions(q ChildrenQuery, args *[]interface{}) []string {
+	arg := func(value interface{}, cast string) string {
+		*args = append(*args, value)
+		return fmt.Sprintf("$%d::%s", len(*args), cast)
+	}
+
+	conditions := []string{"e.parent_id = " + arg(q.ParentID, "uuid"), "e.deleted_at IS NULL"}
+	if q.Class != "" {
+		conditions = append(conditions, "e.entity_class = "+arg(q.Class, "text"))
+	}
+	if q.Type != "" {
+		conditions = append(conditions, "e.o_type = "+arg(q.Type, "text"))
+	}
+	if q.Published != nil {
+		conditions = append(conditions, "e.published = "+arg(*q.Published, "boolean"))
+	}
+	if q.Search != "" {
+		conditions = append(conditions, "e.o_key ILIKE "+arg("%"+escapeLike(q.Search)+"%", "text"))
+	}
+
+	return conditions
+}
+
+// cursorCondition renders "row comes after the cursor" as a row comparison, the sort keys are never
+// null and all run in the same direction
+func cursorCondition(encoded, signature string, keys []sortKey, desc bool, args *[]interface{}) (string, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+
+	var c childCursor
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return "", ErrInvalidCursor
+	}
+	if c.Sort != signature || len(c.Values) != len(keys) {
+		return "", ErrInvalidCursor
+	}
+
+	var id pgtype.UUID
+	if err := id.Scan(c.ID); err != nil {
+		return "", ErrInvalidCursor
+	}
+
+	exprs := make([]string, 0, len(keys)+1)
+	params := make([]string, 0, len(keys)+1)
+	for i, key := range keys {
+		value, err := cursorValue(key.cast, c.Values[i])
+		if err != nil {
+			return "", err
+		}
+		*args = append(*args, value)
+		exprs = append(exprs, key.expr)
+		params = append(params, fmt.Sprintf("$%d::%s", len(*args), key.cast))
+	}
+	*args = append(*args, id)
+	exprs = append(exprs, "e.id")
+	params = append(params, fmt.Sprintf("$%d::uuid", len(*args)))
+
+	operator := ">"
+	if desc {
+		operator = "<"
+	}
+
+	return fmt.Sprintf("(%s) %s (%s)", strings.Join(exprs, ", "), operator, strings.Join(params, ", ")), nil
+}
+
+func cursorValue(cast string, raw json.RawMessage) (interface{}, error) {
+	var (
+		value interface{}
+		err   error
+	)
+	switch cast {
+	case "integer":
+		var n int64
+		err = json.Unmarshal(raw, &n)
+		value = n
+	case "text":
+		var s string
+		err = json.Unmarshal(raw, &s)
+		value = s
+	case "timestamptz":
+		var t time.Time
+		err = json.Unmarshal(raw, &t)
+		value = t
+	default:
+		err = ErrInvalidCursor
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return value, nil
+}
+
+// rank mirrors typeRank for the cursor of the default sort
+func rank(oType string) int {
+	switch oType {
+	case "folder":
+		return 1
+	case "object":
+		return 2
+	case "variant":
+		return 3
+	default:
+		return 4
+	}
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
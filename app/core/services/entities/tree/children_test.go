@@ -0,0 +1,116 @@
+package tree
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestBuildChildren(t *testing.T) {
+	var parentID pgtype.UUID
+	if err := parentID.Scan(RootEntityID); err != nil {
+		t.Fatal(err)
+	}
+	published := true
+
+	tests := []struct {
+		name     string
+		query    ChildrenQuery
+		contains []string
+		args     int
+	}{
+		{
+			name:     "defaults",
+			query:    ChildrenQuery{ParentID: parentID, Limit: 50},
+			contains: []string{"e.parent_id = $1::uuid", "ORDER BY " + typeRank + ", e.o_key, e.id\nLIMIT $2"},
+			args:     2,
+		},
+		{
+			name: "filters",
+			query: ChildrenQuery{
+				ParentID: parentID, Class: "product", Type: "object", Published: &published, Search: "50%",
+				Limit: 10, Offset: 20,
+			},
+			contains: []string{
+				"e.entity_class = $2::text", "e.o_type = $3::text", "e.published = $4::boolean",
+				"e.o_key ILIKE $5::text", "LIMIT $6 OFFSET $7",
+			},
+			args: 7,
+		},
+		{
+			name:     "descending by update",
+			query:    ChildrenQuery{ParentID: parentID, Sort: SortUpdatedAt, Desc: true, Limit: 10},
+			contains: []string{"ORDER BY e.updated_at DESC, e.id DESC"},
+			args:     2,
+		},
+		{
+			name:     "manual index",
+			query:    ChildrenQuery{ParentID: parentID, Sort: SortIndex, Limit: 10},
+			contains: []string{"ORDER BY e.sort_index, e.o_key, e.id"},
+			args:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := BuildChildren(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, fragment := range tt.contains {
+				if !strings.Contains(sql, fragment) {
+					t.Errorf("expected %q in:\n%s", fragment, sql)
+				}
+			}
+			if len(args) != tt.args {
+				t.Errorf("expected %d args, got %d", tt.args, len(args))
+			}
+		})
+	}
+
+	// The search is matched literally
+	_, args, _ := BuildChildren(tests[1].query)
+	if args[4] != `%50\%%` {
+		t.Errorf("expected an escaped search pattern, got %v", args[4])
+	}
+}
+
+func TestChildrenCursor(t *testing.T) {
+	var parentID, lastID pgtype.UUID
+	_ = parentID.Scan(RootEntityID)
+	_ = lastID.Scan("00000000-0000-0000-0000-000000000042")
+
+	q := ChildrenQuery{ParentID: parentID, Sort: SortIndex, Limit: 10}
+	last := Child{ID: lastID, OKey: "tv", OType: "object", SortIndex: 3, UpdatedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}}
+
+	cursor, err := NextChildrenCursor(q, last)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q.Cursor = cursor
+	sql, args, err := BuildChildren(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sql, "(e.sort_index, e.o_key, e.id) > ($2::integer, $3::text, $4::uuid)") {
+		t.Errorf("expected a keyset condition in:\n%s", sql)
+	}
+	if args[1] != int64(3) || args[2] != "tv" || args[3] != lastID {
+		t.Errorf("unexpected cursor args %v", args[1:4])
+	}
+
+	// A cursor only continues the sort and direction it was built for
+	q.Desc = true
+	if _, _, err := BuildChildren(q); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor for another direction, got %v", err)
+	}
+
+	q.Desc, q.Cursor = false, "not-a-cursor"
+	if _, _, err := BuildChildren(q); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor for garbage, got %v", err)
+	}
+}
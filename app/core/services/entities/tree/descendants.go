@@ -0,0 +1,201 @@
+package tree
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+const (
+	EntityIDKey     = "entity_id"
+	DescendantIDKey = "descendant_id"
+
+	DefaultDescendantsLimit = 100
+	MaxDescendantsLimit     = 1000
+)
+
+type DescendantsResponse struct {
+	Items      []db.Entity `json:"items"`
+	Limit      int         `json:"limit"`
+	HasMore    bool        `json:"has_more"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+type DescendantCountsResponse struct {
+	Total   int64                                 `json:"total"`
+	Classes []db.CountEntityDescendantsByClassRow `json:"classes"`
+}
+
+type AncestorResponse struct {
+	Ancestor bool `json:"ancestor"`
+}
+
+// Descendants is an endpoint that lists the live descendants of an entity in tree order, parents
+// before their children. depth limits how many levels below the entity are included, class keeps
+// the descendants of one class and pages continue with the next_cursor of the previous page.
+func (h *Handler) Descendants(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	entity, ok := h.loadEntity(w, r, reqID, EntityIDKey)
+	if !ok {
+		return
+	}
+
+	params := r.URL.Query()
+	maxDepth, err := parseDepth(entity, params.Get("depth"))
+	if err != nil {
+		errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+		return
+	}
+
+	limit := DefaultDescendantsLimit
+	if raw := params.Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > MaxDescendantsLimit {
+			errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": "limit must be between 1 and %d"}`, MaxDescendantsLimit)))
+			return
+		}
+	}
+
+	var after pgtype.Text
+	if cursor := params.Get("cursor"); cursor != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || !IsAncestor(entity.TreePath, string(decoded)) {
+			errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": %q}`, ErrInvalidCursor.Error())))
+			return
+		}
+		after = pgtype.Text{String: string(decoded), Valid: true}
+	}
+
+	var class pgtype.Text
+	if raw := params.Get("class"); raw != "" {
+		class = pgtype.Text{String: raw, Valid: true}
+	}
+
+	// One row past the page tells whether another page follows
+	items, err := h.Queries.ListEntityDescendants(r.Context(), db.ListEntityDescendantsParams{
+		TreePath:    entity.TreePath,
+		MaxDepth:    maxDepth,
+		EntityClass: class,
+		After:       after,
+		RowLimit:    int32(limit + 1),
+	})
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("entity_id", entity.ID.String()).Msg("Failed to fetch entity descendants")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	response := DescendantsResponse{Items: items, Limit: limit}
+	if len(items) > limit {
+		response.Items = items[:limit]
+		response.HasMore = true
+		response.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(items[limit-1].TreePath))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// DescendantCounts is an endpoint that counts the live descendants of an entity per class,
+// optionally only down to depth levels below it
+func (h *Handler) DescendantCounts(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	entity, ok := h.loadEntity(w, r, reqID, EntityIDKey)
+	if !ok {
+		return
+	}
+
+	maxDepth, err := parseDepth(entity, r.URL.Query().Get("depth"))
+	if err != nil {
+		errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+		return
+	}
+
+	classes, err := h.Queries.CountEntityDescendantsByClass(r.Context(), db.CountEntityDescendantsByClassParams{
+		TreePath: entity.TreePath,
+		MaxDepth: maxDepth,
+	})
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("entity_id", entity.ID.String()).Msg("Failed to count entity descendants")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	response := DescendantCountsResponse{Classes: classes}
+	for _, class := range classes {
+		response.Total += class.Count
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// IsAncestorOf is an endpoint that tells whether an entity is an ancestor of another, an entity is
+// not its own ancestor
+func (h *Handler) IsAncestorOf(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	ancestor, ok := h.loadEntity(w, r, reqID, EntityIDKey)
+	if !ok {
+		return
+	}
+
+	descendant, ok := h.loadEntity(w, r, reqID, DescendantIDKey)
+	if !ok {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(AncestorResponse{Ancestor: IsAncestor(ancestor.TreePath, descendant.TreePath)})
+}
+
+// loadEntity reads the live entity named by the URL parameter key, it writes the error response
+// and returns false when the id is invalid or the entity does not exist
+func (h *Handler) loadEntity(w http.ResponseWriter, r *http.Request, reqID, key string) (db.Entity, bool) {
+	var id pgtype.UUID
+	if err := id.Scan(chi.URLParam(r, key)); err != nil {
+		errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": "invalid %s"}`, key)))
+		return db.Entity{}, false
+	}
+
+	entity, err := h.Queries.GetEntityByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "entity not found"}`))
+			return db.Entity{}, false
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to read entity")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return db.Entity{}, false
+	}
+
+	return entity, true
+}
+
+// parseDepth turns the number of levels below entity into the absolute depth limit of the
+// descendant queries, an empty value means no limit
+func parseDepth(entity db.Entity, raw string) (pgtype.Int4, error) {
+	if raw == "" {
+		return pgtype.Int4{}, nil
+	}
+
+	levels, err := strconv.Atoi(raw)
+	if err != nil || levels <= 0 {
+		return pgtype.Int4{}, errors.New("depth must be a positive number of levels")
+	}
+
+	return pgtype.Int4{Int32: entity.Depth + int32(levels), Valid: true}, nil
+}
@@ -0,0 +1,48 @@
+package tree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+const (
+	// FolderClass is the class of plain folders, they have no definition and no data row
+	FolderClass = "folder"
+	FolderType  = "folder"
+)
+
+var ErrClassNotAllowed = errors.New("the parent folder does not allow children of this class")
+
+// Allows reports whether folder accepts children of class, a folder without allowed classes
+// accepts every class
+func Allows(folder db.EntityFolder, class string) bool {
+	return len(folder.AllowedClasses) == 0 || slices.Contains(folder.AllowedClasses, class)
+}
+
+// CheckChild returns ErrClassNotAllowed when the parent is a folder that does not accept children
+// of class. Parents that are not folders accept every class.
+func CheckChild(ctx context.Context, queries *db.Queries, parentID pgtype.UUID, class string) error {
+	if !parentID.Valid {
+		return nil
+	}
+
+	folder, err := queries.GetEntityFolder(ctx, parentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !Allows(folder, class) {
+		return fmt.Errorf("%w: %s", ErrClassNotAllowed, class)
+	}
+
+	return nil
+}
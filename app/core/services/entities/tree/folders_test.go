@@ -0,0 +1,29 @@
+package tree
+
+import (
+	"testing"
+
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+func TestAllows(t *testing.T) {
+	open := db.EntityFolder{AllowedClasses: []string{}}
+	products := db.EntityFolder{AllowedClasses: []string{"product", FolderClass}}
+
+	tests := []struct {
+		folder db.EntityFolder
+		class  string
+		want   bool
+	}{
+		{folder: open, class: "product", want: true},
+		{folder: products, class: "product", want: true},
+		{folder: products, class: FolderClass, want: true},
+		{folder: products, class: "category", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := Allows(tt.folder, tt.class); got != tt.want {
+			t.Errorf("Allows(%v, %q) = %v, want %v", tt.folder.AllowedClasses, tt.class, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package tree
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+var ErrPathMismatch = errors.New("path must be the path of the parent followed by the key")
+
+// Locate resolves the parent and path of a new entity with key. With a parent the path is derived
+// from it and a given path must match, without one the parent is the entity at the given path
+// minus its last segment. With neither the entity goes under the root.
+func Locate(ctx context.Context, queries *db.Queries, parentID pgtype.UUID, key, path string) (pgtype.UUID, string, error) {
+	if path != "" {
+		normalized, pathKey, err := SplitPath(path)
+		if err != nil {
+			return parentID, "", err
+		}
+		if pathKey != key {
+			return parentID, "", ErrPathMismatch
+		}
+		path = normalized
+	}
+
+	var (
+		parent db.Entity
+		err    error
+	)
+	switch {
+	case parentID.Valid:
+		parent, err = queries.GetEntityByID(ctx, parentID)
+	case path != "":
+		parent, err = entityAt(ctx, queries, path[:strings.LastIndex(path, "/")])
+	default:
+		parent, err = entityAt(ctx, queries, RootPath)
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return parentID, "", ErrParentNotFound
+	}
+	if err != nil {
+		return parentID, "", err
+	}
+
+	derived := ChildPath(parent.OPath, key)
+	if path != "" && path != derived {
+		return parentID, "", ErrPathMismatch
+	}
+
+	return parent.ID, derived, nil
+}
+
+// entityAt reads the live entity at path, an empty path is the root
+func entityAt(ctx context.Context, queries *db.Queries, path string) (db.Entity, error) {
+	if path == "" || path == RootPath {
+		var rootID pgtype.UUID
+		if err := rootID.Scan(RootEntityID); err != nil {
+			return db.Entity{}, err
+		}
+		return queries.GetEntityByID(ctx, rootID)
+	}
+
+	path, key, err := SplitPath(path)
+	if err != nil {
+		return db.Entity{}, err
+	}
+	return queries.GetEntityByPath(ctx, db.GetEntityByPathParams{OPath: path, OKey: key})
+}
@@ -0,0 +1,113 @@
+package tree
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// uniqueViolation is the postgres error code of a unique constraint violation
+const uniqueViolation = "23505"
+
+var (
+	ErrRootEntity     = errors.New("the root entity cannot be moved or renamed")
+	ErrParentNotFound = errors.New("the target parent is deleted or does not exist")
+	ErrCycle          = errors.New("an entity cannot be moved under itself or its descendants")
+	ErrPathConflict   = errors.New("an entity with this path and key already exists")
+)
+
+// Move places an entity under parentID with key at the expected version. Its path is derived from
+// the parent and the paths of its live descendants are rewritten below it, the number of rewritten
+// descendants is returned. Under a new parent it is placed after its new siblings. A write at a
+// stale version fails with pgx.ErrNoRows. The move is recorded in the audit trail, the queries
+// must be bound to a transaction.
+func Move(ctx context.Context, queries *db.Queries, entity db.Entity, parentID pgtype.UUID, key string, version int64, userID pgtype.UUID) (db.Entity, int64, error) {
+	if IsRoot(entity.ID) {
+		return entity, 0, ErrRootEntity
+	}
+	if parentID == entity.ID {
+		return entity, 0, ErrCycle
+	}
+
+	parent, err := queries.GetEntityByID(ctx, parentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity, 0, ErrParentNotFound
+	}
+	if err != nil {
+		return entity, 0, err
+	}
+
+	// The new parent must not be one of the descendants of the entity
+	if IsAncestor(entity.TreePath, parent.TreePath) {
+		return entity, 0, ErrCycle
+	}
+
+	if parent.ID != entity.ParentID {
+		if err := CheckChild(ctx, queries, parent.ID, entity.EntityClass); err != nil {
+			return entity, 0, err
+		}
+	}
+
+	path := ChildPath(parent.OPath, key)
+	existing, err := queries.GetEntityByPath(ctx, db.GetEntityByPathParams{OPath: path, OKey: key})
+	switch {
+	case err == nil && existing.ID != entity.ID:
+		return entity, 0, ErrPathConflict
+	case err != nil && !errors.Is(err, pgx.ErrNoRows):
+		return entity, 0, err
+	}
+
+	moved, err := queries.UpdateEntity(ctx, db.UpdateEntityParams{
+		ID:        entity.ID,
+		ParentID:  parent.ID,
+		OKey:      key,
+		OPath:     path,
+		Published: entity.Published,
+		HasData:   entity.HasData,
+		UpdatedBy: userID,
+		Version:   version,
+	})
+	if err != nil {
+		return entity, 0, pathConflict(err)
+	}
+
+	// The manual position among the old siblings means nothing under the new parent
+	if moved.ParentID != entity.ParentID {
+		if moved.SortIndex, err = queries.AppendEntitySortIndex(ctx, moved.ID); err != nil {
+			return moved, 0, err
+		}
+	}
+
+	count, err := queries.RewriteSubtreePaths(ctx, moved.ID)
+	if err != nil {
+		return moved, 0, pathConflict(err)
+	}
+
+	before, err := audit.EntityState(entity, nil, nil)
+	if err != nil {
+		return moved, 0, err
+	}
+	after, err := audit.EntityState(moved, nil, nil)
+	if err != nil {
+		return moved, 0, err
+	}
+	if err := audit.RecordEntity(ctx, queries, audit.ActionMove, moved.ID, before, after); err != nil {
+		return moved, 0, err
+	}
+
+	return moved, count, nil
+}
+
+// pathConflict maps a unique violation of the path and key to ErrPathConflict
+func pathConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrPathConflict
+	}
+	return err
+}
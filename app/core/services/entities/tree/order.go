@@ -0,0 +1,83 @@
+package tree
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrNotChild     = errors.New("entity is not a live child of the parent")
+	ErrDuplicateID  = errors.New("entity is listed more than once")
+	ErrSelfPosition = errors.New("an entity cannot be placed before or after itself")
+)
+
+// Reorder puts the children listed in ids first, in that order, followed by the children that are
+// not listed in their current order
+func Reorder(current, ids []pgtype.UUID) ([]pgtype.UUID, error) {
+	children := make(map[pgtype.UUID]bool, len(current))
+	for _, id := range current {
+		children[id] = true
+	}
+
+	listed := make(map[pgtype.UUID]bool, len(ids))
+	order := make([]pgtype.UUID, 0, len(current))
+	for _, id := range ids {
+		if !children[id] {
+			return nil, ErrNotChild
+		}
+		if listed[id] {
+			return nil, ErrDuplicateID
+		}
+		listed[id] = true
+		order = append(order, id)
+	}
+
+	for _, id := range current {
+		if !listed[id] {
+			order = append(order, id)
+		}
+	}
+
+	return order, nil
+}
+
+// Place moves id right before target, or right after it when after is set, the other children
+// keep their order
+func Place(current []pgtype.UUID, id, target pgtype.UUID, after bool) ([]pgtype.UUID, error) {
+	if id == target {
+		return nil, ErrSelfPosition
+	}
+
+	found := false
+	rest := make([]pgtype.UUID, 0, len(current))
+	for _, child := range current {
+		if child == id {
+			found = true
+			continue
+		}
+		rest = append(rest, child)
+	}
+	if !found {
+		return nil, ErrNotChild
+	}
+
+	order := make([]pgtype.UUID, 0, len(current))
+	placed := false
+	for _, child := range rest {
+		if child == target && !after {
+			order = append(order, id)
+			placed = true
+		}
+		order = append(order, child)
+		if child == target && after {
+			order = append(order, id)
+			placed = true
+		}
+	}
+	if !placed {
+		return nil, ErrNotChild
+	}
+
+	return order, nil
+}
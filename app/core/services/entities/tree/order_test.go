@@ -0,0 +1,88 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func uuids(t *testing.T, n int) []pgtype.UUID {
+	t.Helper()
+	ids := make([]pgtype.UUID, n)
+	for i := range ids {
+		if err := ids[i].Scan(fmt.Sprintf("00000000-0000-0000-0000-%012d", i+10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ids
+}
+
+func equalOrder(got, want []pgtype.UUID) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestReorder(t *testing.T) {
+	ids := uuids(t, 5)
+	a, b, c, d, e := ids[0], ids[1], ids[2], ids[3], ids[4]
+
+	order, err := Reorder(ids, []pgtype.UUID{d, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []pgtype.UUID{d, b, a, c, e}; !equalOrder(order, want) {
+		t.Errorf("Reorder = %v, want %v", order, want)
+	}
+
+	if _, err := Reorder(ids, []pgtype.UUID{a, a}); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("expected ErrDuplicateID, got %v", err)
+	}
+
+	stranger := uuids(t, 6)[5]
+	if _, err := Reorder(ids, []pgtype.UUID{stranger}); !errors.Is(err, ErrNotChild) {
+		t.Errorf("expected ErrNotChild, got %v", err)
+	}
+}
+
+func TestPlace(t *testing.T) {
+	ids := uuids(t, 4)
+	a, b, c, d := ids[0], ids[1], ids[2], ids[3]
+
+	tests := []struct {
+		name   string
+		id     pgtype.UUID
+		target pgtype.UUID
+		after  bool
+		want   []pgtype.UUID
+	}{
+		{name: "before the first", id: d, target: a, want: []pgtype.UUID{d, a, b, c}},
+		{name: "after the last", id: a, target: d, after: true, want: []pgtype.UUID{b, c, d, a}},
+		{name: "after a neighbour", id: b, target: c, after: true, want: []pgtype.UUID{a, c, b, d}},
+		{name: "before itself in place", id: b, target: c, want: []pgtype.UUID{a, b, c, d}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := Place(ids, tt.id, tt.target, tt.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalOrder(order, tt.want) {
+				t.Errorf("Place = %v, want %v", order, tt.want)
+			}
+		})
+	}
+
+	if _, err := Place(ids, a, a, false); !errors.Is(err, ErrSelfPosition) {
+		t.Errorf("expected ErrSelfPosition, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package tree
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RootEntityID is the system entity every tree starts from, it is created by the entities migration
+const RootEntityID = "00000000-0000-0000-0000-000000000001"
+
+// IsRoot reports whether id is the system root entity
+func IsRoot(id pgtype.UUID) bool {
+	return id.Valid && id.String() == RootEntityID
+}
+
+// RootPath is the path of the root entity
+const RootPath = "/"
+
+var ErrInvalidPath = errors.New("path must be absolute like /products/shoes without empty segments")
+
+// SplitPath normalizes an absolute entity path and returns it with the key of the entity it
+// addresses, which is its last segment. A trailing slash is ignored and the root path has no key.
+func SplitPath(path string) (string, string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return "", "", ErrInvalidPath
+	}
+	if path == RootPath {
+		return RootPath, "", nil
+	}
+
+	path = strings.TrimSuffix(path, "/")
+	segments := strings.Split(path[1:], "/")
+	for _, segment := range segments {
+		if segment == "" {
+			return "", "", ErrInvalidPath
+		}
+	}
+
+	return path, segments[len(segments)-1], nil
+}
+
+// ChildPath is the path of an entity with key under a parent path. Paths include the key of the
+// entity itself, so children of the root at / are /key and children of /a are /a/key.
+func ChildPath(parentPath, key string) string {
+	return strings.TrimSuffix(parentPath, "/") + "/" + key
+}
+
+// SuffixKey is the key to try for a copy of key once attempt earlier keys were taken, attempt 0 is
+// the key itself followed by key-copy, key-copy-2 and so on
+func SuffixKey(key string, attempt int) string {
+	switch attempt {
+	case 0:
+		return key
+	case 1:
+		return key + "-copy"
+	default:
+		return key + "-copy-" + strconv.Itoa(attempt)
+	}
+}
+
+// IsAncestor reports whether the entity at tree path ancestor is a strict ancestor of the entity
+// at tree path descendant. Tree paths list the ids from the root down to the entity itself like
+// /<root id>/<parent id>/<id>/, so the tree path of an ancestor is a prefix of its descendants'.
+func IsAncestor(ancestor, descendant string) bool {
+	return ancestor != "" && ancestor != descendant && strings.HasPrefix(descendant, ancestor)
+}
@@ -0,0 +1,140 @@
+package tree
+
+import (
+	"testing"
+
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestChildPath(t *testing.T) {
+	tests := []struct {
+		parent string
+		key    string
+		want   string
+	}{
+		{parent: "/", key: "products", want: "/products"},
+		{parent: "/products", key: "tv", want: "/products/tv"},
+		{parent: "/products/", key: "tv", want: "/products/tv"},
+	}
+
+	for _, tt := range tests {
+		if got := ChildPath(tt.parent, tt.key); got != tt.want {
+			t.Errorf("ChildPath(%q, %q) = %q, want %q", tt.parent, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestIsRoot(t *testing.T) {
+	var root, other pgtype.UUID
+	if err := root.Scan(RootEntityID); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Scan("00000000-0000-0000-0000-000000000002"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsRoot(root) {
+		t.Error("expected the root entity to be the root")
+	}
+	if IsRoot(other) || IsRoot(pgtype.UUID{}) {
+		t.Error("expected only the root entity to be the root")
+	}
+}
+
+func TestSuffixKey(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    string
+	}{
+		{attempt: 0, want: "tv"},
+		{attempt: 1, want: "tv-copy"},
+		{attempt: 2, want: "tv-copy-2"},
+		{attempt: 10, want: "tv-copy-10"},
+	}
+
+	for _, tt := range tests {
+		if got := SuffixKey("tv", tt.attempt); got != tt.want {
+			t.Errorf("SuffixKey(%q, %d) = %q, want %q", "tv", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		key     string
+		wantErr bool
+	}{
+		{path: "/", want: "/", key: ""},
+		{path: "/products", want: "/products", key: "products"},
+		{path: "/products/shoes/runner-1", want: "/products/shoes/runner-1", key: "runner-1"},
+		{path: "/products/shoes/", want: "/products/shoes", key: "shoes"},
+		{path: "", wantErr: true},
+		{path: "products/shoes", wantErr: true},
+		{path: "/products//shoes", wantErr: true},
+		{path: "//", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		path, key, err := SplitPath(tt.path)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("SplitPath(%q): expected an error", tt.path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SplitPath(%q): unexpected error %v", tt.path, err)
+			continue
+		}
+		if path != tt.want || key != tt.key {
+			t.Errorf("SplitPath(%q) = %q, %q, want %q, %q", tt.path, path, key, tt.want, tt.key)
+		}
+	}
+}
+
+func TestIsAncestor(t *testing.T) {
+	root := "/" + RootEntityID + "/"
+	products := root + "00000000-0000-0000-0000-000000000002/"
+	tv := products + "00000000-0000-0000-0000-000000000003/"
+
+	tests := []struct {
+		ancestor   string
+		descendant string
+		want       bool
+	}{
+		{ancestor: root, descendant: tv, want: true},
+		{ancestor: products, descendant: tv, want: true},
+		{ancestor: tv, descendant: products, want: false},
+		{ancestor: tv, descendant: tv, want: false},
+		{ancestor: "", descendant: tv, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsAncestor(tt.ancestor, tt.descendant); got != tt.want {
+			t.Errorf("IsAncestor(%q, %q) = %v, want %v", tt.ancestor, tt.descendant, got, tt.want)
+		}
+	}
+}
+
+func TestLocateRejectsPath(t *testing.T) {
+	// A path that cannot belong to the key is rejected before the parent is looked up
+	tests := []struct {
+		path string
+		want error
+	}{
+		{path: "/products/tv", want: ErrPathMismatch},
+		{path: "/", want: ErrPathMismatch},
+		{path: "products/radio", want: ErrInvalidPath},
+		{path: "/products//radio", want: ErrInvalidPath},
+	}
+
+	for _, tt := range tests {
+		if _, _, err := Locate(context.Background(), nil, pgtype.UUID{}, "radio", tt.path); !errors.Is(err, tt.want) {
+			t.Errorf("Locate(%q) = %v, want %v", tt.path, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package tree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	validatorUtil "github.com/oriiyx/fritz/app/core/utils/validator"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// ReorderRequest either lists children in their new order, or places one child before or after a
+// sibling. Children that are not listed follow the listed ones in their current order.
+type ReorderRequest struct {
+	IDs    []string `json:"ids,omitempty" validate:"omitempty,dive,uuid"`
+	ID     string   `json:"id,omitempty" validate:"omitempty,uuid"`
+	Before string   `json:"before,omitempty" validate:"omitempty,uuid"`
+	After  string   `json:"after,omitempty" validate:"omitempty,uuid"`
+}
+
+type ReorderResponse struct {
+	Items   []db.ListEntityChildOrderRow `json:"items"`
+	Updated int64                        `json:"updated"`
+}
+
+// Reorder is an endpoint that sets the manual order of the live children of an entity. The children
+// are numbered from 0 in their new order, the index sort of the children lists them that way.
+func (h *Handler) Reorder(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	var req ReorderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to decode request")
+		errhandler.BadRequest(w, errhandler.RespInvalidRequestBody)
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to marshal validation errors")
+			errhandler.ServerError(w, errhandler.RespJSONEncodeFailure)
+			return
+		}
+		errhandler.ValidationErrors(w, respBody)
+		return
+	}
+
+	listed := len(req.IDs) > 0
+	placed := req.ID != "" || req.Before != "" || req.After != ""
+	if listed == placed || (placed && (req.ID == "" || (req.Before == "") == (req.After == ""))) {
+		errhandler.BadRequest(w, []byte(`{"error": "give either ids, or id with one of before and after"}`))
+		return
+	}
+
+	parent, ok := h.loadEntity(w, r, reqID, EntityIDKey)
+	if !ok {
+		return
+	}
+
+	var response ReorderResponse
+	err := pgx.BeginFunc(r.Context(), h.DB, func(tx pgx.Tx) error {
+		queries := h.Queries.WithTx(tx)
+
+		children, err := queries.ListEntityChildOrder(r.Context(), parent.ID)
+		if err != nil {
+			return err
+		}
+
+		current := make([]pgtype.UUID, len(children))
+		for i, child := range children {
+			current[i] = child.ID
+		}
+
+		order, err := reorder(current, req)
+		if err != nil {
+			return err
+		}
+
+		params := db.UpdateEntitySortIndexesParams{Ids: order, SortIndexes: make([]int32, len(order))}
+		response.Items = make([]db.ListEntityChildOrderRow, len(order))
+		for i, id := range order {
+			params.SortIndexes[i] = int32(i)
+			response.Items[i] = db.ListEntityChildOrderRow{ID: id, SortIndex: int32(i)}
+		}
+
+		response.Updated, err = queries.UpdateEntitySortIndexes(r.Context(), params)
+		if err != nil || response.Updated == 0 {
+			return err
+		}
+
+		before, err := audit.ObjectState(map[string]interface{}{"children": current})
+		if err != nil {
+			return err
+		}
+		after, err := audit.ObjectState(map[string]interface{}{"children": order})
+		if err != nil {
+			return err
+		}
+		return audit.RecordEntity(r.Context(), queries, audit.ActionReorder, parent.ID, before, after)
+	})
+	if err != nil {
+		if errors.Is(err, ErrNotChild) || errors.Is(err, ErrDuplicateID) || errors.Is(err, ErrSelfPosition) {
+			errhandler.UnprocessableEntity(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("parent_id", parent.ID.String()).Msg("Failed to reorder children")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return
+	}
+
+	h.Logger.Info().
+		Str("parent_id", parent.ID.String()).
+		Int64("updated", response.Updated).
+		Msg("Children reordered")
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// reorder applies the list or the placement of req to the current order of the children
+func reorder(current []pgtype.UUID, req ReorderRequest) ([]pgtype.UUID, error) {
+	if len(req.IDs) > 0 {
+		ids := make([]pgtype.UUID, len(req.IDs))
+		for i, raw := range req.IDs {
+			if err := ids[i].Scan(raw); err != nil {
+				return nil, err
+			}
+		}
+		return Reorder(current, ids)
+	}
+
+	var id, target pgtype.UUID
+	if err := id.Scan(req.ID); err != nil {
+		return nil, err
+	}
+	if err := target.Scan(req.Before + req.After); err != nil {
+		return nil, err
+	}
+
+	return Place(current, id, target, req.After != "")
+}
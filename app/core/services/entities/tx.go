@@ -0,0 +1,16 @@
+package entities
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// inTx runs fn in a transaction with the queries bound to it, the transaction commits when fn
+// returns nil and rolls back otherwise
+func (h *Handler) inTx(ctx context.Context, fn func(tx pgx.Tx, queries *db.Queries) error) error {
+	return pgx.BeginFunc(ctx, h.DB, func(tx pgx.Tx) error {
+		return fn(tx, h.Queries.WithTx(tx))
+	})
+}
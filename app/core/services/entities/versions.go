@@ -0,0 +1,339 @@
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/versions"
+	"github.com/oriiyx/fritz/app/core/services/entities/writes"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+const (
+	VersionKey = "version"
+
+	DefaultVersionsLimit = 50
+	MaxVersionsLimit     = 500
+)
+
+// VersionResponse is a version record with its data snapshot as JSON rather than bytes
+type VersionResponse struct {
+	db.EntityVersion
+	Data json.RawMessage `json:"data"`
+}
+
+func newVersionResponse(version db.EntityVersion) VersionResponse {
+	return VersionResponse{EntityVersion: version, Data: version.Data}
+}
+
+type VersionsResponse struct {
+	Items  []VersionResponse `json:"items"`
+	Total  int64             `json:"total"`
+	Limit  int               `json:"limit"`
+	Offset int               `json:"offset"`
+}
+
+// ListVersions is an endpoint that lists the version history of an entity, newest first
+func (h *Handler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	entity, _, ok := h.loadEntity(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	params := db.ListEntityVersionsParams{EntityID: entity.ID, Limit: DefaultVersionsLimit}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > MaxVersionsLimit {
+			errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": "limit must be between 1 and %d"}`, MaxVersionsLimit)))
+			return
+		}
+		params.Limit = int32(n)
+	}
+	if offset := r.URL.Query().Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			errhandler.BadRequest(w, []byte(`{"error": "offset must be a non-negative integer"}`))
+			return
+		}
+		params.Offset = int32(n)
+	}
+
+	items, err := h.Queries.ListEntityVersions(r.Context(), params)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to list entity versions")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	total, err := h.Queries.CountEntityVersions(r.Context(), entity.ID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to count entity versions")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	response := VersionsResponse{
+		Items:  make([]VersionResponse, 0, len(items)),
+		Total:  total,
+		Limit:  int(params.Limit),
+		Offset: int(params.Offset),
+	}
+	for _, item := range items {
+		response.Items = append(response.Items, newVersionResponse(item))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// GetVersion is an endpoint that returns a single version of an entity
+func (h *Handler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	entity, _, ok := h.loadEntity(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	version, ok := h.loadVersion(w, r, reqID, entity.ID, chi.URLParam(r, VersionKey))
+	if !ok {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(newVersionResponse(version))
+}
+
+// DiffVersions is an endpoint that compares the versions from and to of an entity field by field
+func (h *Handler) DiffVersions(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	if r.URL.Query().Get("from") == "" || r.URL.Query().Get("to") == "" {
+		errhandler.BadRequest(w, []byte(`{"error": "from and to versions are required"}`))
+		return
+	}
+
+	entity, _, ok := h.loadEntity(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	from, ok := h.loadVersion(w, r, reqID, entity.ID, r.URL.Query().Get("from"))
+	if !ok {
+		return
+	}
+	to, ok := h.loadVersion(w, r, reqID, entity.ID, r.URL.Query().Get("to"))
+	if !ok {
+		return
+	}
+
+	diff, err := versions.Compare(from, to)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to compare entity versions")
+		errhandler.ServerError(w, errhandler.RespProcessFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(diff)
+}
+
+// RestoreVersion is an endpoint that writes the data of an earlier version back through the
+// adapter, as a save would. The metadata of the entity is left as it is and the restore itself
+// becomes a new version.
+func (h *Handler) RestoreVersion(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	expectedVersion, ok := h.requireIfMatch(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	entity, adapter, ok := h.loadEntity(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	if !h.checkLock(w, r, reqID, entity.ID) {
+		return
+	}
+
+	version, ok := h.loadVersion(w, r, reqID, entity.ID, chi.URLParam(r, VersionKey))
+	if !ok {
+		return
+	}
+	if version.Data == nil {
+		errhandler.BadRequest(w, []byte(`{"error": "version has no data to restore"}`))
+		return
+	}
+
+	data, err := versions.RestoreData(version.Data, adapter.Columns())
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to decode version data")
+		errhandler.ServerError(w, errhandler.RespJSONDecodeFailure)
+		return
+	}
+
+	if entity.Version != expectedVersion {
+		errhandler.PreconditionFailed(w, errhandler.RespEntityVersionConflict)
+		return
+	}
+
+	// A restore replaces the data like a save, so it runs the update hooks
+	payload := &hooks.EntityUpdatePayload{
+		Class:  entity.EntityClass,
+		Entity: entity,
+		Data:   data,
+		UserID: ctxUtil.GetUserID(r.Context()),
+	}
+	if !h.triggerBefore(w, r, reqID, hooks.HookBeforeEntityUpdate, payload) {
+		return
+	}
+	data = payload.Data
+
+	var result interface{}
+	err = h.inTx(r.Context(), func(tx pgx.Tx, queries *db.Queries) error {
+		before, err := audit.ReadEntityState(r.Context(), adapter.WithTx(tx), entity)
+		if err != nil {
+			return err
+		}
+
+		entity, result, err = writes.Data(r.Context(), tx, queries, adapter, entity, expectedVersion, data, payload.UserID)
+		if err != nil {
+			return err
+		}
+
+		return h.record(r.Context(), queries, adapter, audit.ActionRestoreVersion, before, entity, result)
+	})
+	if err != nil {
+		if h.writeValidationError(w, reqID, err) {
+			return
+		}
+		if h.writeVersionConflict(w, reqID, err) {
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to restore entity version")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return
+	}
+
+	h.Logger.Info().
+		Str("entity_id", entity.ID.String()).
+		Int64("restored_version", version.Version).
+		Msg("Entity version restored")
+
+	payload.Entity = entity
+	h.triggerAfter(r, reqID, hooks.HookAfterEntityUpdate, payload)
+
+	response := map[string]interface{}{
+		"entity":        entity,
+		"data":          result,
+		"restored_from": version.Version,
+	}
+
+	setETag(w, entity.Version)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// record writes the version history record and the audit entry of a write inside its transaction
+func (h *Handler) record(ctx context.Context, queries *db.Queries, adapter adapters.EntityAdapter, action string, before audit.State, entity db.Entity, data interface{}) error {
+	return writes.Record(ctx, queries, adapter, action, before, entity, data, ctxUtil.GetUserID(ctx), h.versionPolicy())
+}
+
+// recordVersion writes the version history record of a write inside its transaction
+func (h *Handler) recordVersion(ctx context.Context, queries *db.Queries, adapter adapters.EntityAdapter, entity db.Entity, data interface{}) error {
+	_, err := versions.Record(ctx, queries, entity, data, adapter.Columns(), ctxUtil.GetUserID(ctx), h.versionPolicy())
+	return err
+}
+
+// versionPolicy is the configured pruning of the version history
+func (h *Handler) versionPolicy() versions.Policy {
+	return versions.Policy{KeepLast: h.Conf.Versions.KeepLast, MaxAge: h.Conf.Versions.MaxAge}
+}
+
+// loadEntity reads the entity of the URL with the adapter of its class, it writes the error
+// response and returns false when there is none
+func (h *Handler) loadEntity(w http.ResponseWriter, r *http.Request, reqID string) (db.Entity, adapters.EntityAdapter, bool) {
+	classID := chi.URLParam(r, DefinitionIDKey)
+
+	adapter, err := adapters.Get(classID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str("class_id", classID).Msg("Unknown entity class")
+		errhandler.BadRequest(w, []byte(`{"error": "unknown entity class"}`))
+		return db.Entity{}, nil, false
+	}
+
+	entity, ok := h.loadEntityOfClass(w, r, reqID, classID)
+	if !ok {
+		return entity, nil, false
+	}
+
+	return entity, adapter, true
+}
+
+// loadEntityOfClass reads the entity of the URL and checks that it is of classID, it writes the
+// error response and returns false when there is none
+func (h *Handler) loadEntityOfClass(w http.ResponseWriter, r *http.Request, reqID, classID string) (db.Entity, bool) {
+	var entityID pgtype.UUID
+	if err := entityID.Scan(chi.URLParam(r, EntityIDKey)); err != nil {
+		errhandler.BadRequest(w, []byte(`{"error": "invalid entity_id"}`))
+		return db.Entity{}, false
+	}
+
+	entity, err := h.Queries.GetEntityByID(r.Context(), entityID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "entity not found"}`))
+			return entity, false
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to load entity")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return entity, false
+	}
+
+	if entity.EntityClass != classID {
+		errhandler.BadRequest(w, []byte(`{"error": "entity class mismatch"}`))
+		return entity, false
+	}
+
+	return entity, true
+}
+
+// loadVersion reads a version of an entity, it writes the error response and returns false when
+// there is none
+func (h *Handler) loadVersion(w http.ResponseWriter, r *http.Request, reqID string, entityID pgtype.UUID, value string) (db.EntityVersion, bool) {
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || number <= 0 {
+		errhandler.BadRequest(w, []byte(`{"error": "invalid version"}`))
+		return db.EntityVersion{}, false
+	}
+
+	version, err := h.Queries.GetEntityVersion(r.Context(), db.GetEntityVersionParams{EntityID: entityID, Version: number})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(fmt.Sprintf(`{"error": "version %d not found"}`, number)))
+			return version, false
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to load entity version")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return version, false
+	}
+
+	return version, true
+}
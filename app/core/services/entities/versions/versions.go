@@ -0,0 +1,226 @@
+package versions
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// Policy decides which versions of an entity are kept. A version survives while it is among the
+// newest KeepLast or younger than MaxAge, zero disables a rule and with both zero every version
+// is kept.
+type Policy struct {
+	KeepLast int
+	MaxAge   time.Duration
+}
+
+// Enabled reports whether the policy ever prunes
+func (p Policy) Enabled() bool {
+	return p.KeepLast > 0 || p.MaxAge > 0
+}
+
+// Change is a single field that differs between two versions, a missing value is null
+type Change struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+// Diff holds the changes from one version of an entity to another
+type Diff struct {
+	From     int64    `json:"from"`
+	To       int64    `json:"to"`
+	Metadata []Change `json:"metadata"`
+	Data     []Change `json:"data"`
+}
+
+// Record writes the version of an entity after a successful write and prunes the versions the
+// policy no longer keeps. data is the class data row the adapter returned, nil when the entity
+// has none. The queries must be bound to the transaction of the write.
+func Record(ctx context.Context, queries *db.Queries, entity db.Entity, data interface{}, columns []adapters.Column, userID pgtype.UUID, policy Policy) (db.EntityVersion, error) {
+	var snapshot []byte
+	if data != nil {
+		var err error
+		if snapshot, err = Snapshot(data, columns); err != nil {
+			return db.EntityVersion{}, err
+		}
+	}
+
+	version, err := queries.CreateEntityVersion(ctx, db.CreateEntityVersionParams{
+		EntityID:    entity.ID,
+		EntityClass: entity.EntityClass,
+		Version:     entity.Version,
+		ParentID:    entity.ParentID,
+		OKey:        entity.OKey,
+		OPath:       entity.OPath,
+		Published:   entity.Published,
+		Data:        snapshot,
+		CreatedBy:   userID,
+	})
+	if err != nil {
+		return version, err
+	}
+
+	_, err = Prune(ctx, queries, entity.ID, policy, time.Now())
+	return version, err
+}
+
+// Prune deletes the versions of an entity the policy no longer keeps at now
+func Prune(ctx context.Context, queries *db.Queries, entityID pgtype.UUID, policy Policy, now time.Time) (int64, error) {
+	if !policy.Enabled() {
+		return 0, nil
+	}
+
+	// Without an age rule every version is old enough to go unless it is among the newest
+	keepAfter := now
+	if policy.MaxAge > 0 {
+		keepAfter = now.Add(-policy.MaxAge)
+	}
+
+	return queries.PruneEntityVersions(ctx, db.PruneEntityVersionsParams{
+		EntityID:  entityID,
+		KeepAfter: pgtype.Timestamptz{Time: keepAfter, Valid: true},
+		KeepLast:  int32(policy.KeepLast),
+	})
+}
+
+// Snapshot encodes the component columns of a class data row as a JSON object, bookkeeping
+// columns such as the row id and timestamps are left out
+func Snapshot(data interface{}, columns []adapters.Column) ([]byte, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var row map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &row); err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[string]json.RawMessage, len(columns))
+	for _, column := range columns {
+		if value, ok := row[column.Name]; ok {
+			snapshot[column.Name] = value
+		}
+	}
+
+	return json.Marshal(snapshot)
+}
+
+// RestoreData decodes a snapshot into the data of an adapter update. Fields of columns that no
+// longer exist are dropped.
+func RestoreData(snapshot []byte, columns []adapters.Column) (map[string]interface{}, error) {
+	var stored map[string]interface{}
+	if err := json.Unmarshal(snapshot, &stored); err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]interface{}, len(columns))
+	for _, column := range columns {
+		if value, ok := stored[column.Name]; ok {
+			data[column.Name] = value
+		}
+	}
+
+	return data, nil
+}
+
+// Compare diffs two versions field by field, metadata and class data separately
+func Compare(from, to db.EntityVersion) (*Diff, error) {
+	diff := &Diff{From: from.Version, To: to.Version, Metadata: []Change{}, Data: []Change{}}
+
+	metadata := []struct {
+		field    string
+		from, to interface{}
+	}{
+		{"parent_id", from.ParentID, to.ParentID},
+		{"o_key", from.OKey, to.OKey},
+		{"o_path", from.OPath, to.OPath},
+		{"published", from.Published, to.Published},
+	}
+	for _, m := range metadata {
+		fromValue, err := json.Marshal(m.from)
+		if err != nil {
+			return nil, err
+		}
+		toValue, err := json.Marshal(m.to)
+		if err != nil {
+			return nil, err
+		}
+		if string(fromValue) != string(toValue) {
+			diff.Metadata = append(diff.Metadata, Change{Field: m.field, From: fromValue, To: toValue})
+		}
+	}
+
+	fromData, err := decodeFields(from.Data)
+	if err != nil {
+		return nil, err
+	}
+	toData, err := decodeFields(to.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(fromData)+len(toData))
+	for field := range fromData {
+		fields = append(fields, field)
+	}
+	for field := range toData {
+		if _, ok := fromData[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		change, changed, err := compareField(field, fromData[field], toData[field])
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			diff.Data = append(diff.Data, change)
+		}
+	}
+
+	return diff, nil
+}
+
+func decodeFields(snapshot []byte) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if len(snapshot) == 0 {
+		return fields, nil
+	}
+	if err := json.Unmarshal(snapshot, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// compareField compares decoded values so formatting differences of the stored JSON don't count
+func compareField(field string, from, to json.RawMessage) (Change, bool, error) {
+	if from == nil {
+		from = json.RawMessage("null")
+	}
+	if to == nil {
+		to = json.RawMessage("null")
+	}
+
+	var fromValue, toValue interface{}
+	if err := json.Unmarshal(from, &fromValue); err != nil {
+		return Change{}, false, err
+	}
+	if err := json.Unmarshal(to, &toValue); err != nil {
+		return Change{}, false, err
+	}
+
+	if reflect.DeepEqual(fromValue, toValue) {
+		return Change{}, false, nil
+	}
+	return Change{Field: field, From: from, To: to}, true, nil
+}
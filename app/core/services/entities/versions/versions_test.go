@@ -0,0 +1,85 @@
+package versions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+var productColumns = []adapters.Column{
+	{Name: "sku", DBType: "varchar"},
+	{Name: "price", DBType: "float8", Nullable: true},
+}
+
+func TestSnapshot(t *testing.T) {
+	row := struct {
+		ID        int64       `json:"id"`
+		Sku       string      `json:"sku"`
+		Price     pgtype.Text `json:"price"`
+		UpdatedAt time.Time   `json:"updated_at"`
+	}{ID: 7, Sku: "TV", UpdatedAt: time.Now()}
+
+	snapshot, err := Snapshot(row, productColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := string(snapshot), `{"price":null,"sku":"TV"}`; got != want {
+		t.Errorf("expected snapshot %s, got %s", want, got)
+	}
+}
+
+func TestRestoreData(t *testing.T) {
+	data, err := RestoreData([]byte(`{"sku": "TV", "price": 12.5, "removed": true}`), productColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data) != 2 || data["sku"] != "TV" || data["price"] != 12.5 {
+		t.Errorf("unexpected restore data %v", data)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	from := db.EntityVersion{Version: 2, OKey: "tv", OPath: "/products/tv", Data: []byte(`{"sku": "TV", "price": 10}`)}
+	to := db.EntityVersion{Version: 5, OKey: "tv", OPath: "/archive/tv", Published: true, Data: []byte(`{"price":12.5,"sku":"TV","stock":3}`)}
+
+	diff, err := Compare(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff.From != 2 || diff.To != 5 {
+		t.Errorf("expected diff from 2 to 5, got %d to %d", diff.From, diff.To)
+	}
+
+	var metadata []string
+	for _, change := range diff.Metadata {
+		metadata = append(metadata, change.Field)
+	}
+	if len(metadata) != 2 || metadata[0] != "o_path" || metadata[1] != "published" {
+		t.Errorf("expected o_path and published to change, got %v", metadata)
+	}
+
+	if len(diff.Data) != 2 {
+		t.Fatalf("expected price and stock to change, got %+v", diff.Data)
+	}
+	if diff.Data[0].Field != "price" || string(diff.Data[0].From) != "10" || string(diff.Data[0].To) != "12.5" {
+		t.Errorf("unexpected price change %+v", diff.Data[0])
+	}
+	if diff.Data[1].Field != "stock" || string(diff.Data[1].From) != "null" || string(diff.Data[1].To) != "3" {
+		t.Errorf("unexpected stock change %+v", diff.Data[1])
+	}
+}
+
+func TestPolicyEnabled(t *testing.T) {
+	if (Policy{}).Enabled() {
+		t.Error("expected an empty policy to keep every version")
+	}
+	if !(Policy{KeepLast: 10}).Enabled() || !(Policy{MaxAge: time.Hour}).Enabled() {
+		t.Error("expected either rule to enable pruning")
+	}
+}
@@ -0,0 +1,94 @@
+package writes
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/versions"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// Data creates the data row of an entity, or replaces it at the expected version, on behalf of the
+// user. It returns the entity at the version the write claimed with the written row, a write that
+// lost the race matches no rows and fails with pgx.ErrNoRows. The queries must be bound to tx.
+func Data(ctx context.Context, tx pgx.Tx, queries *db.Queries, adapter adapters.EntityAdapter, entity db.Entity, version int64, data map[string]interface{}, userID pgtype.UUID) (db.Entity, interface{}, error) {
+	return write(ctx, tx, queries, adapter, entity, version, data, userID, false)
+}
+
+// Patch writes only the keys of data like Data, an entity without a data row gets one created
+func Patch(ctx context.Context, tx pgx.Tx, queries *db.Queries, adapter adapters.EntityAdapter, entity db.Entity, version int64, data map[string]interface{}, userID pgtype.UUID) (db.Entity, interface{}, error) {
+	return write(ctx, tx, queries, adapter, entity, version, data, userID, true)
+}
+
+func write(ctx context.Context, tx pgx.Tx, queries *db.Queries, adapter adapters.EntityAdapter, entity db.Entity, version int64, data map[string]interface{}, userID pgtype.UUID, patch bool) (db.Entity, interface{}, error) {
+	adapter = adapter.WithTx(tx)
+
+	if entity.HasData {
+		var (
+			result interface{}
+			err    error
+		)
+		if patch {
+			result, err = adapter.Patch(ctx, entity.ID, version, data)
+		} else {
+			result, err = adapter.Update(ctx, entity.ID, version, data)
+		}
+		if err != nil {
+			return entity, nil, err
+		}
+
+		// The data write advanced the version, reload so callers carry it
+		entity, err = queries.SetEntityUpdatedBy(ctx, db.SetEntityUpdatedByParams{ID: entity.ID, UpdatedBy: userID})
+		return entity, result, err
+	}
+
+	result, err := adapter.Create(ctx, entity.ID, data)
+	if err != nil {
+		return entity, nil, err
+	}
+
+	// Marking the entity as having data also claims the next version
+	entity, err = queries.UpdateEntity(ctx, db.UpdateEntityParams{
+		ID:        entity.ID,
+		ParentID:  entity.ParentID,
+		OKey:      entity.OKey,
+		OPath:     entity.OPath,
+		Published: entity.Published,
+		HasData:   true,
+		UpdatedBy: userID,
+		Version:   version,
+	})
+	if err != nil {
+		return entity, nil, err
+	}
+
+	return entity, result, nil
+}
+
+// Record writes the version history record and the audit entry of a write that took an entity from
+// before to its state with data, before is nil for a create. The user is the author of both.
+func Record(ctx context.Context, queries *db.Queries, adapter adapters.EntityAdapter, action string, before audit.State, entity db.Entity, data interface{}, userID pgtype.UUID, policy versions.Policy) error {
+	if _, err := versions.Record(ctx, queries, entity, data, adapter.Columns(), userID, policy); err != nil {
+		return err
+	}
+	return Audit(ctx, queries, adapter, action, before, entity, data, userID)
+}
+
+// Audit appends only the audit entry of a write, for writes that leave the live data as it was
+func Audit(ctx context.Context, queries *db.Queries, adapter adapters.EntityAdapter, action string, before audit.State, entity db.Entity, data interface{}, userID pgtype.UUID) error {
+	after, err := audit.EntityState(entity, data, adapter.Columns())
+	if err != nil {
+		return err
+	}
+	changes, err := audit.Diff(before, after)
+	if err != nil {
+		return err
+	}
+
+	entry := audit.EntityEntry(action, entity.ID, changes)
+	entry.ActorID = userID
+	return audit.Record(ctx, queries, entry)
+}
@@ -0,0 +1,92 @@
+package imports
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oriiyx/fritz/app/core/api/base"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	"github.com/oriiyx/fritz/app/core/services/entities/versions"
+	"github.com/oriiyx/fritz/app/core/services/imports/pipeline"
+	"github.com/oriiyx/fritz/app/core/services/objects/definition_builder"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+const (
+	ProfileIDKey = "profile_id"
+	RunIDKey     = "run_id"
+
+	// ImportsFilePathTemplate holds uploaded files while their run is processed
+	ImportsFilePathTemplate = "var/imports"
+	// MaxUploadSize bounds the body of an upload
+	MaxUploadSize = 256 << 20
+)
+
+type Handler struct {
+	*base.HandlerController
+
+	entityBuilder *definition_builder.Builder
+	versionPolicy versions.Policy
+	background    *pipeline.Background
+}
+
+// New prepares the imports handler, runs that outlive their request are processed on background
+func New(ctrl *base.HandlerController, background *pipeline.Background) *Handler {
+	eb := definition_builder.NewDefinitionsBuilder(ctrl.Logger, ctrl.DB, ctrl.CustomWriter)
+
+	return &Handler{
+		HandlerController: ctrl,
+		entityBuilder:     eb,
+		versionPolicy:     versions.Policy{KeepLast: ctrl.Conf.Versions.KeepLast, MaxAge: ctrl.Conf.Versions.MaxAge},
+		background:        background,
+	}
+}
+
+// ProfileResponse is an import profile with its mapping as JSON rather than bytes
+type ProfileResponse struct {
+	db.ImportProfile
+	Mapping json.RawMessage `json:"mapping"`
+}
+
+func newProfileResponse(profile db.ImportProfile) ProfileResponse {
+	return ProfileResponse{ImportProfile: profile, Mapping: profile.Mapping}
+}
+
+// RunResponse is an import run with its row error report as JSON rather than bytes
+type RunResponse struct {
+	db.ImportRun
+	RowErrors json.RawMessage `json:"row_errors"`
+}
+
+func newRunResponse(run db.ImportRun) RunResponse {
+	return RunResponse{ImportRun: run, RowErrors: run.RowErrors}
+}
+
+// loadProfile reads the profile from the URL, it writes the error response and returns false
+// when the profile cannot be used
+func (h *Handler) loadProfile(w http.ResponseWriter, r *http.Request, id string) (db.ImportProfile, bool) {
+	profileID, ok := parseUUID(w, id, "invalid profile_id")
+	if !ok {
+		return db.ImportProfile{}, false
+	}
+
+	profile, err := h.Queries.GetImportProfile(r.Context(), profileID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "import profile not found"}`))
+			return profile, false
+		}
+		h.Logger.Error().Err(err).Str("profile_id", id).Msg("Failed to load import profile")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return profile, false
+	}
+
+	return profile, true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	respBody, _ := json.Marshal(errhandler.Error{Error: err.Error()})
+	errhandler.BadRequest(w, respBody)
+}
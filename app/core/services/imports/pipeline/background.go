@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// Background processes import runs outside of the requests that start them. Shutdown cancels the
+// runs in progress and waits until they have recorded their outcome, so a stopped server leaves no
+// run behind as running.
+type Background struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+func NewBackground() *Background {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Background{ctx: ctx, cancel: cancel}
+}
+
+// Go calls fn in the background. Its context keeps the values of ctx, like the acting user and the
+// request ID, but is only cancelled by Shutdown. Once shut down fn is called right away with a
+// cancelled context so it can record its failure.
+func (b *Background) Go(ctx context.Context, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(b.ctx, cancel)
+
+	b.mu.Lock()
+	if b.stopped {
+		b.mu.Unlock()
+		cancel()
+
+		fn(ctx)
+		return
+	}
+	b.wg.Add(1)
+	b.mu.Unlock()
+
+	go func() {
+		defer b.wg.Done()
+		defer cancel()
+		defer stop()
+
+		fn(ctx)
+	}()
+}
+
+// Shutdown cancels the runs in progress and waits for them to return, it gives up when ctx is done
+func (b *Background) Shutdown(ctx context.Context) error {
+	b.mu.Lock()
+	b.stopped = true
+	b.mu.Unlock()
+
+	b.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
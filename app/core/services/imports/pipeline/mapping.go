@@ -0,0 +1,156 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+)
+
+// Format is the layout of an import source file
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+// Valid reports whether the format is supported
+func (f Format) Valid() bool {
+	return f == FormatCSV || f == FormatJSONL
+}
+
+// Targets next to component names, they fill the entities row instead of the data table
+const (
+	TargetKey       = "o_key"
+	TargetPath      = "o_path"
+	TargetParentID  = "parent_id"
+	TargetPublished = "published"
+)
+
+var entityTargets = map[string]bool{
+	TargetKey:       true,
+	TargetPath:      true,
+	TargetParentID:  true,
+	TargetPublished: true,
+}
+
+// TransformType names a value transform, transforms run in order on the source value
+type TransformType string
+
+const (
+	// TransformTrim removes leading and trailing whitespace
+	TransformTrim TransformType = "trim"
+	// TransformLower lowercases the value
+	TransformLower TransformType = "lower"
+	// TransformUpper uppercases the value
+	TransformUpper TransformType = "upper"
+	// TransformSplit splits the value by Separator and keeps the part at Index, negative
+	// indexes count from the end
+	TransformSplit TransformType = "split"
+	// TransformDefault replaces an empty or missing value with Value
+	TransformDefault TransformType = "default"
+	// TransformLookup replaces an entity key with the ID of the entity under Path, optionally
+	// checking that it belongs to Class
+	TransformLookup TransformType = "lookup"
+)
+
+// Transform is a single step applied to a mapped value
+type Transform struct {
+	Type      TransformType `json:"type"`
+	Separator string        `json:"separator,omitempty"`
+	Index     int           `json:"index,omitempty"`
+	Value     string        `json:"value,omitempty"`
+	Path      string        `json:"path,omitempty"`
+	Class     string        `json:"class,omitempty"`
+}
+
+// FieldMapping maps a source column (CSV header or JSON key) to a component or entity target
+type FieldMapping struct {
+	Source     string      `json:"source,omitempty"`
+	Target     string      `json:"target"`
+	Transforms []Transform `json:"transforms,omitempty"`
+}
+
+// Mapping is the saved part of an import profile. Rows are matched on o_path and o_key,
+// so both targets must be mapped. The o_path is the path of the entity itself ending in its key,
+// a new entity must fit under its parent.
+type Mapping struct {
+	Fields []FieldMapping `json:"fields"`
+}
+
+// Sources returns the source columns the mapping reads
+func (m *Mapping) Sources() []string {
+	var sources []string
+	for _, field := range m.Fields {
+		if field.Source != "" {
+			sources = append(sources, field.Source)
+		}
+	}
+	return sources
+}
+
+// Validate checks the mapping against the definition it imports into
+func (m *Mapping) Validate(definition *definitions.EntityDefinition) error {
+	components := make(map[string]bool, len(definition.Layout.Components))
+	for _, component := range definition.Layout.Components {
+		components[component.Name] = true
+	}
+
+	seen := make(map[string]bool, len(m.Fields))
+	for _, field := range m.Fields {
+		if field.Target == "" {
+			return fmt.Errorf("mapping for source %q has no target", field.Source)
+		}
+		if !entityTargets[field.Target] && !components[field.Target] {
+			return fmt.Errorf("target %q is neither a component of %s nor one of o_key, o_path, parent_id, published", field.Target, definition.ID)
+		}
+		if seen[field.Target] {
+			return fmt.Errorf("target %q is mapped more than once", field.Target)
+		}
+		seen[field.Target] = true
+
+		if field.Source == "" && !hasDefault(field.Transforms) {
+			return fmt.Errorf("target %q needs a source or a default transform", field.Target)
+		}
+
+		for _, transform := range field.Transforms {
+			if err := transform.validate(); err != nil {
+				return fmt.Errorf("target %q: %w", field.Target, err)
+			}
+		}
+	}
+
+	if !seen[TargetKey] || !seen[TargetPath] {
+		return fmt.Errorf("o_key and o_path must be mapped, rows are matched on them")
+	}
+
+	return nil
+}
+
+func (t Transform) validate() error {
+	switch t.Type {
+	case TransformTrim, TransformLower, TransformUpper, TransformDefault:
+		return nil
+	case TransformSplit:
+		if t.Separator == "" {
+			return fmt.Errorf("split needs a separator")
+		}
+		return nil
+	case TransformLookup:
+		if t.Path == "" {
+			return fmt.Errorf("lookup needs the path the referenced entities live under")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown transform %q", t.Type)
+	}
+}
+
+func hasDefault(transforms []Transform) bool {
+	for _, transform := range transforms {
+		if transform.Type == TransformDefault {
+			return true
+		}
+	}
+	return false
+}
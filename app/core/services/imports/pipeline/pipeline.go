@@ -0,0 +1,661 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/kernel"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/locks"
+	"github.com/oriiyx/fritz/app/core/services/entities/publishing"
+	"github.com/oriiyx/fritz/app/core/services/entities/tree"
+	"github.com/oriiyx/fritz/app/core/services/entities/versions"
+	"github.com/oriiyx/fritz/app/core/services/entities/writes"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+const (
+	DefaultBatchSize = 100
+	// MaxReportedErrors caps the per-row report, Failed still counts every failed row
+	MaxReportedErrors = 1000
+)
+
+// keyPattern mirrors the valid_o_key constraint of the entities table
+var keyPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// RowError is the report entry of a row that could not be imported
+type RowError struct {
+	Row     int                 `json:"row"`
+	Key     string              `json:"key,omitempty"`
+	Errors  map[string][]string `json:"errors,omitempty"`
+	Message string              `json:"message,omitempty"`
+}
+
+// Progress is the running tally of an import, in dry runs Created and Updated count the rows
+// that would be created or updated
+type Progress struct {
+	Total     int        `json:"total"`
+	Processed int        `json:"processed"`
+	Created   int        `json:"created"`
+	Updated   int        `json:"updated"`
+	Failed    int        `json:"failed"`
+	Errors    []RowError `json:"errors"`
+}
+
+// Options control a single run
+type Options struct {
+	DryRun    bool
+	BatchSize int
+	// Total is the number of records when known upfront, see Count
+	Total int
+	// OnProgress is called after every batch, an error stops the run
+	OnProgress func(Progress) error
+	// OnHookError is called for an after hook that fails, the rows are committed by then and the
+	// run goes on
+	OnHookError func(name string, err error)
+}
+
+// Beginner starts transactions, it is satisfied by *pgxpool.Pool and pgx.Tx
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Pipeline imports records of one entity class through its adapter
+type Pipeline struct {
+	conn       Beginner
+	queries    *db.Queries
+	hooks      *kernel.Hooks
+	definition *definitions.EntityDefinition
+	adapter    adapters.EntityAdapter
+	mapping    Mapping
+	policy     versions.Policy
+
+	// fullMapping is true when every component is mapped, existing data is then replaced with
+	// Update, otherwise only the mapped components are written with Patch
+	fullMapping bool
+	kinds       map[string]definitions.ValueKind
+	lookups     map[lookupKey]string
+}
+
+// New validates the mapping against the definition and prepares a pipeline, every batch is written
+// in its own transaction on conn and versions are pruned by policy. Written rows run the entity
+// hooks of hooks like writes through the API, nil runs none.
+func New(conn Beginner, queries *db.Queries, hooks *kernel.Hooks, definition *definitions.EntityDefinition, adapter adapters.EntityAdapter, mapping Mapping, policy versions.Policy) (*Pipeline, error) {
+	if err := mapping.Validate(definition); err != nil {
+		return nil, err
+	}
+
+	p := &Pipeline{
+		conn:        conn,
+		queries:     queries,
+		hooks:       hooks,
+		definition:  definition,
+		adapter:     adapter,
+		mapping:     mapping,
+		policy:      policy,
+		fullMapping: true,
+		kinds:       make(map[string]definitions.ValueKind, len(definition.Layout.Components)),
+		lookups:     make(map[lookupKey]string),
+	}
+
+	mapped := make(map[string]bool, len(mapping.Fields))
+	for _, field := range mapping.Fields {
+		mapped[field.Target] = true
+	}
+	for _, component := range definition.Layout.Components {
+		p.kinds[component.Name] = component.DBType.Kind()
+		if !mapped[component.Name] {
+			p.fullMapping = false
+		}
+	}
+
+	return p, nil
+}
+
+// row is a source record mapped onto an entity
+type row struct {
+	number    int
+	key       string
+	path      string
+	parentID  *pgtype.UUID
+	published *bool
+	data      map[string]interface{}
+
+	// after holds the after hooks of the writes of the row
+	after []afterHook
+}
+
+// afterHook is an after hook of a written row, it runs once the batch of the row is committed
+type afterHook struct {
+	name    string
+	payload interface{}
+}
+
+// Run reads every record from r and validates it, unless DryRun is set valid rows are written.
+// Failing rows are reported in the progress and do not stop the run. A batch is committed at once,
+// every row of it is written in its own savepoint so a failing row is rolled back alone.
+func (p *Pipeline) Run(ctx context.Context, format Format, r io.Reader, opts Options) (Progress, error) {
+	progress := Progress{Total: opts.Total, Errors: make([]RowError, 0)}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	reader, err := NewReader(format, r)
+	if err != nil {
+		return progress, err
+	}
+
+	if headed, ok := reader.(interface{ Header() []string }); ok {
+		if err := checkHeader(headed.Header(), p.mapping.Sources()); err != nil {
+			return progress, err
+		}
+	}
+
+	for done := false; !done; {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+
+		// The tally and the after hooks of a batch only count once the batch is committed
+		batch := progress
+		var after []afterHook
+
+		var err error
+		if opts.DryRun {
+			done, err = p.runBatch(ctx, nil, reader, opts.BatchSize, &batch, nil)
+		} else {
+			err = pgx.BeginFunc(ctx, p.conn, func(tx pgx.Tx) error {
+				var batchErr error
+				done, batchErr = p.runBatch(ctx, tx, reader, opts.BatchSize, &batch, &after)
+				return batchErr
+			})
+		}
+		if err != nil {
+			return progress, err
+		}
+		progress = batch
+
+		for _, hook := range after {
+			if err := p.trigger(ctx, hook.name, hook.payload); err != nil && opts.OnHookError != nil {
+				opts.OnHookError(hook.name, err)
+			}
+		}
+
+		if progress.Total < progress.Processed {
+			progress.Total = progress.Processed
+		}
+
+		if opts.OnProgress != nil {
+			if err := opts.OnProgress(progress); err != nil {
+				return progress, err
+			}
+		}
+	}
+
+	return progress, nil
+}
+
+// runBatch processes up to size records and reports whether the reader is exhausted. Rows are
+// written on tx and the after hooks of the written rows are added to after, in dry runs tx is nil
+// and nothing is written.
+func (p *Pipeline) runBatch(ctx context.Context, tx pgx.Tx, reader Reader, size int, progress *Progress, after *[]afterHook) (bool, error) {
+	for i := 0; i < size; i++ {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return true, nil
+		}
+
+		var readErr *ReadError
+		if errors.As(err, &readErr) {
+			progress.fail(RowError{Row: readErr.Row, Message: readErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+
+		var rowErr *RowError
+		if tx == nil {
+			rowErr = p.process(ctx, nil, record, progress, nil)
+		} else if rowErr, err = p.processInSavepoint(ctx, tx, record, progress, after); err != nil {
+			return false, err
+		}
+		if rowErr != nil {
+			progress.fail(*rowErr)
+		}
+	}
+
+	return false, nil
+}
+
+func (pr *Progress) fail(rowErr RowError) {
+	pr.Processed++
+	pr.Failed++
+	if len(pr.Errors) < MaxReportedErrors {
+		pr.Errors = append(pr.Errors, rowErr)
+	}
+}
+
+// processInSavepoint processes a record in a savepoint of tx, the writes of a failing row are
+// rolled back and the batch goes on
+func (p *Pipeline) processInSavepoint(ctx context.Context, tx pgx.Tx, record Record, progress *Progress, after *[]afterHook) (*RowError, error) {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	if rowErr := p.process(ctx, savepoint, record, progress, after); rowErr != nil {
+		if err := savepoint.Rollback(ctx); err != nil {
+			return nil, fmt.Errorf("failed to roll back savepoint: %w", err)
+		}
+		return rowErr, nil
+	}
+
+	if err := savepoint.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil, nil
+}
+
+// process maps, validates and, unless tx is nil, writes a single record on tx. The after hooks of
+// a written record are added to after.
+func (p *Pipeline) process(ctx context.Context, tx pgx.Tx, record Record, progress *Progress, after *[]afterHook) *RowError {
+	mapped, rowErr := p.mapRecord(ctx, record)
+	if rowErr != nil {
+		return rowErr
+	}
+
+	queries, adapter := p.queries, p.adapter
+	if tx != nil {
+		queries, adapter = p.queries.WithTx(tx), p.adapter.WithTx(tx)
+	}
+
+	entity, err := queries.GetEntityByPath(ctx, db.GetEntityByPathParams{OPath: mapped.path, OKey: mapped.key})
+	exists := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return mapped.failure(err)
+	}
+
+	if exists && entity.EntityClass != p.definition.ID {
+		return mapped.failure(fmt.Errorf("%s is a %s entity", mapped.path, entity.EntityClass))
+	}
+
+	// Like the API, an import does not write over the edit lock of another user
+	if exists {
+		if err := locks.Check(ctx, queries, entity.ID, ctxUtil.GetUserID(ctx)); err != nil {
+			return mapped.failure(err)
+		}
+	}
+
+	// A new entity goes under its parent, the path of the row must match the path derived from it
+	if !exists {
+		var parentID pgtype.UUID
+		if mapped.parentID != nil {
+			parentID = *mapped.parentID
+		}
+		if parentID, mapped.path, err = tree.Locate(ctx, queries, parentID, mapped.key, mapped.path); err != nil {
+			return mapped.failure(err)
+		}
+		mapped.parentID = &parentID
+	}
+
+	patch := exists && entity.HasData && !p.fullMapping
+
+	// Like the API the before hooks see the data before it is validated and may change it, a hook
+	// that fails vetoes the row. Dry runs write nothing and run no hooks.
+	var created *hooks.EntityCreatePayload
+	var updated *hooks.EntityUpdatePayload
+	if tx != nil {
+		userID := ctxUtil.GetUserID(ctx)
+		if exists {
+			updated = &hooks.EntityUpdatePayload{
+				Class:   entity.EntityClass,
+				Entity:  entity,
+				Data:    mapped.data,
+				UserID:  userID,
+				Partial: patch,
+				Draft:   entity.Published,
+			}
+			err = p.trigger(ctx, hooks.HookBeforeEntityUpdate, updated)
+			mapped.data = updated.Data
+		} else {
+			params := p.createParams(mapped, userID)
+			created = &hooks.EntityCreatePayload{
+				Class: params.EntityClass,
+				Entity: db.Entity{
+					EntityClass: params.EntityClass,
+					ParentID:    params.ParentID,
+					OKey:        params.OKey,
+					OPath:       params.OPath,
+					OType:       params.OType,
+					Published:   params.Published,
+					CreatedBy:   params.CreatedBy,
+					UpdatedBy:   params.UpdatedBy,
+				},
+				Data:   mapped.data,
+				UserID: userID,
+			}
+			err = p.trigger(ctx, hooks.HookBeforeEntityCreate, created)
+			mapped.data = created.Data
+		}
+		if err != nil {
+			return mapped.failure(err)
+		}
+	}
+
+	if patch {
+		err = p.adapter.ValidatePatch(mapped.data)
+	} else {
+		err = p.adapter.Validate(mapped.data)
+	}
+	if err != nil {
+		return mapped.failure(err)
+	}
+
+	if tx != nil {
+		if exists {
+			err = p.update(ctx, tx, queries, adapter, mapped, entity, patch, updated)
+		} else {
+			err = p.create(ctx, tx, queries, adapter, mapped, created)
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = fmt.Errorf("entity was modified by another request")
+		}
+		if err != nil {
+			return mapped.failure(err)
+		}
+		*after = append(*after, mapped.after...)
+	}
+
+	progress.Processed++
+	if exists {
+		progress.Updated++
+	} else {
+		progress.Created++
+	}
+
+	return nil
+}
+
+// createParams is the metadata of the entity a row creates
+func (p *Pipeline) createParams(mapped *row, userID pgtype.UUID) db.CreateEntityParams {
+	params := db.CreateEntityParams{
+		EntityClass: p.definition.ID,
+		OKey:        mapped.key,
+		OPath:       mapped.path,
+		OType:       "object",
+		CreatedBy:   userID,
+		UpdatedBy:   userID,
+	}
+	if mapped.parentID != nil {
+		params.ParentID = *mapped.parentID
+	}
+	if mapped.published != nil {
+		params.Published = *mapped.published
+	}
+	return params
+}
+
+// create creates the entity of a row with its data, payload is the create hook payload of the row
+func (p *Pipeline) create(ctx context.Context, tx pgx.Tx, queries *db.Queries, adapter adapters.EntityAdapter, mapped *row, payload *hooks.EntityCreatePayload) error {
+	userID := ctxUtil.GetUserID(ctx)
+
+	params := p.createParams(mapped, userID)
+	if err := tree.CheckChild(ctx, queries, params.ParentID, params.EntityClass); err != nil {
+		return err
+	}
+
+	entity, err := queries.CreateEntity(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to create entity: %w", err)
+	}
+
+	entity, data, err := writes.Data(ctx, tx, queries, adapter, entity, entity.Version, mapped.data, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := p.record(ctx, queries, adapter, audit.ActionCreate, nil, entity, data); err != nil {
+		return err
+	}
+
+	payload.Entity = entity
+	mapped.after = append(mapped.after, afterHook{name: hooks.HookAfterEntityCreate, payload: payload})
+	return nil
+}
+
+// update saves the data of a row on an existing entity like a save through the API, a published
+// entity keeps serving its published data and the row becomes its draft. A changed parent moves
+// the entity and a changed publication goes through the publishing helpers. payload is the update
+// hook payload of the row.
+func (p *Pipeline) update(ctx context.Context, tx pgx.Tx, queries *db.Queries, adapter adapters.EntityAdapter, mapped *row, entity db.Entity, patch bool, payload *hooks.EntityUpdatePayload) error {
+	userID := ctxUtil.GetUserID(ctx)
+
+	var err error
+	if entity.Published {
+		entity, err = p.saveDraft(ctx, queries, adapter, mapped, entity, patch)
+	} else {
+		entity, err = p.writeData(ctx, tx, queries, adapter, mapped, entity, patch)
+	}
+	if err != nil {
+		return err
+	}
+
+	payload.Entity = entity
+	mapped.after = append(mapped.after, afterHook{name: hooks.HookAfterEntityUpdate, payload: payload})
+
+	if mapped.parentID != nil && *mapped.parentID != entity.ParentID {
+		entity, _, err = tree.Move(ctx, queries, entity, *mapped.parentID, entity.OKey, entity.Version, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if mapped.published != nil && *mapped.published != entity.Published {
+		return p.setPublished(ctx, tx, queries, adapter, mapped, entity, *mapped.published)
+	}
+	return nil
+}
+
+// saveDraft stores the data of a row as the draft of a published entity, a partial mapping is
+// applied to its pending data
+func (p *Pipeline) saveDraft(ctx context.Context, queries *db.Queries, adapter adapters.EntityAdapter, mapped *row, entity db.Entity, patch bool) (db.Entity, error) {
+	data := mapped.data
+	if patch {
+		pending, err := publishing.PendingData(ctx, queries, adapter, entity)
+		if err != nil {
+			return entity, err
+		}
+		for key, value := range mapped.data {
+			pending[key] = value
+		}
+		data = pending
+	}
+
+	// The draft claims the next version, a concurrent write since the row was read fails it
+	entity, _, err := publishing.SaveAuditedDraft(ctx, queries, adapter, entity, entity.Version, data, ctxUtil.GetUserID(ctx))
+	return entity, err
+}
+
+// writeData writes the data of a row to an unpublished entity
+func (p *Pipeline) writeData(ctx context.Context, tx pgx.Tx, queries *db.Queries, adapter adapters.EntityAdapter, mapped *row, entity db.Entity, patch bool) (db.Entity, error) {
+	before, err := audit.ReadEntityState(ctx, adapter, entity)
+	if err != nil {
+		return entity, err
+	}
+
+	write := writes.Data
+	if patch {
+		write = writes.Patch
+	}
+	entity, data, err := write(ctx, tx, queries, adapter, entity, entity.Version, mapped.data, ctxUtil.GetUserID(ctx))
+	if err != nil {
+		return entity, err
+	}
+
+	return entity, p.record(ctx, queries, adapter, audit.ActionUpdate, before, entity, data)
+}
+
+// setPublished publishes or unpublishes an entity like the publish endpoints, publishing writes
+// its pending draft
+func (p *Pipeline) setPublished(ctx context.Context, tx pgx.Tx, queries *db.Queries, adapter adapters.EntityAdapter, mapped *row, entity db.Entity, published bool) error {
+	userID := ctxUtil.GetUserID(ctx)
+
+	beforeHook, afterName := hooks.HookBeforeEntityUnpublish, hooks.HookAfterEntityUnpublish
+	if published {
+		beforeHook, afterName = hooks.HookBeforeEntityPublish, hooks.HookAfterEntityPublish
+	}
+
+	payload := &hooks.EntityPublishPayload{Class: entity.EntityClass, Entity: entity, UserID: userID}
+	if err := p.trigger(ctx, beforeHook, payload); err != nil {
+		return err
+	}
+
+	before, err := audit.ReadEntityState(ctx, adapter, entity)
+	if err != nil {
+		return err
+	}
+
+	var data interface{}
+	action := audit.ActionUnpublish
+	if published {
+		action = audit.ActionPublish
+		entity, data, err = publishing.Publish(ctx, tx, queries, adapter, entity, entity.Version, userID)
+	} else {
+		entity, err = publishing.Unpublish(ctx, queries, entity, entity.Version, userID)
+		if err == nil && entity.HasData {
+			data, err = adapter.Read(ctx, entity.ID)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := p.record(ctx, queries, adapter, action, before, entity, data); err != nil {
+		return err
+	}
+
+	payload.Entity = entity
+	mapped.after = append(mapped.after, afterHook{name: afterName, payload: payload})
+	return nil
+}
+
+// trigger runs the callbacks of a hook
+func (p *Pipeline) trigger(ctx context.Context, name string, payload interface{}) error {
+	if p.hooks == nil {
+		return nil
+	}
+	return p.hooks.Trigger(ctx, name, payload)
+}
+
+// record writes the version and the audit entry of a change to the live data of an entity
+func (p *Pipeline) record(ctx context.Context, queries *db.Queries, adapter adapters.EntityAdapter, action string, before audit.State, entity db.Entity, data interface{}) error {
+	return writes.Record(ctx, queries, adapter, action, before, entity, data, ctxUtil.GetUserID(ctx), p.policy)
+}
+
+// mapRecord applies the mapping and transforms, field errors are collected for the whole row
+func (p *Pipeline) mapRecord(ctx context.Context, record Record) (*row, *RowError) {
+	mapped := &row{number: record.Row, data: make(map[string]interface{})}
+	fieldErrs := adapters.ValidationError{}
+
+	for _, field := range p.mapping.Fields {
+		var value interface{}
+		if field.Source != "" {
+			value = record.Values[field.Source]
+		}
+
+		value, err := p.applyTransforms(ctx, value, field.Transforms)
+		if err != nil {
+			fieldErrs.Add(field.Target, err.Error())
+			continue
+		}
+
+		switch field.Target {
+		case TargetKey:
+			mapped.key = stringify(value)
+			if value == nil || mapped.key == "" {
+				fieldErrs.Add(field.Target, "is required")
+			} else if !keyPattern.MatchString(mapped.key) {
+				fieldErrs.Add(field.Target, "may only contain letters, digits, _ and -")
+			}
+		case TargetPath:
+			if value == nil || stringify(value) == "" {
+				fieldErrs.Add(field.Target, "is required")
+				continue
+			}
+			// The path of the entity itself, its last segment is the key
+			path, _, err := tree.SplitPath(stringify(value))
+			if err != nil {
+				fieldErrs.Add(field.Target, err.Error())
+				continue
+			}
+			mapped.path = path
+		case TargetParentID:
+			if isEmpty(value) {
+				continue
+			}
+			var parentID pgtype.UUID
+			if err := parentID.Scan(stringify(value)); err != nil {
+				fieldErrs.Add(field.Target, "must be a UUID")
+				continue
+			}
+			mapped.parentID = &parentID
+		case TargetPublished:
+			if isEmpty(value) {
+				continue
+			}
+			published, err := strconv.ParseBool(stringify(value))
+			if err != nil {
+				fieldErrs.Add(field.Target, "must be true or false")
+				continue
+			}
+			mapped.published = &published
+		default:
+			mapped.data[field.Target] = coerce(value, p.kinds[field.Target])
+		}
+	}
+
+	if len(fieldErrs.Fields) > 0 {
+		return nil, &RowError{Row: record.Row, Key: mapped.key, Errors: fieldErrs.Fields}
+	}
+
+	return mapped, nil
+}
+
+// failure turns an error of a mapped row into its report entry
+func (r *row) failure(err error) *RowError {
+	rowErr := &RowError{Row: r.number, Key: r.key}
+	if validationErr, ok := adapters.AsValidationError(err); ok {
+		rowErr.Errors = validationErr.Fields
+	} else {
+		rowErr.Message = err.Error()
+	}
+	return rowErr
+}
+
+// checkHeader fails when a mapped source column is missing from the file
+func checkHeader(header, sources []string) error {
+	columns := make(map[string]bool, len(header))
+	for _, column := range header {
+		columns[column] = true
+	}
+
+	for _, source := range sources {
+		if !columns[source] {
+			return fmt.Errorf("source column %q is not in the file header", source)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/oriiyx/fritz/app/core/services/entities/versions"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions/definitionstest"
+	"time"
+)
+
+func testMapping() Mapping {
+	return Mapping{Fields: []FieldMapping{
+		{Source: "sku", Target: TargetKey, Transforms: []Transform{{Type: TransformTrim}, {Type: TransformLower}}},
+		{Source: "path", Target: TargetPath, Transforms: []Transform{{Type: TransformLower}}},
+		{Source: "name", Target: "title", Transforms: []Transform{{Type: TransformSplit, Separator: "|", Index: -1}}},
+		{Source: "stock", Target: "stock"},
+		{Source: "active", Target: TargetPublished},
+	}}
+}
+
+func TestMappingValidate(t *testing.T) {
+	def := definitionstest.Product()
+
+	mapping := testMapping()
+	if err := mapping.Validate(def); err != nil {
+		t.Fatalf("expected mapping to be valid, got %v", err)
+	}
+
+	tests := map[string]Mapping{
+		"unknown target":  {Fields: append(testMapping().Fields, FieldMapping{Source: "x", Target: "colour"})},
+		"duplicate":       {Fields: append(testMapping().Fields, FieldMapping{Source: "x", Target: "title"})},
+		"missing o_path":  {Fields: testMapping().Fields[:1]},
+		"no source":       {Fields: append(testMapping().Fields, FieldMapping{Target: TargetParentID})},
+		"split separator": {Fields: append(testMapping().Fields, FieldMapping{Source: "x", Target: TargetParentID, Transforms: []Transform{{Type: TransformSplit}}})},
+		"unknown type":    {Fields: append(testMapping().Fields, FieldMapping{Source: "x", Target: TargetParentID, Transforms: []Transform{{Type: "reverse"}}})},
+	}
+	for name, mapping := range tests {
+		if err := mapping.Validate(def); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMapRecord(t *testing.T) {
+	p, err := New(nil, nil, nil, definitionstest.Product(), nil, testMapping(), versions.Policy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewReader(FormatCSV, strings.NewReader("\ufeffsku,path,name,stock,active\n  SKU-1 ,/Products/SKU-1/,Acme | Anvil,12,true\nbad key,/products/bad key,Rope,many,maybe\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapped, rowErr := p.mapRecord(context.Background(), record)
+	if rowErr != nil {
+		t.Fatalf("expected row to map, got %+v", rowErr)
+	}
+	if mapped.key != "sku-1" || mapped.path != "/products/sku-1" {
+		t.Errorf("unexpected key and path %q %q", mapped.key, mapped.path)
+	}
+	if mapped.published == nil || !*mapped.published {
+		t.Errorf("expected published to be true")
+	}
+	if mapped.data["title"] != "Anvil" || mapped.data["stock"] != json.Number("12") {
+		t.Errorf("unexpected data %v", mapped.data)
+	}
+
+	// Invalid keys and entity fields are reported per target, component values are left
+	// to the adapter
+	record, err = reader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, rowErr = p.mapRecord(context.Background(), record)
+	if rowErr == nil || rowErr.Row != 2 {
+		t.Fatalf("expected row 2 to fail, got %+v", rowErr)
+	}
+	if len(rowErr.Errors[TargetKey]) != 1 || len(rowErr.Errors[TargetPublished]) != 1 || len(rowErr.Errors) != 2 {
+		t.Errorf("unexpected row errors %v", rowErr.Errors)
+	}
+
+	if _, err := reader.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
+
+func TestJSONLReader(t *testing.T) {
+	input := "{\"sku\": \"a\", \"stock\": 3}\n\n[1, 2]\n{\"sku\": \"b\"}\n"
+
+	total, err := Count(FormatJSONL, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 {
+		t.Errorf("expected 3 records, got %d", total)
+	}
+
+	reader, err := NewReader(FormatJSONL, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := reader.Next()
+	if err != nil || record.Row != 1 || record.Values["stock"] != json.Number("3") {
+		t.Fatalf("unexpected first record %+v, %v", record, err)
+	}
+
+	// Blank lines are skipped and a malformed line fails its row only
+	var readErr *ReadError
+	if _, err := reader.Next(); !errors.As(err, &readErr) || readErr.Row != 2 {
+		t.Fatalf("expected a read error on row 2, got %v", err)
+	}
+
+	record, err = reader.Next()
+	if err != nil || record.Row != 3 || record.Values["sku"] != "b" {
+		t.Fatalf("unexpected last record %+v, %v", record, err)
+	}
+}
+
+func TestCoerce(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		kind     definitions.ValueKind
+		expected interface{}
+	}{
+		{"", definitions.KindText, nil},
+		{json.Number("4"), definitions.KindText, "4"},
+		{" 4.5 ", definitions.KindFloat, json.Number("4.5")},
+		{"four", definitions.KindInteger, "four"},
+		{"TRUE", definitions.KindBoolean, true},
+		{"yes", definitions.KindBoolean, "yes"},
+	}
+
+	for _, test := range tests {
+		if actual := coerce(test.value, test.kind); actual != test.expected {
+			t.Errorf("coerce(%#v, %s): expected %#v, got %#v", test.value, test.kind, test.expected, actual)
+		}
+	}
+}
+
+func TestBackgroundShutdown(t *testing.T) {
+	type key struct{}
+	background := NewBackground()
+
+	reqCtx, cancelReq := context.WithCancel(context.WithValue(context.Background(), key{}, "user"))
+	finished := make(chan interface{}, 1)
+	background.Go(reqCtx, func(ctx context.Context) {
+		<-ctx.Done()
+		finished <- ctx.Value(key{})
+	})
+
+	// The run outlives its request
+	cancelReq()
+	select {
+	case <-finished:
+		t.Fatal("expected the run to outlive its request")
+	default:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := background.Shutdown(ctx); err != nil {
+		t.Fatalf("expected the run to stop, got %v", err)
+	}
+	if value := <-finished; value != "user" {
+		t.Errorf("expected the request values to be kept, got %v", value)
+	}
+
+	ran := false
+	background.Go(context.Background(), func(ctx context.Context) {
+		ran = ctx.Err() != nil
+	})
+	if !ran {
+		t.Error("expected a run after shutdown to be called with a cancelled context")
+	}
+}
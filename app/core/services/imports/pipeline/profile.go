@@ -0,0 +1,38 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/oriiyx/fritz/app/core/kernel"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/versions"
+	"github.com/oriiyx/fritz/app/core/services/objects/definition_builder"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// FromProfile prepares the pipeline of a stored profile, errors mean the profile no longer fits
+// its entity class
+func FromProfile(conn Beginner, queries *db.Queries, hooks *kernel.Hooks, eb *definition_builder.Builder, profile db.ImportProfile, policy versions.Policy) (*Pipeline, error) {
+	var mapping Mapping
+	if err := json.Unmarshal(profile.Mapping, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to decode mapping: %w", err)
+	}
+
+	return ForClass(conn, queries, hooks, eb, profile.EntityClass, mapping, policy)
+}
+
+// ForClass prepares a pipeline for an entity class and mapping
+func ForClass(conn Beginner, queries *db.Queries, hooks *kernel.Hooks, eb *definition_builder.Builder, classID string, mapping Mapping, policy versions.Policy) (*Pipeline, error) {
+	definition, err := eb.LoadDefinitionByID(classID)
+	if err != nil {
+		return nil, fmt.Errorf("unknown entity class %q", classID)
+	}
+
+	adapter, err := adapters.Get(classID)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(conn, queries, hooks, definition, adapter, mapping, policy)
+}
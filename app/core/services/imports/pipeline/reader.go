@@ -0,0 +1,165 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxLineSize bounds a single JSON Lines record
+const maxLineSize = 16 * 1024 * 1024
+
+// Record is one source row, Row counts data rows from 1
+type Record struct {
+	Row    int
+	Values map[string]interface{}
+}
+
+// Reader yields the records of a source file and returns io.EOF after the last one
+type Reader interface {
+	Next() (Record, error)
+}
+
+// ReadError is a single malformed record, reading continues with the next one
+type ReadError struct {
+	Row int
+	Err error
+}
+
+func (e *ReadError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
+
+// NewReader returns a reader for the given format
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatJSONL:
+		return newJSONLReader(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+}
+
+// Count reads the whole source and returns the number of records
+func Count(format Format, r io.Reader) (int, error) {
+	reader, err := NewReader(format, r)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for {
+		_, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		var readErr *ReadError
+		if err != nil && !errors.As(err, &readErr) {
+			return count, err
+		}
+		count++
+	}
+}
+
+type csvReader struct {
+	reader *csv.Reader
+	header []string
+	row    int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("csv file is empty, a header row is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	// Spreadsheet exports often start with a byte order mark
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	return &csvReader{reader: reader, header: header}, nil
+}
+
+// Header returns the column names of the file
+func (c *csvReader) Header() []string {
+	return c.header
+}
+
+func (c *csvReader) Next() (Record, error) {
+	fields, err := c.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return Record{}, io.EOF
+	}
+	c.row++
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return Record{}, &ReadError{Row: c.row, Err: parseErr.Err}
+	}
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to read csv row %d: %w", c.row, err)
+	}
+
+	values := make(map[string]interface{}, len(c.header))
+	for i, column := range c.header {
+		if i < len(fields) {
+			values[column] = fields[i]
+		}
+	}
+
+	return Record{Row: c.row, Values: values}, nil
+}
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	return &jsonlReader{scanner: scanner}
+}
+
+func (j *jsonlReader) Next() (Record, error) {
+	for j.scanner.Scan() {
+		line := bytes.TrimSpace(j.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		j.row++
+
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+
+		var values map[string]interface{}
+		if err := decoder.Decode(&values); err != nil {
+			return Record{}, &ReadError{Row: j.row, Err: fmt.Errorf("not a JSON object: %w", err)}
+		}
+
+		return Record{Row: j.row, Values: values}, nil
+	}
+
+	if err := j.scanner.Err(); err != nil {
+		return Record{}, fmt.Errorf("failed to read json lines: %w", err)
+	}
+	return Record{}, io.EOF
+}
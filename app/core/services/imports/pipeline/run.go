@@ -0,0 +1,105 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// Import run statuses as stored in import_runs.status
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Execute runs the pipeline over the file at path for a stored import run. Progress is written to
+// the run after every batch and the run is finished as completed or failed.
+func (p *Pipeline) Execute(ctx context.Context, run db.ImportRun, format Format, path string, opts Options) (db.ImportRun, error) {
+	if err := p.queries.StartImportRun(ctx, run.ID); err != nil {
+		return run, fmt.Errorf("failed to start import run: %w", err)
+	}
+
+	progress, err := p.executeFile(ctx, run.ID, format, path, opts)
+
+	// Record the final tally even when the run stopped early
+	if progressErr := p.saveProgress(ctx, run.ID, progress); progressErr != nil && err == nil {
+		err = progressErr
+	}
+
+	finish := db.FinishImportRunParams{ID: run.ID, Status: StatusCompleted}
+	if err != nil {
+		finish.Status = StatusFailed
+		finish.Error = pgtype.Text{String: err.Error(), Valid: true}
+	}
+
+	// The run outcome is recorded even when ctx was cancelled
+	finished, finishErr := p.queries.FinishImportRun(context.WithoutCancel(ctx), finish)
+	if finishErr != nil {
+		return run, fmt.Errorf("failed to finish import run: %w", finishErr)
+	}
+
+	return finished, err
+}
+
+func (p *Pipeline) executeFile(ctx context.Context, runID pgtype.UUID, format Format, path string, opts Options) (Progress, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Progress{}, fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer file.Close()
+
+	// Count first so progress can be reported against a known total
+	opts.Total, err = Count(format, file)
+	if err != nil {
+		return Progress{}, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return Progress{}, fmt.Errorf("failed to rewind import file: %w", err)
+	}
+
+	onProgress := opts.OnProgress
+	opts.OnProgress = func(progress Progress) error {
+		if err := p.saveProgress(ctx, runID, progress); err != nil {
+			return err
+		}
+		if onProgress != nil {
+			return onProgress(progress)
+		}
+		return nil
+	}
+
+	return p.Run(ctx, format, file, opts)
+}
+
+func (p *Pipeline) saveProgress(ctx context.Context, runID pgtype.UUID, progress Progress) error {
+	if progress.Errors == nil {
+		progress.Errors = make([]RowError, 0)
+	}
+
+	rowErrors, err := json.Marshal(progress.Errors)
+	if err != nil {
+		return fmt.Errorf("failed to encode row errors: %w", err)
+	}
+
+	err = p.queries.UpdateImportRunProgress(context.WithoutCancel(ctx), db.UpdateImportRunProgressParams{
+		ID:            runID,
+		TotalRows:     int32(progress.Total),
+		ProcessedRows: int32(progress.Processed),
+		CreatedRows:   int32(progress.Created),
+		UpdatedRows:   int32(progress.Updated),
+		FailedRows:    int32(progress.Failed),
+		RowErrors:     rowErrors,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save import progress: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// lookupKey identifies a cached lookup result
+type lookupKey struct {
+	path string
+	key  string
+}
+
+// applyTransforms runs the transforms of a mapping in order, nil means the value is missing
+func (p *Pipeline) applyTransforms(ctx context.Context, value interface{}, transforms []Transform) (interface{}, error) {
+	for _, transform := range transforms {
+		if transform.Type == TransformDefault {
+			if isEmpty(value) {
+				value = transform.Value
+			}
+			continue
+		}
+
+		if value == nil {
+			continue
+		}
+
+		s := stringify(value)
+		switch transform.Type {
+		case TransformTrim:
+			value = strings.TrimSpace(s)
+		case TransformLower:
+			value = strings.ToLower(s)
+		case TransformUpper:
+			value = strings.ToUpper(s)
+		case TransformSplit:
+			parts := strings.Split(s, transform.Separator)
+			index := transform.Index
+			if index < 0 {
+				index += len(parts)
+			}
+			if index < 0 || index >= len(parts) {
+				value = nil
+				continue
+			}
+			value = strings.TrimSpace(parts[index])
+		case TransformLookup:
+			if s == "" {
+				value = nil
+				continue
+			}
+			id, err := p.lookup(ctx, transform, s)
+			if err != nil {
+				return nil, err
+			}
+			value = id
+		}
+	}
+
+	return value, nil
+}
+
+// lookup resolves an entity key under the transform path to the entity ID
+func (p *Pipeline) lookup(ctx context.Context, transform Transform, key string) (string, error) {
+	cacheKey := lookupKey{path: transform.Path, key: key}
+	if id, ok := p.lookups[cacheKey]; ok {
+		return id, nil
+	}
+
+	entity, err := p.queries.GetEntityByPath(ctx, db.GetEntityByPathParams{OPath: transform.Path, OKey: key})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("no entity with key %q under %s", key, transform.Path)
+		}
+		return "", err
+	}
+	if transform.Class != "" && entity.EntityClass != transform.Class {
+		return "", fmt.Errorf("entity %q under %s is a %s, expected %s", key, transform.Path, entity.EntityClass, transform.Class)
+	}
+
+	id := entity.ID.String()
+	p.lookups[cacheKey] = id
+	return id, nil
+}
+
+// coerce converts source values into what the adapter payload expects for the column kind.
+// CSV cells are always strings and empty cells mean null. Values that do not convert are passed
+// through so the adapter reports them.
+func coerce(value interface{}, kind definitions.ValueKind) interface{} {
+	if s, ok := value.(string); ok && s == "" {
+		return nil
+	}
+
+	switch kind {
+	case definitions.KindText, definitions.KindDate, definitions.KindTimestamp:
+		switch v := value.(type) {
+		case json.Number:
+			return v.String()
+		case bool:
+			return strconv.FormatBool(v)
+		}
+	case definitions.KindInteger, definitions.KindFloat:
+		if s, ok := value.(string); ok {
+			s = strings.TrimSpace(s)
+			if _, err := strconv.ParseFloat(s, 64); err == nil {
+				return json.Number(s)
+			}
+		}
+	case definitions.KindBoolean:
+		if s, ok := value.(string); ok {
+			if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+				return b
+			}
+		}
+	}
+
+	return value
+}
+
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	s, ok := value.(string)
+	return ok && s == ""
+}
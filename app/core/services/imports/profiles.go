@@ -0,0 +1,190 @@
+package imports
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/services/imports/pipeline"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	validatorUtil "github.com/oriiyx/fritz/app/core/utils/validator"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+// uniqueViolation is the postgres error code of a unique constraint violation
+const uniqueViolation = "23505"
+
+// ProfileRequest creates or replaces an import profile
+type ProfileRequest struct {
+	Name        string           `json:"name" validate:"required,max=255"`
+	EntityClass string           `json:"entity_class" validate:"required,max=255"`
+	Format      pipeline.Format  `json:"format" validate:"required,oneof=csv jsonl"`
+	Mapping     pipeline.Mapping `json:"mapping"`
+}
+
+// CreateProfile is an endpoint that stores a new import profile after checking its mapping
+// against the entity class
+func (h *Handler) CreateProfile(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	req, mapping, ok := h.decodeProfileRequest(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	profile, err := h.Queries.CreateImportProfile(r.Context(), db.CreateImportProfileParams{
+		Name:        req.Name,
+		EntityClass: req.EntityClass,
+		Format:      string(req.Format),
+		Mapping:     mapping,
+	})
+	if err != nil {
+		h.writeProfileStoreError(w, reqID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(newProfileResponse(profile))
+}
+
+// UpdateProfile is an endpoint that replaces an import profile
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	existing, ok := h.loadProfile(w, r, chi.URLParam(r, ProfileIDKey))
+	if !ok {
+		return
+	}
+
+	req, mapping, ok := h.decodeProfileRequest(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	profile, err := h.Queries.UpdateImportProfile(r.Context(), db.UpdateImportProfileParams{
+		ID:          existing.ID,
+		Name:        req.Name,
+		EntityClass: req.EntityClass,
+		Format:      string(req.Format),
+		Mapping:     mapping,
+	})
+	if err != nil {
+		h.writeProfileStoreError(w, reqID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(newProfileResponse(profile))
+}
+
+// GetProfiles is an endpoint that lists all import profiles
+func (h *Handler) GetProfiles(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	profiles, err := h.Queries.ListImportProfiles(r.Context())
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to list import profiles")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	response := make([]ProfileResponse, 0, len(profiles))
+	for _, profile := range profiles {
+		response = append(response, newProfileResponse(profile))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// GetProfile is an endpoint that returns a single import profile
+func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	profile, ok := h.loadProfile(w, r, chi.URLParam(r, ProfileIDKey))
+	if !ok {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(newProfileResponse(profile))
+}
+
+// DeleteProfile is an endpoint that removes an import profile, its runs are kept
+func (h *Handler) DeleteProfile(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	profile, ok := h.loadProfile(w, r, chi.URLParam(r, ProfileIDKey))
+	if !ok {
+		return
+	}
+
+	if err := h.Queries.DeleteImportProfile(r.Context(), profile.ID); err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("profile_id", profile.ID.String()).Msg("Failed to delete import profile")
+		errhandler.ServerError(w, errhandler.RespDBDataRemoveFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeProfileRequest reads and validates a profile, the mapping is checked against the
+// definition and adapter of the entity class
+func (h *Handler) decodeProfileRequest(w http.ResponseWriter, r *http.Request, reqID string) (ProfileRequest, []byte, bool) {
+	var req ProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to decode request")
+		errhandler.BadRequest(w, errhandler.RespInvalidRequestBody)
+		return req, nil, false
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to marshal validation errors")
+			errhandler.ServerError(w, errhandler.RespJSONEncodeFailure)
+			return req, nil, false
+		}
+		errhandler.ValidationErrors(w, respBody)
+		return req, nil, false
+	}
+
+	if _, err := pipeline.ForClass(h.DB, h.Queries, h.Hooks, h.entityBuilder, req.EntityClass, req.Mapping, h.versionPolicy); err != nil {
+		writeError(w, err)
+		return req, nil, false
+	}
+
+	mapping, err := json.Marshal(req.Mapping)
+	if err != nil {
+		h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to marshal import mapping")
+		errhandler.ServerError(w, errhandler.RespJSONEncodeFailure)
+		return req, nil, false
+	}
+
+	return req, mapping, true
+}
+
+func (h *Handler) writeProfileStoreError(w http.ResponseWriter, reqID string, err error) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		errhandler.BadRequest(w, []byte(`{"error": "an import profile with this name already exists"}`))
+		return
+	}
+
+	h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to store import profile")
+	errhandler.ServerError(w, errhandler.RespDBDataInsertFailure)
+}
+
+// parseUUID scans a URL parameter, it writes a bad request and returns false when it is not a UUID
+func parseUUID(w http.ResponseWriter, value, message string) (pgtype.UUID, bool) {
+	var id pgtype.UUID
+	if err := id.Scan(value); err != nil {
+		respBody, _ := json.Marshal(errhandler.Error{Error: message})
+		errhandler.BadRequest(w, respBody)
+		return id, false
+	}
+	return id, true
+}
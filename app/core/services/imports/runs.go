@@ -0,0 +1,215 @@
+package imports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	"github.com/oriiyx/fritz/app/core/services/imports/pipeline"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+const (
+	DefaultRunsLimit = 50
+	MaxRunsLimit     = 500
+)
+
+// StartRun is an endpoint that imports the request body with a profile. The body is the raw CSV
+// or JSON Lines file. With dry_run=true every row is validated but nothing is written and the
+// finished run is returned; otherwise the run is processed in the background and returned with
+// 202, poll GetRun for its progress.
+func (h *Handler) StartRun(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	profile, ok := h.loadProfile(w, r, chi.URLParam(r, ProfileIDKey))
+	if !ok {
+		return
+	}
+
+	opts := pipeline.Options{}
+	if dryRun := r.URL.Query().Get("dry_run"); dryRun != "" {
+		b, err := strconv.ParseBool(dryRun)
+		if err != nil {
+			errhandler.BadRequest(w, []byte(`{"error": "dry_run must be true or false"}`))
+			return
+		}
+		opts.DryRun = b
+	}
+	if batchSize := r.URL.Query().Get("batch_size"); batchSize != "" {
+		n, err := strconv.Atoi(batchSize)
+		if err != nil || n <= 0 {
+			errhandler.BadRequest(w, []byte(`{"error": "batch_size must be a positive integer"}`))
+			return
+		}
+		opts.BatchSize = n
+	}
+
+	p, err := pipeline.FromProfile(h.DB, h.Queries, h.Hooks, h.entityBuilder, profile, h.versionPolicy)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	run, err := h.Queries.CreateImportRun(r.Context(), db.CreateImportRunParams{
+		ProfileID:   profile.ID,
+		EntityClass: profile.EntityClass,
+		DryRun:      opts.DryRun,
+	})
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to create import run")
+		errhandler.ServerError(w, errhandler.RespDBDataInsertFailure)
+		return
+	}
+
+	format := pipeline.Format(profile.Format)
+	path, err := storeUpload(http.MaxBytesReader(w, r.Body, MaxUploadSize), run.ID, format)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("run_id", run.ID.String()).Msg("Failed to store import upload")
+		_, _ = h.Queries.FinishImportRun(r.Context(), db.FinishImportRunParams{
+			ID:     run.ID,
+			Status: pipeline.StatusFailed,
+			Error:  pgtype.Text{String: "failed to store upload", Valid: true},
+		})
+
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": "upload exceeds %d bytes"}`, MaxUploadSize)))
+			return
+		}
+		errhandler.ServerError(w, errhandler.RespProcessFailure)
+		return
+	}
+
+	if opts.DryRun {
+		defer os.Remove(path)
+
+		run, err = p.Execute(r.Context(), run, format, path, opts)
+		if err != nil {
+			// The failure is recorded on the run itself
+			h.Logger.Warn().Err(err).Str(l.KeyReqID, reqID).Str("run_id", run.ID.String()).Msg("Import dry run failed")
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(newRunResponse(run))
+		return
+	}
+
+	logger := h.Logger.With().Str("run_id", run.ID.String()).Str("class_id", profile.EntityClass).Logger()
+	opts.OnHookError = func(name string, err error) {
+		logger.Error().Err(err).Str("hook", name).Msg("Hook failed after an import batch")
+	}
+
+	// The run outlives the request but keeps its user and request ID for the written entities and
+	// their audit entries, a shutdown stops it and it is recorded as failed
+	h.background.Go(r.Context(), func(ctx context.Context) {
+		defer os.Remove(path)
+
+		finished, err := p.Execute(ctx, run, format, path, opts)
+		if err != nil {
+			logger.Error().Err(err).Msg("Import run failed")
+			return
+		}
+		logger.Info().
+			Int32("created", finished.CreatedRows).
+			Int32("updated", finished.UpdatedRows).
+			Int32("failed", finished.FailedRows).
+			Msg("Import run completed")
+	})
+
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(newRunResponse(run))
+}
+
+// GetRun is an endpoint that returns an import run with its progress and row error report
+func (h *Handler) GetRun(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	runID, ok := parseUUID(w, chi.URLParam(r, RunIDKey), "invalid run_id")
+	if !ok {
+		return
+	}
+
+	run, err := h.Queries.GetImportRun(r.Context(), runID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "import run not found"}`))
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to load import run")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(newRunResponse(run))
+}
+
+// GetRuns is an endpoint that lists the most recent import runs, optionally of one profile
+func (h *Handler) GetRuns(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	params := db.ListImportRunsParams{RowLimit: DefaultRunsLimit}
+	if profileID := r.URL.Query().Get(ProfileIDKey); profileID != "" {
+		id, ok := parseUUID(w, profileID, "invalid profile_id")
+		if !ok {
+			return
+		}
+		params.ProfileID = id
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > MaxRunsLimit {
+			errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": "limit must be between 1 and %d"}`, MaxRunsLimit)))
+			return
+		}
+		params.RowLimit = int32(n)
+	}
+
+	runs, err := h.Queries.ListImportRuns(r.Context(), params)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to list import runs")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	response := make([]RunResponse, 0, len(runs))
+	for _, run := range runs {
+		response = append(response, newRunResponse(run))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// storeUpload writes the upload to the imports directory, named after the run
+func storeUpload(body io.Reader, runID pgtype.UUID, format pipeline.Format) (string, error) {
+	if err := os.MkdirAll(ImportsFilePathTemplate, 0750); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(ImportsFilePathTemplate, fmt.Sprintf("%s.%s", runID.String(), format))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(file, body); err != nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+		return "", err
+	}
+
+	return path, file.Close()
+}
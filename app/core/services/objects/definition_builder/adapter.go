@@ -8,7 +8,7 @@ import (
 	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
 )
 
-func (e *Builder) genAdapterCode(d *definitions.EntityDefinition) string {
+func (e *Builder) genAdapterCode(d *definitions.EntityDefinition, schemaHash string) string {
 	var code strings.Builder
 
 	code.WriteString("// Code generated by fritz. DO NOT EDIT.\n")
@@ -22,9 +22,14 @@ func (e *Builder) genAdapterCode(d *definitions.EntityDefinition) string {
 		// "encoding/json": false,
 	}
 
+	imports["github.com/jackc/pgx/v5"] = true
 	imports["github.com/jackc/pgx/v5/pgtype"] = true
 	imports["github.com/oriiyx/fritz/database/generated"] = true
 
+	if hasRegexValidation(d) {
+		imports["regexp"] = true
+	}
+
 	code.WriteString("import (\n")
 	for imp := range imports {
 		if imp == "github.com/oriiyx/fritz/database/generated" {
@@ -45,6 +50,22 @@ func (e *Builder) genAdapterCode(d *definitions.EntityDefinition) string {
 	code.WriteString(fmt.Sprintf("\treturn &%sAdapter{queries: queries}\n", entityName))
 	code.WriteString("}\n\n")
 
+	code.WriteString(fmt.Sprintf("func (a *%sAdapter) WithTx(tx pgx.Tx) EntityAdapter {\n", entityName))
+	code.WriteString(fmt.Sprintf("\treturn &%sAdapter{queries: a.queries.WithTx(tx)}\n", entityName))
+	code.WriteString("}\n\n")
+
+	// Generate schema fingerprint used for drift detection
+	code.WriteString(e.genAdapterSchema(d, schemaHash))
+	code.WriteString("\n\n")
+
+	// Generate payload validation from component settings
+	code.WriteString(e.genAdapterValidate(d))
+	code.WriteString("\n\n")
+
+	// Generate Validate method
+	code.WriteString(e.genAdapterValidatePayload(d))
+	code.WriteString("\n\n")
+
 	// Generate Create method
 	code.WriteString(e.genAdapterCreate(d))
 	code.WriteString("\n\n")
@@ -57,6 +78,10 @@ func (e *Builder) genAdapterCode(d *definitions.EntityDefinition) string {
 	code.WriteString(e.genAdapterUpdate(d))
 	code.WriteString("\n\n")
 
+	// Generate Patch method
+	code.WriteString(e.genAdapterPatch(d))
+	code.WriteString("\n\n")
+
 	// Generate Delete method
 	code.WriteString(e.genAdapterDelete(d))
 
@@ -74,6 +99,72 @@ func (e *Builder) genAdapterCode(d *definitions.EntityDefinition) string {
 	return code.String()
 }
 
+func (e *Builder) genAdapterSchema(d *definitions.EntityDefinition, schemaHash string) string {
+	var code strings.Builder
+	entityName := d.Name
+
+	code.WriteString(fmt.Sprintf("// %sSchemaHash is the hash of the definition schema this adapter was generated from\n", entityName))
+	code.WriteString(fmt.Sprintf("const %sSchemaHash = %q\n\n", entityName, schemaHash))
+
+	code.WriteString(fmt.Sprintf("func (a *%sAdapter) SchemaHash() string {\n", entityName))
+	code.WriteString(fmt.Sprintf("\treturn %sSchemaHash\n", entityName))
+	code.WriteString("}\n\n")
+
+	code.WriteString(fmt.Sprintf("func (a *%sAdapter) Columns() []Column {\n", entityName))
+	code.WriteString("\treturn []Column{\n")
+	for _, comp := range d.Layout.Components {
+		code.WriteString(fmt.Sprintf("\t\t{Name: %q, DBType: %q, Nullable: %t},\n", comp.Name, string(comp.DBType), !comp.Mandatory))
+	}
+	code.WriteString("\t}\n")
+	code.WriteString("}\n")
+
+	return code.String()
+}
+
+// genAdapterValidatePayload generates Validate and ValidatePatch, they run the same conversions
+// and rules as Create and Patch without writing anything
+func (e *Builder) genAdapterValidatePayload(d *definitions.EntityDefinition) string {
+	var code strings.Builder
+	entityName := d.Name
+
+	code.WriteString(fmt.Sprintf("func (a *%sAdapter) Validate(data map[string]interface{}) error {\n", entityName))
+	code.WriteString("\tp := newPayload(data)\n")
+	code.WriteString(fmt.Sprintf("\t_ = db.Create%sParams{\n", entityName))
+
+	for _, comp := range d.Layout.Components {
+		fieldName := toPascalCase(comp.Name)
+		code.WriteString(fmt.Sprintf("\t\t%s: ", fieldName))
+		code.WriteString(e.genFieldConversion(comp, "p"))
+		code.WriteString(",\n")
+	}
+
+	code.WriteString("\t}\n\n")
+	code.WriteString(fmt.Sprintf("\tvalidate%s(p)\n", entityName))
+	code.WriteString("\treturn p.err()\n")
+	code.WriteString("}\n\n")
+
+	code.WriteString(fmt.Sprintf("func (a *%sAdapter) ValidatePatch(data map[string]interface{}) error {\n", entityName))
+	code.WriteString("\tp := newPatchPayload(data)\n")
+	code.WriteString(fmt.Sprintf("\t_ = db.Patch%sParams{\n", entityName))
+
+	for _, comp := range d.Layout.Components {
+		fieldName := toPascalCase(comp.Name)
+		nullable := comp
+		nullable.Mandatory = false
+
+		code.WriteString(fmt.Sprintf("\t\t%s: ", fieldName))
+		code.WriteString(e.genFieldConversion(nullable, "p"))
+		code.WriteString(",\n")
+	}
+
+	code.WriteString("\t}\n\n")
+	code.WriteString(fmt.Sprintf("\tvalidate%s(p)\n", entityName))
+	code.WriteString("\treturn p.err()\n")
+	code.WriteString("}\n")
+
+	return code.String()
+}
+
 func (e *Builder) genAdapterCreate(d *definitions.EntityDefinition) string {
 	var code strings.Builder
 	entityName := d.Name
@@ -95,16 +186,21 @@ func (e *Builder) genAdapterCreate(d *definitions.EntityDefinition) string {
 	code.WriteString("\t}\n\n")
 
 	// Build params
+	code.WriteString("\tp := newPayload(data)\n")
 	code.WriteString(fmt.Sprintf("\tparams := db.Create%sParams{\n", entityName))
 	code.WriteString("\t\tEntityID: eid,\n")
 
 	for _, comp := range d.Layout.Components {
 		fieldName := toPascalCase(comp.Name)
 		code.WriteString(fmt.Sprintf("\t\t%s: ", fieldName))
-		code.WriteString(e.genFieldConversion(comp, "data"))
+		code.WriteString(e.genFieldConversion(comp, "p"))
 		code.WriteString(",\n")
 	}
 
+	code.WriteString("\t}\n\n")
+	code.WriteString(fmt.Sprintf("\tvalidate%s(p)\n", entityName))
+	code.WriteString("\tif err := p.err(); err != nil {\n")
+	code.WriteString("\t\treturn nil, err\n")
 	code.WriteString("\t}\n\n")
 	code.WriteString(fmt.Sprintf("\treturn a.queries.Create%s(ctx, params)\n", entityName))
 	code.WriteString("}\n")
@@ -140,7 +236,7 @@ func (e *Builder) genAdapterUpdate(d *definitions.EntityDefinition) string {
 	var code strings.Builder
 	entityName := d.Name
 
-	code.WriteString(fmt.Sprintf("func (a *%sAdapter) Update(ctx context.Context, id any, data map[string]interface{}) (interface{}, error) {\n", entityName))
+	code.WriteString(fmt.Sprintf("func (a *%sAdapter) Update(ctx context.Context, id any, version int64, data map[string]interface{}) (interface{}, error) {\n", entityName))
 
 	code.WriteString("\tvar uid pgtype.UUID\n")
 	code.WriteString("\tswitch v := id.(type) {\n")
@@ -154,16 +250,22 @@ func (e *Builder) genAdapterUpdate(d *definitions.EntityDefinition) string {
 	code.WriteString("\t\treturn nil, fmt.Errorf(\"id must be string or pgtype.UUID, got %T\", id)\n")
 	code.WriteString("\t}\n\n")
 
+	code.WriteString("\tp := newPayload(data)\n")
 	code.WriteString(fmt.Sprintf("\tparams := db.Update%sParams{\n", entityName))
 	code.WriteString("\t\tEntityID: uid,\n")
+	code.WriteString("\t\tVersion: version,\n")
 
 	for _, comp := range d.Layout.Components {
 		fieldName := toPascalCase(comp.Name)
 		code.WriteString(fmt.Sprintf("\t\t%s: ", fieldName))
-		code.WriteString(e.genFieldConversion(comp, "data"))
+		code.WriteString(e.genFieldConversion(comp, "p"))
 		code.WriteString(",\n")
 	}
 
+	code.WriteString("\t}\n\n")
+	code.WriteString(fmt.Sprintf("\tvalidate%s(p)\n", entityName))
+	code.WriteString("\tif err := p.err(); err != nil {\n")
+	code.WriteString("\t\treturn nil, err\n")
 	code.WriteString("\t}\n\n")
 	code.WriteString(fmt.Sprintf("\treturn a.queries.Update%s(ctx, params)\n", entityName))
 	code.WriteString("}\n")
@@ -171,6 +273,52 @@ func (e *Builder) genAdapterUpdate(d *definitions.EntityDefinition) string {
 	return code.String()
 }
 
+func (e *Builder) genAdapterPatch(d *definitions.EntityDefinition) string {
+	var code strings.Builder
+	entityName := d.Name
+
+	code.WriteString(fmt.Sprintf("func (a *%sAdapter) Patch(ctx context.Context, id any, version int64, data map[string]interface{}) (interface{}, error) {\n", entityName))
+
+	code.WriteString("\tvar uid pgtype.UUID\n")
+	code.WriteString("\tswitch v := id.(type) {\n")
+	code.WriteString("\tcase string:\n")
+	code.WriteString("\t\tif err := uid.Scan(v); err != nil {\n")
+	code.WriteString("\t\t\treturn nil, fmt.Errorf(\"invalid id: %w\", err)\n")
+	code.WriteString("\t\t}\n")
+	code.WriteString("\tcase pgtype.UUID:\n")
+	code.WriteString("\t\tuid = v\n")
+	code.WriteString("\tdefault:\n")
+	code.WriteString("\t\treturn nil, fmt.Errorf(\"id must be string or pgtype.UUID, got %T\", id)\n")
+	code.WriteString("\t}\n\n")
+
+	// Every patch parameter is nullable, the Set* flags decide which columns are written
+	code.WriteString("\tp := newPatchPayload(data)\n")
+	code.WriteString(fmt.Sprintf("\tparams := db.Patch%sParams{\n", entityName))
+	code.WriteString("\t\tEntityID: uid,\n")
+	code.WriteString("\t\tVersion: version,\n")
+
+	for _, comp := range d.Layout.Components {
+		fieldName := toPascalCase(comp.Name)
+		nullable := comp
+		nullable.Mandatory = false
+
+		code.WriteString(fmt.Sprintf("\t\tSet%s: p.has(%q),\n", fieldName, comp.Name))
+		code.WriteString(fmt.Sprintf("\t\t%s: ", fieldName))
+		code.WriteString(e.genFieldConversion(nullable, "p"))
+		code.WriteString(",\n")
+	}
+
+	code.WriteString("\t}\n\n")
+	code.WriteString(fmt.Sprintf("\tvalidate%s(p)\n", entityName))
+	code.WriteString("\tif err := p.err(); err != nil {\n")
+	code.WriteString("\t\treturn nil, err\n")
+	code.WriteString("\t}\n\n")
+	code.WriteString(fmt.Sprintf("\treturn a.queries.Patch%s(ctx, params)\n", entityName))
+	code.WriteString("}\n")
+
+	return code.String()
+}
+
 func (e *Builder) genAdapterDelete(d *definitions.EntityDefinition) string {
 	var code strings.Builder
 	entityName := d.Name
@@ -196,7 +344,7 @@ func (e *Builder) genAdapterDelete(d *definitions.EntityDefinition) string {
 }
 
 // genFieldConversion generates the field conversion code for adapter methods
-func (e *Builder) genFieldConversion(comp definitions.DataComponent, dataVar string) string {
+func (e *Builder) genFieldConversion(comp definitions.DataComponent, payloadVar string) string {
 	goType := comp.GetGoType()
 	fieldName := comp.Name
 
@@ -204,62 +352,142 @@ func (e *Builder) genFieldConversion(comp definitions.DataComponent, dataVar str
 	switch goType {
 	// String types
 	case "string":
-		return fmt.Sprintf("mustGetString(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.mustString(%q)", payloadVar, fieldName)
 
 	case "pgtype.Text":
-		return fmt.Sprintf("getPgText(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.pgText(%q)", payloadVar, fieldName)
 
 	// Integer types - NOT NULL
 	case "int32":
-		return fmt.Sprintf("mustGetInt32(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.mustInt32(%q)", payloadVar, fieldName)
 
 	case "int64":
-		return fmt.Sprintf("mustGetInt64(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.mustInt64(%q)", payloadVar, fieldName)
 
 	case "int16":
-		return fmt.Sprintf("mustGetInt16(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.mustInt16(%q)", payloadVar, fieldName)
 
 	// Integer types - NULLABLE (pgtype)
 	case "pgtype.Int4":
-		return fmt.Sprintf("getPgInt4(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.pgInt4(%q)", payloadVar, fieldName)
 
 	case "pgtype.Int8":
-		return fmt.Sprintf("getPgInt8(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.pgInt8(%q)", payloadVar, fieldName)
 
 	case "pgtype.Int2":
-		return fmt.Sprintf("getPgInt2(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.pgInt2(%q)", payloadVar, fieldName)
 
 	//	Float - NOT NULL
 	case "float32":
-		return fmt.Sprintf("mustGetFloat32(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.mustFloat32(%q)", payloadVar, fieldName)
 
 	case "float64":
-		return fmt.Sprintf("mustGetFloat64(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.mustFloat64(%q)", payloadVar, fieldName)
 
 	// Float - nullable
 	case "pgtype.Float4":
-		return fmt.Sprintf("getPgFloat4(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.pgFloat4(%q)", payloadVar, fieldName)
 
 	case "pgtype.Float8":
-		return fmt.Sprintf("getPgFloat8(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.pgFloat8(%q)", payloadVar, fieldName)
 
 	// Boolean types
 	case "bool":
-		return fmt.Sprintf("mustGetBool(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.mustBool(%q)", payloadVar, fieldName)
 
 	case "pgtype.Bool":
-		return fmt.Sprintf("getPgBool(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.pgBool(%q)", payloadVar, fieldName)
 
 	// Date/Time types
 	case "pgtype.Date":
-		return fmt.Sprintf("getPgDate(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.pgDate(%q)", payloadVar, fieldName)
 
 	case "pgtype.Timestamptz":
-		return fmt.Sprintf("getPgTimestamp(%s, \"%s\")", dataVar, fieldName)
+		return fmt.Sprintf("%s.pgTimestamp(%q)", payloadVar, fieldName)
 
 	default:
-		return fmt.Sprintf("%s[\"%s\"]", dataVar, fieldName)
+		return fmt.Sprintf("%s.pgText(%q)", payloadVar, fieldName)
+	}
+}
+
+// genAdapterValidate generates the function that enforces component settings on a payload
+func (e *Builder) genAdapterValidate(d *definitions.EntityDefinition) string {
+	var code strings.Builder
+	var patterns strings.Builder
+	entityName := d.Name
+
+	names := make([]string, 0, len(d.Layout.Components))
+	for _, comp := range d.Layout.Components {
+		names = append(names, fmt.Sprintf("%q", comp.Name))
+	}
+
+	code.WriteString(fmt.Sprintf("// validate%s checks the payload against the %s definition settings\n", entityName, d.ID))
+	code.WriteString(fmt.Sprintf("func validate%s(p *payload) {\n", entityName))
+	code.WriteString(fmt.Sprintf("\tp.allowOnly(%s)\n", strings.Join(names, ", ")))
+
+	for _, comp := range d.Layout.Components {
+		if comp.Mandatory {
+			// Also covers patches, where conversion helpers are always the nullable variants
+			code.WriteString(fmt.Sprintf("\tp.required(%q)\n", comp.Name))
+		}
+
+		settings, _ := comp.GetSettings()
+		switch s := settings.(type) {
+		case definitions.InputSettings:
+			if s.ColumnLength != nil {
+				code.WriteString(fmt.Sprintf("\tp.maxLength(%q, %d)\n", comp.Name, *s.ColumnLength))
+			}
+			if s.RegexValidation != "" {
+				patternVar := fmt.Sprintf("%s%sPattern", lowerFirst(entityName), toPascalCase(comp.Name))
+				patterns.WriteString(fmt.Sprintf("var %s = regexp.MustCompile(%q)\n", patternVar, s.RegexValidation))
+				code.WriteString(fmt.Sprintf("\tp.matches(%q, %s)\n", comp.Name, patternVar))
+			}
+		case definitions.IntegerSettings:
+			minValue := s.MinValue
+			if s.Unsigned && (minValue == nil || *minValue < 0) {
+				zero := 0
+				minValue = &zero
+			}
+			if minValue != nil {
+				code.WriteString(fmt.Sprintf("\tp.minNumber(%q, %d)\n", comp.Name, *minValue))
+			}
+			if s.MaxValue != nil {
+				code.WriteString(fmt.Sprintf("\tp.maxNumber(%q, %d)\n", comp.Name, *s.MaxValue))
+			}
+		case definitions.FloatSettings:
+			if s.MinValue != nil {
+				code.WriteString(fmt.Sprintf("\tp.minNumber(%q, %d)\n", comp.Name, *s.MinValue))
+			}
+			if s.MaxValue != nil {
+				code.WriteString(fmt.Sprintf("\tp.maxNumber(%q, %d)\n", comp.Name, *s.MaxValue))
+			}
+		}
+	}
+
+	code.WriteString("}\n")
+
+	if patterns.Len() > 0 {
+		return patterns.String() + "\n" + code.String()
+	}
+
+	return code.String()
+}
+
+func hasRegexValidation(d *definitions.EntityDefinition) bool {
+	for _, comp := range d.Layout.Components {
+		settings, _ := comp.GetSettings()
+		if s, ok := settings.(definitions.InputSettings); ok && s.RegexValidation != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
 	}
+	return strings.ToLower(s[:1]) + s[1:]
 }
 
 func toPascalCase(s string) string {
@@ -56,7 +56,7 @@ func (e *Builder) ValidateNewDefinition(definition *definitions.EntityDefinition
 		}
 	}
 
-	validationOfExistingDefinitionsResult, err := e.ValidateExistingDefinition(definition)
+	validationOfExistingDefinitionsResult, err := e.ValidateExistingDefinition(definition, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -64,10 +64,27 @@ func (e *Builder) ValidateNewDefinition(definition *definitions.EntityDefinition
 	return validationOfExistingDefinitionsResult, nil
 }
 
-// ValidateExistingDefinition validates definition points that touch only core of the definition
+// ValidateExistingDefinition validates definition points that touch only core of the definition,
+// components the stored definition does not have yet must not use a reserved name
 //
 // [ ] - Duplicate Component Names
-func (e *Builder) ValidateExistingDefinition(definition *definitions.EntityDefinition) ([]byte, error) {
+func (e *Builder) ValidateExistingDefinition(definition, stored *definitions.EntityDefinition) ([]byte, error) {
+	// Identifiers and types are rendered into DDL and generated code
+	if err := definition.ValidateIdentifiers(); err != nil {
+		resp, _ := json.Marshal(map[string]string{
+			"error":  "invalid definition identifiers",
+			"reason": err.Error(),
+		})
+		return resp, nil
+	}
+	if err := definition.ValidateNewNames(stored); err != nil {
+		resp, _ := json.Marshal(map[string]string{
+			"error":  "invalid definition identifiers",
+			"reason": err.Error(),
+		})
+		return resp, nil
+	}
+
 	// Check for duplicated component names
 	componentNames := make(map[string]bool, 1)
 	for _, component := range definition.Layout.Components {
@@ -76,6 +93,16 @@ func (e *Builder) ValidateExistingDefinition(definition *definitions.EntityDefin
 		}
 
 		componentNames[component.Name] = true
+
+		// Settings drive the generated adapter validation so they must be consistent
+		if err := component.ValidateSettings(); err != nil {
+			resp, _ := json.Marshal(map[string]string{
+				"error":         "invalid component settings",
+				"componentName": component.Name,
+				"reason":        err.Error(),
+			})
+			return resp, nil
+		}
 	}
 
 	return nil, nil
@@ -6,13 +6,20 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	helpers "github.com/oriiyx/fritz/app/core/utils/helpers/schema"
 )
 
 const EntitiesTableQueriesFilePathTemplate = "database/fritz"
 const EntitiesAdaptersFilePathTemplate = "app/core/services/entities/adapters"
 
 func (e *Builder) CreateCrudOperations(tablename string, d *definitions.EntityDefinition) error {
+	// Names are rendered into SQL, sqlc parameter names and Go code below
+	if err := d.ValidateIdentifiers(); err != nil {
+		return err
+	}
+
 	queriesName := fmt.Sprintf("queries_%s", d.ID)
 	commentBlock := "-- Code generated by fritz. DO NOT EDIT.\n" +
 		fmt.Sprintf("-- Created at: %s\n", time.Now().UTC().String())
@@ -27,6 +34,11 @@ func (e *Builder) CreateCrudOperations(tablename string, d *definitions.EntityDe
 		return err
 	}
 
+	patchStatement, err := e.genPatch(tablename, d)
+	if err != nil {
+		return err
+	}
+
 	readStatement, err := e.genRead(tablename, d)
 	if err != nil {
 		return err
@@ -37,7 +49,7 @@ func (e *Builder) CreateCrudOperations(tablename string, d *definitions.EntityDe
 		return err
 	}
 
-	sql := strings.Join([]string{commentBlock, createStatement, editStatement, readStatement, deleteStatement}, "\n\n")
+	sql := strings.Join([]string{commentBlock, createStatement, editStatement, patchStatement, readStatement, deleteStatement}, "\n\n")
 
 	err = e.cw.WriteNewFile(sql, EntitiesTableQueriesFilePathTemplate, fmt.Sprintf("%s.sql", queriesName))
 	if err != nil {
@@ -66,7 +78,12 @@ func (e *Builder) CreateCrudOperations(tablename string, d *definitions.EntityDe
 		Msg("SQLC generation completed successfully")
 
 	// Now generate the adapter code that bridges JSON -> SQLC
-	adapterCode := e.genAdapterCode(d)
+	schemaHash, err := helpers.CalculateSchemaHash(d)
+	if err != nil {
+		return fmt.Errorf("failed to calculate schema hash for adapter: %w", err)
+	}
+
+	adapterCode := e.genAdapterCode(d, schemaHash)
 	adapterFilename := e.CreateAdapterFileName(d)
 
 	err = e.cw.WriteNewFile(adapterCode, "app/core/services/entities/adapters", adapterFilename)
@@ -132,47 +149,94 @@ func (e *Builder) genCreate(tablename string, d *definitions.EntityDefinition) (
 
 	// Add each component column
 	for _, component := range d.Layout.Components {
-		columns = append(columns, component.Name)
+		columns = append(columns, pgx.Identifier{component.Name}.Sanitize())
 		placeholders = append(placeholders, fmt.Sprintf("$%d", paramIndex))
 		paramIndex++
 	}
 
 	sqlStatement := fmt.Sprintf(
-		"INSERT INTO %s (%s)\nVALUES (%s)\nRETURNING *;",
-		tablename,
+		"INSERT INTO %s (%s)\nVALUES (%s)\nRETURNING %s;",
+		pgx.Identifier{tablename}.Sanitize(),
 		strings.Join(columns, ", "),
 		strings.Join(placeholders, ", "),
+		dataColumns(d),
 	)
 
 	sql := strings.Join([]string{sqlcStatement, sqlStatement}, "\n")
 	return sql, nil
 }
 
+// dataColumns lists the columns the generated queries return, generated columns such as the
+// search vector stay out of the adapter rows
+func dataColumns(d *definitions.EntityDefinition) string {
+	columns := []string{"id", "entity_id", "created_at", "updated_at"}
+	for _, component := range d.Layout.Components {
+		columns = append(columns, pgx.Identifier{component.Name}.Sanitize())
+	}
+	return strings.Join(columns, ", ")
+}
+
+// claimEntityVersion bumps the entity version only when it still matches the version the client saw.
+// Data writes select their row through it, so a concurrent save makes them match nothing.
+const claimEntityVersion = "WITH claimed AS (UPDATE entities\n" +
+	"                 SET version = version + 1, updated_at = NOW()\n" +
+	"                 WHERE id = sqlc.arg(entity_id) AND version = sqlc.arg(version)::bigint\n" +
+	"                 RETURNING id)\n"
+
 func (e *Builder) genEdit(tablename string, d *definitions.EntityDefinition) (string, error) {
 	queryName := fmt.Sprintf("Update%s", d.Name)
 	sqlcStatement := fmt.Sprintf("-- name: %s :one", queryName)
 
 	// Build SET clauses
 	var setClauses []string
-	paramIndex := 1
 
 	// Add each component column
 	for _, component := range d.Layout.Components {
-		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", component.Name, paramIndex))
-		paramIndex++
+		setClauses = append(setClauses, fmt.Sprintf("%s = sqlc.arg(%s)", pgx.Identifier{component.Name}.Sanitize(), component.Name))
 	}
 
 	// Add updated_at
 	setClauses = append(setClauses, "updated_at = NOW()")
 
-	// ID is the last parameter
-	idParam := paramIndex
-
 	sqlStatement := fmt.Sprintf(
-		"UPDATE %s\nSET %s\nWHERE entity_id = $%d\nRETURNING *;",
-		tablename,
+		"%sUPDATE %s\nSET %s\nWHERE entity_id = (SELECT id FROM claimed)\nRETURNING %s;",
+		claimEntityVersion,
+		pgx.Identifier{tablename}.Sanitize(),
 		strings.Join(setClauses, ", "),
-		idParam,
+		dataColumns(d),
+	)
+
+	sql := strings.Join([]string{sqlcStatement, sqlStatement}, "\n")
+	return sql, nil
+}
+
+// genPatch generates an update that only writes the columns whose set_<column> flag is true,
+// so a supplied null clears a value while an omitted key leaves it untouched
+func (e *Builder) genPatch(tablename string, d *definitions.EntityDefinition) (string, error) {
+	queryName := fmt.Sprintf("Patch%s", d.Name)
+	sqlcStatement := fmt.Sprintf("-- name: %s :one", queryName)
+
+	var setClauses []string
+	for _, component := range d.Layout.Components {
+		column := pgx.Identifier{component.Name}.Sanitize()
+		setClauses = append(setClauses, fmt.Sprintf(
+			"%s = CASE WHEN sqlc.arg(set_%s)::boolean THEN sqlc.narg(%s)::%s ELSE %s END",
+			column,
+			component.Name,
+			component.Name,
+			component.DBType.Base(),
+			column,
+		))
+	}
+
+	setClauses = append(setClauses, "updated_at = NOW()")
+
+	sqlStatement := fmt.Sprintf(
+		"%sUPDATE %s\nSET %s\nWHERE entity_id = (SELECT id FROM claimed)\nRETURNING %s;",
+		claimEntityVersion,
+		pgx.Identifier{tablename}.Sanitize(),
+		strings.Join(setClauses, ",\n    "),
+		dataColumns(d),
 	)
 
 	sql := strings.Join([]string{sqlcStatement, sqlStatement}, "\n")
@@ -182,7 +246,7 @@ func (e *Builder) genEdit(tablename string, d *definitions.EntityDefinition) (st
 func (e *Builder) genRead(tablename string, d *definitions.EntityDefinition) (string, error) {
 	queryName := fmt.Sprintf("Get%sByID", d.Name)
 	sqlcStatement := fmt.Sprintf("-- name: %s :one", queryName)
-	sqlStatement := fmt.Sprintf("SELECT * FROM %s WHERE entity_id = $1;", tablename)
+	sqlStatement := fmt.Sprintf("SELECT %s FROM %s WHERE entity_id = $1;", dataColumns(d), pgx.Identifier{tablename}.Sanitize())
 
 	sql := strings.Join([]string{sqlcStatement, sqlStatement}, "\n")
 	return sql, nil
@@ -191,7 +255,7 @@ func (e *Builder) genRead(tablename string, d *definitions.EntityDefinition) (st
 func (e *Builder) genDelete(tablename string, d *definitions.EntityDefinition) (string, error) {
 	queryName := fmt.Sprintf("Delete%s", d.Name)
 	sqlcStatement := fmt.Sprintf("-- name: %s :exec", queryName)
-	sqlStatement := fmt.Sprintf("DELETE FROM %s WHERE entity_id = $1;", tablename)
+	sqlStatement := fmt.Sprintf("DELETE FROM %s WHERE entity_id = $1;", pgx.Identifier{tablename}.Sanitize())
 
 	sql := strings.Join([]string{sqlcStatement, sqlStatement}, "\n")
 	return sql, nil
@@ -0,0 +1,223 @@
+package definition_builder
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+)
+
+// lexedSQL splits a statement into quoted literals, quoted identifiers and everything else
+type lexedSQL struct {
+	literals    []string
+	identifiers []string
+	bare        string
+}
+
+// lexSQL tokenizes the quoting the DDL generator emits: '...', E'...' and "..."
+func lexSQL(sql string) (lexedSQL, error) {
+	var out lexedSQL
+	var bare strings.Builder
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		escape := false
+		if (c == 'E' || c == 'e') && i+1 < len(sql) && sql[i+1] == '\'' {
+			if i == 0 || !isIdentChar(sql[i-1]) {
+				escape = true
+				i++
+				c = sql[i]
+			}
+		}
+
+		switch c {
+		case '\'':
+			value, end, err := readQuoted(sql, i, '\'', escape)
+			if err != nil {
+				return out, err
+			}
+			out.literals = append(out.literals, value)
+			bare.WriteString("?")
+			i = end
+		case '"':
+			value, end, err := readQuoted(sql, i, '"', false)
+			if err != nil {
+				return out, err
+			}
+			out.identifiers = append(out.identifiers, value)
+			bare.WriteString("?")
+			i = end
+		default:
+			bare.WriteByte(c)
+		}
+	}
+
+	out.bare = bare.String()
+	return out, nil
+}
+
+func readQuoted(sql string, start int, quote byte, escape bool) (string, int, error) {
+	var value strings.Builder
+	for i := start + 1; i < len(sql); i++ {
+		c := sql[i]
+		if escape && c == '\\' {
+			if i+1 >= len(sql) || sql[i+1] != '\\' {
+				return "", 0, errors.New("unexpected backslash escape")
+			}
+			value.WriteByte('\\')
+			i++
+			continue
+		}
+		if c == quote {
+			if i+1 < len(sql) && sql[i+1] == quote {
+				value.WriteByte(quote)
+				i++
+				continue
+			}
+			return value.String(), i, nil
+		}
+		value.WriteByte(c)
+	}
+	return "", 0, errors.New("unterminated quoted token")
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// assertNoInjection fails when anything outside quoted tokens could start a new statement or comment
+func assertNoInjection(t *testing.T, sql string, statements int) lexedSQL {
+	t.Helper()
+
+	lexed, err := lexSQL(sql)
+	if err != nil {
+		t.Fatalf("generated SQL does not lex: %v\n%s", err, sql)
+	}
+	if strings.Count(lexed.bare, ";") > statements {
+		t.Fatalf("generated SQL contains a statement separator outside of quotes:\n%s", sql)
+	}
+	if strings.Contains(lexed.bare, "--") || strings.Contains(lexed.bare, "/*") {
+		t.Fatalf("generated SQL contains a comment outside of quotes:\n%s", sql)
+	}
+	return lexed
+}
+
+func fuzzDefinition(id, name, componentName, dbType, defaultValue string) *definitions.EntityDefinition {
+	settings, _ := json.Marshal(definitions.InputSettings{DefaultValue: defaultValue})
+
+	return &definitions.EntityDefinition{
+		ID:   id,
+		Name: name,
+		Layout: definitions.Layout{
+			Type: "default",
+			Components: []definitions.DataComponent{
+				{
+					Type:     definitions.ComponentInput,
+					Name:     componentName,
+					Title:    "Title",
+					DBType:   definitions.DBType(dbType),
+					Settings: settings,
+				},
+			},
+		},
+	}
+}
+
+func FuzzDDLGeneration(f *testing.F) {
+	f.Add("product", "Product", "sku", "varchar(255)", "plain")
+	f.Add("product", "Product", "sku", "varchar(255)", "it's")
+	f.Add("product", "Product", "sku", "text", `'); DROP TABLE entities; --`)
+	f.Add("product", "Product", "sku", "text", `\'; DROP TABLE entities; --`)
+	f.Add("product", "Product", "sku", "text", "a\x00b")
+	f.Add("product; DROP TABLE entities", "Product", "sku", "text", "")
+	f.Add("product", "Product", `sku" text); DROP TABLE entities; --`, "text", "")
+	f.Add("product", "Product", "sku", "text DEFAULT now()); DROP TABLE entities; --", "")
+	f.Add("product", "Product", "sku", "varchar(1); DROP TABLE entities)", "")
+
+	b := &Builder{}
+
+	f.Fuzz(func(t *testing.T, id, name, componentName, dbType, defaultValue string) {
+		d := fuzzDefinition(id, name, componentName, dbType, defaultValue)
+
+		createSQL, err := b.BuildCreateTableSQL(d)
+		if err != nil {
+			// Rejected definitions never reach the database
+			return
+		}
+
+		lexed := assertNoInjection(t, createSQL, 0)
+
+		if indexSQL, ok := b.BuildSearchIndexSQL(d); ok {
+			assertNoInjection(t, indexSQL, 0)
+		}
+
+		if !containsString(lexed.identifiers, definitions.EntityTablePrefix+id) || !containsString(lexed.identifiers, componentName) {
+			t.Fatalf("table or column identifier was not quoted:\n%s", createSQL)
+		}
+
+		// Settings travel as JSON, compare against what the component actually holds
+		settings, _ := d.Layout.Components[0].GetSettings()
+		storedDefault := settings.(definitions.InputSettings).DefaultValue
+		expectedDefault := strings.ReplaceAll(storedDefault, "\x00", "")
+		if storedDefault != "" && !containsString(lexed.literals, expectedDefault) {
+			t.Fatalf("default value %q does not round-trip through the literal:\n%s", defaultValue, createSQL)
+		}
+
+		changeset, err := b.BuildTableChangeset(&ComponentChangeset{
+			Added:    d.Layout.Components,
+			Removed:  d.Layout.Components,
+			Modified: d.Layout.Components,
+		}, b.CreateEntityTableName(d))
+		if err != nil {
+			t.Fatalf("valid definition was rejected by the changeset builder: %v", err)
+		}
+		assertNoInjection(t, changeset.Added.String(), 0)
+		assertNoInjection(t, changeset.Removed.String(), 0)
+		assertNoInjection(t, changeset.Modified.String(), 0)
+
+		tableName := b.CreateEntityTableName(d)
+		generators := []func(string, *definitions.EntityDefinition) (string, error){
+			b.genCreate, b.genEdit, b.genPatch, b.genRead, b.genDelete,
+		}
+		for _, generate := range generators {
+			query, err := generate(tableName, d)
+			if err != nil {
+				t.Fatalf("query generation failed: %v", err)
+			}
+
+			// The first line is the sqlc name annotation
+			_, body, _ := strings.Cut(query, "\n")
+			assertNoInjection(t, body, 1)
+		}
+	})
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidateExistingDefinitionKeepsStoredNames(t *testing.T) {
+	b := &Builder{}
+	stored := fuzzDefinition("product", "Product", "version", "text", "")
+
+	if resp, err := b.ValidateExistingDefinition(stored, nil); err != nil || resp == nil {
+		t.Fatalf("expected a new reserved component to be rejected, got %s %v", resp, err)
+	}
+	if resp, err := b.ValidateExistingDefinition(stored, stored); err != nil || resp != nil {
+		t.Fatalf("expected a stored reserved component to be kept, got %s %v", resp, err)
+	}
+
+	updated := fuzzDefinition("product", "Product", "version", "text", "")
+	updated.Layout.Components = append(updated.Layout.Components, fuzzDefinition("product", "Product", "set_price", "text", "").Layout.Components...)
+	if resp, err := b.ValidateExistingDefinition(updated, stored); err != nil || resp == nil {
+		t.Fatalf("expected an added set_ component to be rejected, got %s %v", resp, err)
+	}
+}
@@ -0,0 +1,112 @@
+package definition_builder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+)
+
+const searchIndexSuffix = "_search_idx"
+
+// SearchIndexName returns the name of the GIN index over the search vector of an entity table
+func SearchIndexName(tablename string) string {
+	// Keep the suffix when the table name already uses the whole identifier length
+	if len(tablename)+len(searchIndexSuffix) > definitions.MaxIdentifierLength {
+		tablename = tablename[:definitions.MaxIdentifierLength-len(searchIndexSuffix)]
+	}
+	return tablename + searchIndexSuffix
+}
+
+// searchVectorColumnDefinition renders the generated tsvector column, ok is false when the
+// definition has no searchable components
+func (e *Builder) searchVectorColumnDefinition(definition *definitions.EntityDefinition) (string, bool) {
+	expr, ok := definition.SearchVectorExpression()
+	if !ok {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s tsvector GENERATED ALWAYS AS (%s) STORED",
+		pgx.Identifier{definitions.SearchVectorColumn}.Sanitize(),
+		expr,
+	), true
+}
+
+// BuildSearchIndexSQL renders the GIN index over the search vector, ok is false when the
+// definition has no searchable components
+func (e *Builder) BuildSearchIndexSQL(definition *definitions.EntityDefinition) (string, bool) {
+	if len(definition.SearchableComponents()) == 0 {
+		return "", false
+	}
+
+	tableName := e.CreateEntityTableName(definition)
+	return fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING GIN (%s)",
+		pgx.Identifier{SearchIndexName(tableName)}.Sanitize(),
+		pgx.Identifier{tableName}.Sanitize(),
+		pgx.Identifier{definitions.SearchVectorColumn}.Sanitize(),
+	), true
+}
+
+// SearchVectorChanged reports whether the search vector has to be rebuilt for a definition update.
+// Postgres cannot change the expression of a generated column, nor the type of a column that a
+// generated column reads, so both cases drop the vector before the changeset and add it back after.
+func (e *Builder) SearchVectorChanged(existing, new *definitions.EntityDefinition, changeset *ComponentChangeset) bool {
+	existingExpr, _ := existing.SearchVectorExpression()
+	newExpr, _ := new.SearchVectorExpression()
+	if existingExpr != newExpr {
+		return true
+	}
+
+	if existingExpr == "" {
+		return false
+	}
+
+	searchable := make(map[string]bool)
+	for _, component := range existing.SearchableComponents() {
+		searchable[component.Name] = true
+	}
+	for _, component := range changeset.Modified {
+		if searchable[component.Name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DropSearchVector removes the search vector of a table together with its index
+func (e *Builder) DropSearchVector(ctx context.Context, tablename string) error {
+	_, err := e.db.Exec(ctx, fmt.Sprintf("%sDROP COLUMN IF EXISTS %s",
+		e.generatePrefix(tablename),
+		pgx.Identifier{definitions.SearchVectorColumn}.Sanitize(),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to drop search vector: %w", err)
+	}
+
+	return nil
+}
+
+// AddSearchVector adds the search vector and its index to an existing table, it does nothing
+// for definitions without searchable components
+func (e *Builder) AddSearchVector(ctx context.Context, definition *definitions.EntityDefinition) error {
+	columnDefinition, ok := e.searchVectorColumnDefinition(definition)
+	if !ok {
+		return nil
+	}
+
+	tableName := e.CreateEntityTableName(definition)
+	_, err := e.db.Exec(ctx, fmt.Sprintf("%sADD COLUMN IF NOT EXISTS %s", e.generatePrefix(tableName), columnDefinition))
+	if err != nil {
+		return fmt.Errorf("failed to add search vector: %w", err)
+	}
+
+	indexSQL, _ := e.BuildSearchIndexSQL(definition)
+	_, err = e.db.Exec(ctx, indexSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create search index: %w", err)
+	}
+
+	return nil
+}
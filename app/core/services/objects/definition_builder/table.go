@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
 )
 
@@ -13,29 +14,26 @@ const EntitiesTableSchemaFilePathTemplate = "database/schema"
 func (e *Builder) CreateEntityTable(ctx context.Context, definition *definitions.EntityDefinition) (string, error) {
 	tableName := e.CreateEntityTableName(definition)
 
-	// Build CREATE TABLE statement from definition.Layout.Components
-	columns := []string{
-		"id UUID PRIMARY KEY DEFAULT uuid_generate_v4()",
-		"entity_id UUID NOT NULL REFERENCES entities(id) ON DELETE CASCADE",
-		"created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()",
-		"updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()",
-	}
-
-	for _, component := range definition.Layout.Components {
-		columns = append(columns, component.ToColumnDefinition())
+	sql, err := e.BuildCreateTableSQL(definition)
+	if err != nil {
+		return "", err
 	}
 
-	sql := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)",
-		tableName,
-		strings.Join(columns, ", "))
-
 	// Execute SQL
-	_, err := e.db.Exec(ctx, sql)
+	_, err = e.db.Exec(ctx, sql)
 
 	if err != nil {
 		return "", err
 	}
 
+	if indexSQL, ok := e.BuildSearchIndexSQL(definition); ok {
+		_, err = e.db.Exec(ctx, indexSQL)
+		if err != nil {
+			return "", err
+		}
+		sql = fmt.Sprintf("%s;\n\n%s", sql, indexSQL)
+	}
+
 	err = e.cw.WriteNewFile(sql, EntitiesTableSchemaFilePathTemplate, fmt.Sprintf("%s.sql", tableName))
 	if err != nil {
 		return "", err
@@ -44,7 +42,46 @@ func (e *Builder) CreateEntityTable(ctx context.Context, definition *definitions
 	return tableName, nil
 }
 
+// BuildCreateTableSQL renders the CREATE TABLE statement for a definition with quoted identifiers
+// and literal defaults
+func (e *Builder) BuildCreateTableSQL(definition *definitions.EntityDefinition) (string, error) {
+	if err := definition.ValidateIdentifiers(); err != nil {
+		return "", err
+	}
+
+	// Build CREATE TABLE statement from definition.Layout.Components
+	columns := []string{
+		"id UUID PRIMARY KEY DEFAULT uuid_generate_v4()",
+		"entity_id UUID NOT NULL REFERENCES entities(id) ON DELETE CASCADE",
+		"created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()",
+		"updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()",
+	}
+
+	for _, component := range definition.Layout.Components {
+		columnDefinition, err := component.ToColumnDefinition()
+		if err != nil {
+			return "", err
+		}
+		columns = append(columns, columnDefinition)
+	}
+
+	if columnDefinition, ok := e.searchVectorColumnDefinition(definition); ok {
+		columns = append(columns, columnDefinition)
+	}
+
+	sql := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)",
+		pgx.Identifier{e.CreateEntityTableName(definition)}.Sanitize(),
+		strings.Join(columns, ", "))
+
+	return sql, nil
+}
+
 func (e *Builder) CreateEntityTableName(definition *definitions.EntityDefinition) string {
-	tableName := fmt.Sprintf("entity_%s", definition.ID)
+	return EntityTableName(definition.ID)
+}
+
+// EntityTableName returns the name of the data table that backs the given definition ID
+func EntityTableName(definitionID string) string {
+	tableName := definitions.EntityTablePrefix + definitionID
 	return tableName
 }
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	"github.com/jackc/pgx/v5"
 )
 
 type TableChangeset struct {
@@ -15,12 +15,50 @@ type TableChangeset struct {
 }
 
 func (e *Builder) UpdateTableFromChangeset(changeset *ComponentChangeset, tablename string, ctx context.Context) error {
+	tc, err := e.BuildTableChangeset(changeset, tablename)
+	if err != nil {
+		return err
+	}
+
+	baseLen := len(e.generatePrefix(tablename))
+
+	if tc.Added.Len() > baseLen {
+		_, err = e.db.Exec(ctx, tc.Added.String())
+		if err != nil {
+			return fmt.Errorf("failed to add columns: %w", err)
+		}
+	}
+
+	if tc.Removed.Len() > baseLen {
+		_, err = e.db.Exec(ctx, tc.Removed.String())
+		if err != nil {
+			return fmt.Errorf("failed to remove columns: %w", err)
+		}
+	}
+
+	if tc.Modified.Len() > baseLen {
+		_, err = e.db.Exec(ctx, tc.Modified.String())
+		if err != nil {
+			return fmt.Errorf("failed to modify columns: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// BuildTableChangeset renders the ALTER TABLE statements for a changeset, every identifier is quoted
+// and every default value is rendered as a literal
+func (e *Builder) BuildTableChangeset(changeset *ComponentChangeset, tablename string) (*TableChangeset, error) {
 	tc := e.CreateTableChangesetBasis(tablename)
 
 	// handle adding new columns to the table
 	var add []string
 	for _, component := range changeset.Added {
-		add = append(add, fmt.Sprintf("ADD COLUMN %s", component.ToColumnDefinition()))
+		columnDefinition, err := component.ToColumnDefinition()
+		if err != nil {
+			return nil, err
+		}
+		add = append(add, fmt.Sprintf("ADD COLUMN %s", columnDefinition))
 	}
 	if add != nil {
 		tc.Added.WriteString(strings.Join(add, ", "))
@@ -29,7 +67,7 @@ func (e *Builder) UpdateTableFromChangeset(changeset *ComponentChangeset, tablen
 	// handle removing columns from the table
 	var remove []string
 	for _, component := range changeset.Removed {
-		remove = append(remove, fmt.Sprintf("DROP COLUMN %s", component.Name))
+		remove = append(remove, fmt.Sprintf("DROP COLUMN %s", pgx.Identifier{component.Name}.Sanitize()))
 	}
 	if remove != nil {
 		tc.Removed.WriteString(strings.Join(remove, ", "))
@@ -38,96 +76,44 @@ func (e *Builder) UpdateTableFromChangeset(changeset *ComponentChangeset, tablen
 	// handle modified columns from the table
 	var modify []string
 	for _, component := range changeset.Modified {
+		if err := component.ValidateIdentifiers(); err != nil {
+			return nil, err
+		}
+
+		column := pgx.Identifier{component.Name}.Sanitize()
+		columnType, err := component.DBType.Render()
+		if err != nil {
+			return nil, err
+		}
+
 		// Change the type (with USING for safe conversion)
 		modify = append(modify, fmt.Sprintf(
 			"ALTER COLUMN %s TYPE %s USING %s::%s",
-			component.Name,
-			string(component.DBType),
-			component.Name,
-			string(component.DBType),
+			column,
+			columnType,
+			column,
+			columnType,
 		))
 
 		// Handle default value changes before nullability
-		settings, _ := component.GetSettings()
-		hasDefault := false
-		var defaultValue string
-
-		switch component.Type {
-		case definitions.ComponentInput:
-			if s, ok := settings.(definitions.InputSettings); ok && s.DefaultValue != "" {
-				hasDefault = true
-				defaultValue = fmt.Sprintf("'%s'", s.DefaultValue)
-			}
-		case definitions.ComponentTextarea:
-			if s, ok := settings.(definitions.TextareaSettings); ok && s.DefaultValue != "" {
-				hasDefault = true
-				defaultValue = fmt.Sprintf("'%s'", s.DefaultValue)
-			}
-		case definitions.ComponentInteger:
-			if s, ok := settings.(definitions.IntegerSettings); ok && s.DefaultValue != nil {
-				hasDefault = true
-				defaultValue = fmt.Sprintf("%d", *s.DefaultValue)
-			}
-		case definitions.ComponentFloat4:
-			if s, ok := settings.(definitions.FloatSettings); ok && s.DefaultValue != nil {
-				hasDefault = true
-				defaultValue = fmt.Sprintf("%d", *s.DefaultValue)
-			}
-		case definitions.ComponentFloat8:
-			if s, ok := settings.(definitions.FloatSettings); ok && s.DefaultValue != nil {
-				hasDefault = true
-				defaultValue = fmt.Sprintf("%d", *s.DefaultValue)
-			}
-		case definitions.ComponentDate:
-			if s, ok := settings.(definitions.DateSettings); ok && s.DefaultValue != nil {
-				hasDefault = true
-				defaultValue = fmt.Sprintf("'%s'", s.DefaultValue.Format("2006-01-02"))
-			}
-		}
-
-		if hasDefault {
-			modify = append(modify, fmt.Sprintf("ALTER COLUMN %s SET DEFAULT %s", component.Name, defaultValue))
+		if defaultValue, ok := component.DefaultLiteral(); ok {
+			modify = append(modify, fmt.Sprintf("ALTER COLUMN %s SET DEFAULT %s", column, defaultValue))
 		} else {
-			modify = append(modify, fmt.Sprintf("ALTER COLUMN %s DROP DEFAULT", component.Name))
+			modify = append(modify, fmt.Sprintf("ALTER COLUMN %s DROP DEFAULT", column))
 		}
 
 		// Handle nullability changes
 		if component.Mandatory {
-			modify = append(modify, fmt.Sprintf("ALTER COLUMN %s SET NOT NULL", component.Name))
+			modify = append(modify, fmt.Sprintf("ALTER COLUMN %s SET NOT NULL", column))
 		} else {
-			modify = append(modify, fmt.Sprintf("ALTER COLUMN %s DROP NOT NULL", component.Name))
+			modify = append(modify, fmt.Sprintf("ALTER COLUMN %s DROP NOT NULL", column))
 		}
-
 	}
 	if modify != nil {
-		s := strings.Join(modify, ", ")
-		tc.Modified.WriteString(s)
-	}
-
-	baseLen := len(e.generatePrefix(tablename))
-
-	if tc.Added.Len() > baseLen {
-		_, err := e.db.Exec(ctx, tc.Added.String())
-		if err != nil {
-			return fmt.Errorf("failed to add columns: %w", err)
-		}
-	}
-
-	if tc.Removed.Len() > baseLen {
-		_, err := e.db.Exec(ctx, tc.Removed.String())
-		if err != nil {
-			return fmt.Errorf("failed to remove columns: %w", err)
-		}
+		tc.Modified.WriteString(strings.Join(modify, ", "))
 	}
 
-	if tc.Modified.Len() > baseLen {
-		_, err := e.db.Exec(ctx, tc.Modified.String())
-		if err != nil {
-			return fmt.Errorf("failed to modify columns: %w", err)
-		}
-	}
-
-	return nil
+	return tc, nil
 }
 
 func (e *Builder) CreateTableChangesetBasis(tablename string) *TableChangeset {
@@ -142,5 +128,5 @@ func (e *Builder) CreateTableChangesetBasis(tablename string) *TableChangeset {
 }
 
 func (e *Builder) generatePrefix(tablename string) string {
-	return fmt.Sprintf("ALTER TABLE IF EXISTS %s ", tablename)
+	return fmt.Sprintf("ALTER TABLE IF EXISTS %s ", pgx.Identifier{tablename}.Sanitize())
 }
@@ -3,9 +3,11 @@ package definitions
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // DataComponent represents an actual configured data component instance
@@ -21,6 +23,11 @@ type DataComponent struct {
 	Invisible   bool `json:"invisible"`
 	NotEditable bool `json:"notEditable"`
 
+	// Full-text search, only text components can be searchable. Both are omitted when unset so
+	// definitions without search keep their schema hash
+	Searchable   bool         `json:"searchable,omitempty"`
+	SearchWeight SearchWeight `json:"searchWeight,omitempty"`
+
 	// Type-specific settings stored as raw JSON
 	Settings json.RawMessage `json:"settings"`
 }
@@ -103,45 +110,57 @@ func (dc *DataComponent) ValidateSettings() error {
 }
 
 // ToColumnDefinition generates SQL add column definition to table
-func (dc *DataComponent) ToColumnDefinition() string {
-	parts := []string{dc.Name, string(dc.DBType)}
+func (dc *DataComponent) ToColumnDefinition() (string, error) {
+	if err := dc.ValidateIdentifiers(); err != nil {
+		return "", err
+	}
+
+	columnType, err := dc.DBType.Render()
+	if err != nil {
+		return "", err
+	}
+
+	parts := []string{pgx.Identifier{dc.Name}.Sanitize(), columnType}
+
+	if defaultValue, ok := dc.DefaultLiteral(); ok {
+		parts = append(parts, fmt.Sprintf("DEFAULT %s", defaultValue))
+	}
+
+	if dc.Mandatory {
+		parts = append(parts, "NOT NULL")
+	}
+
+	return strings.Join(parts, " "), nil
+}
 
-	// Get settings to check for default value
+// DefaultLiteral renders the default value from the component settings as a SQL literal
+func (dc *DataComponent) DefaultLiteral() (string, bool) {
 	settings, _ := dc.GetSettings()
 
-	// Handle default values based on type
 	switch dc.Type {
 	case ComponentInput:
 		if s, ok := settings.(InputSettings); ok && s.DefaultValue != "" {
-			parts = append(parts, fmt.Sprintf("DEFAULT '%s'", s.DefaultValue))
+			return QuoteLiteral(s.DefaultValue), true
 		}
 	case ComponentTextarea:
 		if s, ok := settings.(TextareaSettings); ok && s.DefaultValue != "" {
-			parts = append(parts, fmt.Sprintf("DEFAULT '%s'", s.DefaultValue))
+			return QuoteLiteral(s.DefaultValue), true
 		}
 	case ComponentInteger:
 		if s, ok := settings.(IntegerSettings); ok && s.DefaultValue != nil {
-			parts = append(parts, fmt.Sprintf("DEFAULT %d", *s.DefaultValue))
+			return strconv.Itoa(*s.DefaultValue), true
 		}
-	case ComponentFloat4:
+	case ComponentFloat4, ComponentFloat8:
 		if s, ok := settings.(FloatSettings); ok && s.DefaultValue != nil {
-			parts = append(parts, fmt.Sprintf("DEFAULT %d", *s.DefaultValue))
-		}
-	case ComponentFloat8:
-		if s, ok := settings.(FloatSettings); ok && s.DefaultValue != nil {
-			parts = append(parts, fmt.Sprintf("DEFAULT %d", *s.DefaultValue))
+			return strconv.Itoa(*s.DefaultValue), true
 		}
 	case ComponentDate:
 		if s, ok := settings.(DateSettings); ok && s.DefaultValue != nil {
-			parts = append(parts, fmt.Sprintf("DEFAULT '%s'", s.DefaultValue.Format("2006-01-02")))
+			return QuoteLiteral(s.DefaultValue.Format("2006-01-02")), true
 		}
 	}
 
-	if dc.Mandatory {
-		parts = append(parts, "NOT NULL")
-	}
-
-	return strings.Join(parts, " ")
+	return "", false
 }
 
 func (dc *DataComponent) UnmarshalJSON(data []byte) error {
@@ -183,13 +202,13 @@ func (dc *DataComponent) GetGoType() string {
 
 	case DataTypeFloat4:
 		if dc.Mandatory {
-			return "float4"
+			return "float32"
 		}
 		return "pgtype.Float4"
 
 	case DataTypeFloat8:
 		if dc.Mandatory {
-			return "float8"
+			return "float64"
 		}
 		return "pgtype.Float8"
 
@@ -1,6 +1,13 @@
 package definitions
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// typeModifierPattern matches the optional size or precision part of a type, e.g. (255) or (10,2)
+var typeModifierPattern = regexp.MustCompile(`^\(\s*(\d{1,5})\s*(?:,\s*(\d{1,5})\s*)?\)$`)
 
 type DBType string
 
@@ -45,3 +52,98 @@ const (
 
 	DataTypeBoolean DBType = "boolean"
 )
+
+var udtNames = map[DBType]string{
+	DataTypeVarchar:     "varchar",
+	DataTypeText:        "text",
+	DataTypeChar:        "bpchar",
+	DataTypeSmallInt:    "int2",
+	DataTypeInteger:     "int4",
+	DataTypeBigInt:      "int8",
+	DataTypeNumeric:     "numeric",
+	DataTypeDecimal:     "numeric",
+	DataTypeFloat4:      "float4",
+	DataTypeFloat8:      "float8",
+	DataTypeSmallSerial: "int2",
+	DataTypeSerial:      "int4",
+	DataTypeBigSerial:   "int8",
+	DataTypeBytea:       "bytea",
+	DataTypeBit:         "bit",
+	DataTypeDate:        "date",
+	DataTypeTimestamp:   "timestamp",
+	DataTypeTimestampTZ: "timestamptz",
+	DataTypeTime:        "time",
+	DataTypeTimeTZ:      "timetz",
+	DataTypeInterval:    "interval",
+	DataTypeBoolean:     "bool",
+}
+
+// Base strips size or precision modifiers, varchar(255) becomes varchar
+func (d DBType) Base() DBType {
+	base, _, _ := strings.Cut(string(d), "(")
+	return DBType(strings.ToLower(strings.TrimSpace(base)))
+}
+
+// UDTName returns the name postgres reports in information_schema.columns.udt_name for this type
+func (d DBType) UDTName() string {
+	if name, ok := udtNames[d.Base()]; ok {
+		return name
+	}
+	return string(d.Base())
+}
+
+// Render returns the type as it is written into DDL and rejects anything that is not a known type
+func (d DBType) Render() (string, error) {
+	base := d.Base()
+	if _, ok := udtNames[base]; !ok {
+		return "", fmt.Errorf("unsupported database type %q", string(d))
+	}
+
+	_, modifier, hasModifier := strings.Cut(string(d), "(")
+	if !hasModifier {
+		return string(base), nil
+	}
+
+	match := typeModifierPattern.FindStringSubmatch("(" + modifier)
+	if match == nil {
+		return "", fmt.Errorf("invalid modifier in database type %q", string(d))
+	}
+	if match[2] != "" {
+		return fmt.Sprintf("%s(%s,%s)", base, match[1], match[2]), nil
+	}
+
+	return fmt.Sprintf("%s(%s)", base, match[1]), nil
+}
+
+// ValueKind groups database types by how their values are parsed and compared
+type ValueKind string
+
+const (
+	KindText      ValueKind = "text"
+	KindInteger   ValueKind = "integer"
+	KindFloat     ValueKind = "float"
+	KindDate      ValueKind = "date"
+	KindTimestamp ValueKind = "timestamp"
+	KindBoolean   ValueKind = "boolean"
+	KindOther     ValueKind = "other"
+)
+
+// Kind returns the value kind of the type
+func (d DBType) Kind() ValueKind {
+	switch d.Base() {
+	case DataTypeVarchar, DataTypeText, DataTypeChar:
+		return KindText
+	case DataTypeSmallInt, DataTypeInteger, DataTypeBigInt, DataTypeSmallSerial, DataTypeSerial, DataTypeBigSerial:
+		return KindInteger
+	case DataTypeFloat4, DataTypeFloat8, DataTypeNumeric, DataTypeDecimal:
+		return KindFloat
+	case DataTypeDate:
+		return KindDate
+	case DataTypeTimestamp, DataTypeTimestampTZ:
+		return KindTimestamp
+	case DataTypeBoolean:
+		return KindBoolean
+	default:
+		return KindOther
+	}
+}
@@ -6,4 +6,7 @@ type EntityDefinition struct {
 	Description  string `json:"description" validate:"max=1000"`
 	AllowInherit bool   `json:"allowInherit"`
 	Layout       Layout `json:"layout"`
+
+	// SearchConfig is the postgres text search configuration used for searchable components
+	SearchConfig string `json:"searchConfig,omitempty"`
 }
@@ -0,0 +1,21 @@
+// Package definitionstest provides the entity definitions shared by the tests of packages that build
+// on definitions
+package definitionstest
+
+import "github.com/oriiyx/fritz/app/core/services/objects/definitions"
+
+// Product is the product class of the tests, a title and a stock count followed by the components a
+// test adds
+func Product(components ...definitions.DataComponent) *definitions.EntityDefinition {
+	return &definitions.EntityDefinition{
+		ID:   "product",
+		Name: "Product",
+		Layout: definitions.Layout{
+			Type: "default",
+			Components: append([]definitions.DataComponent{
+				{Type: definitions.ComponentInput, Name: "title", DBType: "varchar(255)"},
+				{Type: definitions.ComponentInteger, Name: "stock", DBType: definitions.DataTypeInteger},
+			}, components...),
+		},
+	}
+}
@@ -0,0 +1,121 @@
+package definitions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxIdentifierLength is the postgres NAMEDATALEN limit, longer identifiers are silently truncated
+const MaxIdentifierLength = 63
+
+// EntityTablePrefix is prepended to a definition ID to build its data table name
+const EntityTablePrefix = "entity_"
+
+var (
+	// identifierPattern is used for definition IDs and component names, both end up as SQL
+	// identifiers, sqlc parameter names and Go field names
+	identifierPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	// goNamePattern is used for definition names, they become part of generated Go and sqlc query names
+	goNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+)
+
+// reservedColumns already exist on every entity table or are used as generated query parameters,
+// they are only refused for new components so definitions stored before the rule keep loading
+var reservedColumns = map[string]bool{
+	"id":         true,
+	"entity_id":  true,
+	"created_at": true,
+	"updated_at": true,
+	"version":    true,
+
+	SearchVectorColumn: true,
+}
+
+// ValidateIdentifiers checks every name of the definition that is rendered into DDL or generated code
+func (d *EntityDefinition) ValidateIdentifiers() error {
+	if !identifierPattern.MatchString(d.ID) {
+		return fmt.Errorf("definition id %q must start with a lowercase letter and contain only lowercase letters, digits and underscores", d.ID)
+	}
+	if len(EntityTablePrefix)+len(d.ID) > MaxIdentifierLength {
+		return fmt.Errorf("definition id %q must be at most %d characters", d.ID, MaxIdentifierLength-len(EntityTablePrefix))
+	}
+
+	if !goNamePattern.MatchString(d.Name) {
+		return fmt.Errorf("definition name %q must start with a letter and contain only letters and digits", d.Name)
+	}
+
+	for _, component := range d.Layout.Components {
+		if err := component.ValidateIdentifiers(); err != nil {
+			return err
+		}
+	}
+
+	return d.ValidateSearch()
+}
+
+// ValidateIdentifiers checks the component name and database type before they are rendered into DDL
+func (dc *DataComponent) ValidateIdentifiers() error {
+	if !identifierPattern.MatchString(dc.Name) {
+		return fmt.Errorf("component name %q must start with a lowercase letter and contain only lowercase letters, digits and underscores", dc.Name)
+	}
+	if len(dc.Name) > MaxIdentifierLength {
+		return fmt.Errorf("component name %q must be at most %d characters", dc.Name, MaxIdentifierLength)
+	}
+
+	if _, err := dc.DBType.Render(); err != nil {
+		return fmt.Errorf("component %q: %w", dc.Name, err)
+	}
+
+	return nil
+}
+
+// ValidateNewNames checks the names of the components existing does not have yet against the names
+// the generated tables and queries use themselves, a nil existing treats every component as new
+func (d *EntityDefinition) ValidateNewNames(existing *EntityDefinition) error {
+	known := make(map[string]bool)
+	if existing != nil {
+		for _, component := range existing.Layout.Components {
+			known[component.Name] = true
+		}
+	}
+
+	for _, component := range d.Layout.Components {
+		if known[component.Name] {
+			continue
+		}
+		if err := component.ValidateNewName(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateNewName refuses the reserved names for a component that is added to a definition
+func (dc *DataComponent) ValidateNewName() error {
+	if reservedColumns[dc.Name] {
+		return fmt.Errorf("component name %q is reserved", dc.Name)
+	}
+	if strings.HasPrefix(dc.Name, "set_") {
+		// set_<name> parameters carry the PATCH flags
+		return fmt.Errorf("component name %q must not start with set_", dc.Name)
+	}
+
+	return nil
+}
+
+// QuoteLiteral renders value as a postgres string literal that is safe to embed in DDL
+func QuoteLiteral(value string) string {
+	// postgres text cannot hold NUL bytes
+	value = strings.ReplaceAll(value, "\x00", "")
+	value = strings.ReplaceAll(value, "'", "''")
+
+	// Use an escape string when backslashes are present so the result does not depend on
+	// standard_conforming_strings
+	if strings.Contains(value, `\`) {
+		return "E'" + strings.ReplaceAll(value, `\`, `\\`) + "'"
+	}
+
+	return "'" + value + "'"
+}
@@ -0,0 +1,135 @@
+package definitions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SearchVectorColumn is the generated tsvector column of entity tables with searchable components
+const SearchVectorColumn = "search_vector"
+
+// DefaultSearchConfig is used when a definition does not choose a text search configuration
+const DefaultSearchConfig = "simple"
+
+// SearchWeight is the postgres tsvector weight of a searchable component, A ranks highest
+type SearchWeight string
+
+const (
+	SearchWeightA SearchWeight = "A"
+	SearchWeightB SearchWeight = "B"
+	SearchWeightC SearchWeight = "C"
+	SearchWeightD SearchWeight = "D"
+)
+
+// DefaultSearchWeight is used for searchable components without an explicit weight
+const DefaultSearchWeight = SearchWeightD
+
+var searchWeights = map[SearchWeight]bool{
+	SearchWeightA: true,
+	SearchWeightB: true,
+	SearchWeightC: true,
+	SearchWeightD: true,
+}
+
+// searchConfigs are the text search configurations shipped with postgres, the configuration is
+// rendered into generated column DDL so only known names are accepted
+var searchConfigs = map[string]bool{
+	"simple":     true,
+	"arabic":     true,
+	"armenian":   true,
+	"basque":     true,
+	"catalan":    true,
+	"danish":     true,
+	"dutch":      true,
+	"english":    true,
+	"finnish":    true,
+	"french":     true,
+	"german":     true,
+	"greek":      true,
+	"hindi":      true,
+	"hungarian":  true,
+	"indonesian": true,
+	"irish":      true,
+	"italian":    true,
+	"lithuanian": true,
+	"nepali":     true,
+	"norwegian":  true,
+	"portuguese": true,
+	"romanian":   true,
+	"russian":    true,
+	"serbian":    true,
+	"spanish":    true,
+	"swedish":    true,
+	"tamil":      true,
+	"turkish":    true,
+	"yiddish":    true,
+}
+
+// Weight returns the search weight of the component, falling back to DefaultSearchWeight
+func (dc *DataComponent) Weight() SearchWeight {
+	if dc.SearchWeight == "" {
+		return DefaultSearchWeight
+	}
+	return dc.SearchWeight
+}
+
+// TextSearchConfig returns the text search configuration of the definition
+func (d *EntityDefinition) TextSearchConfig() string {
+	if d.SearchConfig == "" {
+		return DefaultSearchConfig
+	}
+	return d.SearchConfig
+}
+
+// SearchableComponents returns the components that feed the search vector, in layout order
+func (d *EntityDefinition) SearchableComponents() []DataComponent {
+	var components []DataComponent
+	for _, component := range d.Layout.Components {
+		if component.Searchable {
+			components = append(components, component)
+		}
+	}
+	return components
+}
+
+// ValidateSearch checks the search configuration and that only text components are searchable
+func (d *EntityDefinition) ValidateSearch() error {
+	if d.SearchConfig != "" && !searchConfigs[d.SearchConfig] {
+		return fmt.Errorf("search config %q is not supported", d.SearchConfig)
+	}
+
+	for _, component := range d.Layout.Components {
+		if component.SearchWeight != "" && !searchWeights[component.SearchWeight] {
+			return fmt.Errorf("component %q: search weight must be one of A, B, C or D", component.Name)
+		}
+		if component.Searchable && component.DBType.Kind() != KindText {
+			return fmt.Errorf("component %q: only text components can be searchable", component.Name)
+		}
+	}
+
+	return nil
+}
+
+// SearchVectorExpression renders the weighted tsvector expression over all searchable components.
+// ok is false when the definition has nothing to search.
+func (d *EntityDefinition) SearchVectorExpression() (expr string, ok bool) {
+	components := d.SearchableComponents()
+	if len(components) == 0 {
+		return "", false
+	}
+
+	config := QuoteLiteral(d.TextSearchConfig()) + "::regconfig"
+
+	parts := make([]string, 0, len(components))
+	for _, component := range components {
+		parts = append(parts, fmt.Sprintf("setweight(to_tsvector(%s, coalesce(%s, '')), %s)",
+			config,
+			pgx.Identifier{component.Name}.Sanitize(),
+			QuoteLiteral(string(component.Weight())),
+		))
+	}
+
+	return strings.Join(parts, " || "), true
+}
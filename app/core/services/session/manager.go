@@ -117,6 +117,10 @@ func (m *Manager) StartCleanup(ctx context.Context) {
 				if err := m.queries.CleanupExpiredSessions(ctx); err != nil {
 					m.logger.Error().Err(err).Msg("failed to cleanup expired sessions")
 				}
+				// Expired edit locks no longer block anyone, the rows are only swept up
+				if _, err := m.queries.DeleteExpiredEntityLocks(ctx); err != nil {
+					m.logger.Error().Err(err).Msg("failed to cleanup expired entity locks")
+				}
 			}
 		}
 	}()
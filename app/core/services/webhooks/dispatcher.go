@@ -0,0 +1,103 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/oriiyx/fritz/app/core/kernel"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	db "github.com/oriiyx/fritz/database/generated"
+	"github.com/rs/zerolog"
+)
+
+// Dispatcher turns the events of the after hooks into pending deliveries of the webhooks that
+// subscribe to them, the Worker sends them
+type Dispatcher struct {
+	queries *db.Queries
+	logger  *zerolog.Logger
+}
+
+func NewDispatcher(queries *db.Queries, logger *zerolog.Logger) *Dispatcher {
+	return &Dispatcher{
+		queries: queries,
+		logger:  logger,
+	}
+}
+
+// Subscribe registers the dispatcher on every event hook. It runs after the callbacks of plugins so
+// a failing webhook store never keeps them from running.
+func (d *Dispatcher) Subscribe(h *kernel.Hooks) {
+	for _, event := range Events {
+		h.Register(event, kernel.PriorityLow, func(ctx context.Context, data interface{}) error {
+			return d.Dispatch(ctx, event, data)
+		})
+	}
+}
+
+// Dispatch stores a delivery of the event for every active webhook it matches. The operation is
+// committed when the hook runs, so the deliveries are stored even if the request is cancelled.
+func (d *Dispatcher) Dispatch(ctx context.Context, event string, data interface{}) error {
+	ctx = context.WithoutCancel(ctx)
+
+	subject, ok := subjectOf(data)
+	if !ok {
+		d.logger.Warn().Str("event", event).Msgf("Unexpected webhook event payload %T", data)
+		return nil
+	}
+
+	webhooks, err := d.queries.ListActiveWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !Matches(webhook, event, subject) {
+			continue
+		}
+
+		// Every matching webhook receives the same body, it is encoded once
+		if payload == nil {
+			payload, err = json.Marshal(Event{Event: event, OccurredAt: time.Now().UTC(), Data: data})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = d.queries.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+			WebhookID: webhook.ID,
+			Event:     event,
+			Payload:   payload,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func subjectOf(data interface{}) (Subject, bool) {
+	switch payload := data.(type) {
+	case *hooks.EntityCreatePayload:
+		return entitySubject(payload.Class, payload.Entity), true
+	case *hooks.EntityUpdatePayload:
+		return entitySubject(payload.Class, payload.Entity), true
+	case *hooks.EntityDeletePayload:
+		return entitySubject(payload.Class, payload.Entity), true
+	case *hooks.EntityPublishPayload:
+		return entitySubject(payload.Class, payload.Entity), true
+	case *hooks.DefinitionPayload:
+		if payload.Definition == nil {
+			return Subject{}, false
+		}
+		return Subject{Class: payload.Definition.ID}, true
+	}
+	return Subject{}, false
+}
+
+// entitySubject is the class of an entity with its o_path, which already ends in its key
+func entitySubject(class string, entity db.Entity) Subject {
+	return Subject{Class: class, Path: entity.OPath}
+}
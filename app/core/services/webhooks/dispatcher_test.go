@@ -0,0 +1,298 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/kernel"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/publishing"
+	"github.com/oriiyx/fritz/app/core/services/entities/versions"
+	"github.com/oriiyx/fritz/app/core/services/imports/pipeline"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions/definitionstest"
+	db "github.com/oriiyx/fritz/database/generated"
+	"github.com/rs/zerolog"
+)
+
+// fakeDB answers the generated queries by their name. A query returning rows gets the responses of
+// its name in order and the last one repeats, a struct is scanned field by field and an error is
+// returned as is. Queries without responses find no rows. Transactions and savepoints run on the
+// fake itself.
+type fakeDB struct {
+	pgx.Tx
+
+	responses map[string][]interface{}
+	lists     map[string][]interface{}
+	execs     map[string][][]interface{}
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{
+		responses: make(map[string][]interface{}),
+		lists:     make(map[string][]interface{}),
+		execs:     make(map[string][][]interface{}),
+	}
+}
+
+func (f *fakeDB) Begin(context.Context) (pgx.Tx, error) { return f, nil }
+func (f *fakeDB) Commit(context.Context) error          { return nil }
+func (f *fakeDB) Rollback(context.Context) error        { return nil }
+
+func (f *fakeDB) Exec(_ context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	name := queryName(sql)
+	f.execs[name] = append(f.execs[name], args)
+	return pgconn.NewCommandTag("OK"), nil
+}
+
+func (f *fakeDB) Query(_ context.Context, sql string, _ ...interface{}) (pgx.Rows, error) {
+	return &fakeRows{values: f.lists[queryName(sql)]}, nil
+}
+
+func (f *fakeDB) QueryRow(_ context.Context, sql string, _ ...interface{}) pgx.Row {
+	name := queryName(sql)
+
+	responses := f.responses[name]
+	if len(responses) == 0 {
+		return fakeRow{err: pgx.ErrNoRows}
+	}
+	if len(responses) > 1 {
+		f.responses[name] = responses[1:]
+	}
+
+	if err, ok := responses[0].(error); ok {
+		return fakeRow{err: err}
+	}
+	return fakeRow{value: responses[0]}
+}
+
+// queryName is the name sqlc gives a query in its leading comment
+func queryName(sql string) string {
+	fields := strings.Fields(strings.TrimPrefix(sql, "-- name:"))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+type fakeRow struct {
+	value interface{}
+	err   error
+}
+
+func (r fakeRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	return scanValue(r.value, dest)
+}
+
+type fakeRows struct {
+	pgx.Rows
+
+	values []interface{}
+	next   int
+}
+
+func (r *fakeRows) Next() bool {
+	r.next++
+	return r.next <= len(r.values)
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error { return scanValue(r.values[r.next-1], dest) }
+func (r *fakeRows) Close()                         {}
+func (r *fakeRows) Err() error                     { return nil }
+
+// scanValue copies the fields of a struct in order into dest, other values fill a single dest
+func scanValue(value interface{}, dest []interface{}) error {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Struct {
+		if len(dest) != 1 {
+			return fmt.Errorf("cannot scan %T into %d columns", value, len(dest))
+		}
+		reflect.ValueOf(dest[0]).Elem().Set(v)
+		return nil
+	}
+
+	if v.NumField() != len(dest) {
+		return fmt.Errorf("cannot scan %T with %d fields into %d columns", value, v.NumField(), len(dest))
+	}
+	for i := range dest {
+		reflect.ValueOf(dest[i]).Elem().Set(v.Field(i))
+	}
+	return nil
+}
+
+// fakeAdapter stores nothing, the data it is given is its row
+type fakeAdapter struct{}
+
+func (fakeAdapter) Validate(map[string]interface{}) error      { return nil }
+func (fakeAdapter) ValidatePatch(map[string]interface{}) error { return nil }
+func (fakeAdapter) Delete(context.Context, any) error          { return nil }
+func (a fakeAdapter) WithTx(pgx.Tx) adapters.EntityAdapter     { return a }
+func (fakeAdapter) SchemaHash() string                         { return "" }
+
+func (fakeAdapter) Columns() []adapters.Column {
+	return []adapters.Column{{Name: "title", DBType: "varchar(255)"}, {Name: "stock", DBType: "integer"}}
+}
+
+func (fakeAdapter) Create(_ context.Context, _ any, data map[string]interface{}) (interface{}, error) {
+	return data, nil
+}
+
+func (fakeAdapter) Read(context.Context, any) (interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+func (fakeAdapter) Update(_ context.Context, _ any, _ int64, data map[string]interface{}) (interface{}, error) {
+	return data, nil
+}
+
+func (fakeAdapter) Patch(_ context.Context, _ any, _ int64, data map[string]interface{}) (interface{}, error) {
+	return data, nil
+}
+
+func testUUID(b byte) pgtype.UUID {
+	return pgtype.UUID{Bytes: [16]byte{b}, Valid: true}
+}
+
+// subscribed returns hooks with a dispatcher for the single active webhook of f
+func subscribed(f *fakeDB) *kernel.Hooks {
+	f.lists["ListActiveWebhooks"] = []interface{}{db.Webhook{ID: testUUID(9), Url: "https://example.com/hook", Active: true}}
+
+	logger := zerolog.Nop()
+	h := kernel.NewHooks()
+	NewDispatcher(db.New(f), &logger).Subscribe(h)
+	return h
+}
+
+// deliveredEvents are the events of the deliveries f stored
+func deliveredEvents(f *fakeDB) []string {
+	var events []string
+	for _, args := range f.execs["CreateWebhookDelivery"] {
+		events = append(events, args[1].(string))
+	}
+	return events
+}
+
+func TestScheduledPublishEnqueuesDelivery(t *testing.T) {
+	adapters.Register("product", fakeAdapter{})
+
+	f := newFakeDB()
+	h := subscribed(f)
+
+	entity := db.Entity{ID: testUUID(1), EntityClass: "product", OKey: "anvil", OPath: "/products/anvil", Version: 1}
+	published := entity
+	published.Published, published.Version = true, 2
+
+	f.responses["ClaimDuePublishSchedule"] = []interface{}{
+		db.PublishSchedule{ID: testUUID(2), EntityID: entity.ID, Action: publishing.ActionPublish, CreatedBy: testUUID(3)},
+		pgx.ErrNoRows,
+	}
+	f.responses["GetEntityByID"] = []interface{}{entity}
+	f.responses["UpdateEntity"] = []interface{}{published}
+	f.responses["CreateEntityVersion"] = []interface{}{db.EntityVersion{}}
+
+	logger := zerolog.Nop()
+	scheduler := publishing.NewScheduler(f, db.New(f), &logger, h, time.Minute, versions.Policy{})
+
+	executed, err := scheduler.RunDue(context.Background(), time.Now())
+	if err != nil {
+		t.Fatalf("expected the schedule to run, got %v", err)
+	}
+	if executed != 1 {
+		t.Fatalf("expected 1 executed schedule, got %d", executed)
+	}
+
+	if finished := f.execs["FinishPublishSchedule"]; len(finished) != 1 {
+		t.Fatalf("expected the schedule to be finished once, got %d", len(finished))
+	}
+	if events := deliveredEvents(f); !reflect.DeepEqual(events, []string{hooks.HookAfterEntityPublish}) {
+		t.Errorf("expected a delivery of %s, got %v", hooks.HookAfterEntityPublish, events)
+	}
+}
+
+func TestImportRowEnqueuesDelivery(t *testing.T) {
+	f := newFakeDB()
+	h := subscribed(f)
+
+	parent := db.Entity{ID: testUUID(1), EntityClass: "folder", OKey: "products", OPath: "/products"}
+	entity := db.Entity{ID: testUUID(2), EntityClass: "product", ParentID: parent.ID, OKey: "anvil", OPath: "/products/anvil", Version: 1}
+	written := entity
+	written.HasData, written.Version = true, 2
+
+	f.responses["GetEntityByID"] = []interface{}{parent}
+	f.responses["CreateEntity"] = []interface{}{entity}
+	f.responses["UpdateEntity"] = []interface{}{written}
+	f.responses["CreateEntityVersion"] = []interface{}{db.EntityVersion{}}
+
+	mapping := pipeline.Mapping{Fields: []pipeline.FieldMapping{
+		{Source: "sku", Target: pipeline.TargetKey},
+		{Source: "path", Target: pipeline.TargetPath},
+		{Source: "parent", Target: pipeline.TargetParentID},
+		{Source: "name", Target: "title"},
+	}}
+	p, err := pipeline.New(f, db.New(f), h, definitionstest.Product(), fakeAdapter{}, mapping, versions.Policy{})
+	if err != nil {
+		t.Fatalf("expected a pipeline, got %v", err)
+	}
+
+	input := "sku,path,parent,name\nanvil,/products/anvil," + parent.ID.String() + ",Anvil\n"
+	progress, err := p.Run(context.Background(), pipeline.FormatCSV, strings.NewReader(input), pipeline.Options{
+		OnHookError: func(name string, err error) {
+			t.Errorf("expected %s to succeed, got %v", name, err)
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected the import to run, got %v", err)
+	}
+	if progress.Created != 1 || progress.Failed != 0 {
+		t.Fatalf("expected 1 created row, got %+v", progress)
+	}
+
+	if events := deliveredEvents(f); !reflect.DeepEqual(events, []string{hooks.HookAfterEntityCreate}) {
+		t.Errorf("expected a delivery of %s, got %v", hooks.HookAfterEntityCreate, events)
+	}
+}
+
+func TestVetoedImportRowEnqueuesNothing(t *testing.T) {
+	f := newFakeDB()
+	h := subscribed(f)
+	h.Register(hooks.HookBeforeEntityCreate, kernel.PriorityNormal, func(context.Context, interface{}) error {
+		return errors.New("imports are closed")
+	})
+
+	parent := db.Entity{ID: testUUID(1), EntityClass: "folder", OKey: "products", OPath: "/products"}
+	f.responses["GetEntityByID"] = []interface{}{parent}
+
+	mapping := pipeline.Mapping{Fields: []pipeline.FieldMapping{
+		{Source: "sku", Target: pipeline.TargetKey},
+		{Source: "path", Target: pipeline.TargetPath},
+		{Source: "parent", Target: pipeline.TargetParentID},
+	}}
+	p, err := pipeline.New(f, db.New(f), h, definitionstest.Product(), fakeAdapter{}, mapping, versions.Policy{})
+	if err != nil {
+		t.Fatalf("expected a pipeline, got %v", err)
+	}
+
+	input := "sku,path,parent\nanvil,/products/anvil," + parent.ID.String() + "\n"
+	progress, err := p.Run(context.Background(), pipeline.FormatCSV, strings.NewReader(input), pipeline.Options{})
+	if err != nil {
+		t.Fatalf("expected the import to run, got %v", err)
+	}
+	if progress.Failed != 1 || len(progress.Errors) != 1 || progress.Errors[0].Message != "imports are closed" {
+		t.Fatalf("expected the row to fail with the veto, got %+v", progress)
+	}
+
+	if events := deliveredEvents(f); len(events) != 0 {
+		t.Errorf("expected no delivery, got %v", events)
+	}
+}
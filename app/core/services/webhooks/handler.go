@@ -0,0 +1,419 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/api/base"
+	"github.com/oriiyx/fritz/app/core/api/common/errhandler"
+	l "github.com/oriiyx/fritz/app/core/api/common/log"
+	ctxUtil "github.com/oriiyx/fritz/app/core/utils/ctx"
+	validatorUtil "github.com/oriiyx/fritz/app/core/utils/validator"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+const (
+	WebhookIDKey  = "webhook_id"
+	DeliveryIDKey = "delivery_id"
+
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+type Handler struct {
+	*base.HandlerController
+}
+
+func New(ctrl *base.HandlerController) *Handler {
+	return &Handler{
+		HandlerController: ctrl,
+	}
+}
+
+// WebhookRequest creates or replaces a webhook. Empty events, classes and path prefix match every
+// event, an empty secret generates one on create and keeps the current one on update.
+type WebhookRequest struct {
+	URL        string   `json:"url" validate:"required,url,max=2048"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16,max=255"`
+	Events     []string `json:"events"`
+	Classes    []string `json:"classes"`
+	PathPrefix string   `json:"path_prefix" validate:"max=1024"`
+	Active     *bool    `json:"active"`
+}
+
+// WebhookResponse is a webhook without its secret, only the response of a create carries it
+type WebhookResponse struct {
+	db.Webhook
+	Secret string `json:"secret,omitempty"`
+}
+
+// DeliveryResponse is a delivery with its payload as JSON rather than bytes
+type DeliveryResponse struct {
+	db.WebhookDelivery
+	Payload json.RawMessage `json:"payload"`
+}
+
+func newDeliveryResponse(delivery db.WebhookDelivery) DeliveryResponse {
+	return DeliveryResponse{WebhookDelivery: delivery, Payload: delivery.Payload}
+}
+
+type DeliveryPage struct {
+	Items      []DeliveryResponse `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// List is an endpoint that lists all webhooks
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	webhooks, err := h.Queries.ListWebhooks(r.Context())
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to list webhooks")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	response := make([]WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		response = append(response, WebhookResponse{Webhook: webhook})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// Create is an endpoint that registers a webhook. The response carries its secret, it is not
+// returned again.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	req, ok := h.decodeWebhookRequest(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateSecret(); err != nil {
+			h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to generate webhook secret")
+			errhandler.ServerError(w, errhandler.RespProcessFailure)
+			return
+		}
+	}
+
+	webhook, err := h.Queries.CreateWebhook(r.Context(), db.CreateWebhookParams{
+		Url:        req.URL,
+		Secret:     secret,
+		Events:     nonNil(req.Events),
+		Classes:    nonNil(req.Classes),
+		PathPrefix: req.PathPrefix,
+		Active:     req.Active == nil || *req.Active,
+		CreatedBy:  ctxUtil.GetUserID(r.Context()),
+	})
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to create webhook")
+		errhandler.ServerError(w, errhandler.RespDBDataInsertFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(WebhookResponse{Webhook: webhook, Secret: webhook.Secret})
+}
+
+// Get is an endpoint that returns a single webhook
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(WebhookResponse{Webhook: webhook})
+}
+
+// Update is an endpoint that replaces a webhook, its pending deliveries are sent to the new URL
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	existing, ok := h.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := h.decodeWebhookRequest(w, r, reqID)
+	if !ok {
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret = existing.Secret
+	}
+
+	webhook, err := h.Queries.UpdateWebhook(r.Context(), db.UpdateWebhookParams{
+		ID:         existing.ID,
+		Url:        req.URL,
+		Secret:     secret,
+		Events:     nonNil(req.Events),
+		Classes:    nonNil(req.Classes),
+		PathPrefix: req.PathPrefix,
+		Active:     req.Active == nil || *req.Active,
+	})
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("webhook_id", existing.ID.String()).Msg("Failed to update webhook")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(WebhookResponse{Webhook: webhook})
+}
+
+// Delete is an endpoint that removes a webhook with its delivery log
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	webhookID, ok := parseUUID(w, chi.URLParam(r, WebhookIDKey), "invalid webhook_id")
+	if !ok {
+		return
+	}
+
+	deleted, err := h.Queries.DeleteWebhook(r.Context(), webhookID)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("webhook_id", webhookID.String()).Msg("Failed to delete webhook")
+		errhandler.ServerError(w, errhandler.RespDBDataRemoveFailure)
+		return
+	}
+	if deleted == 0 {
+		errhandler.NotFound(w, []byte(`{"error": "webhook not found"}`))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries is an endpoint that lists the delivery log of a webhook newest first. It filters
+// by status, limit sets the page size and cursor continues after the previous page.
+func (h *Handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	webhook, ok := h.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	params, err := deliveryParams(webhook.ID, r.URL.Query().Get("status"), r.URL.Query().Get("limit"), r.URL.Query().Get("cursor"))
+	if err != nil {
+		errhandler.BadRequest(w, []byte(fmt.Sprintf(`{"error": %q}`, err.Error())))
+		return
+	}
+
+	// One row more than the limit tells whether another page follows
+	params.RowLimit++
+	deliveries, err := h.Queries.ListWebhookDeliveries(r.Context(), params)
+	if err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("webhook_id", webhook.ID.String()).Msg("Failed to list webhook deliveries")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return
+	}
+
+	page := DeliveryPage{Items: make([]DeliveryResponse, 0, len(deliveries))}
+	if len(deliveries) == int(params.RowLimit) {
+		deliveries = deliveries[:len(deliveries)-1]
+		last := deliveries[len(deliveries)-1]
+		page.NextCursor = encodeCursor(last.CreatedAt.Time, last.ID)
+	}
+	for _, delivery := range deliveries {
+		page.Items = append(page.Items, newDeliveryResponse(delivery))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+// RetryDelivery is an endpoint that sends a delivered or dead delivery again with a fresh set of
+// attempts
+func (h *Handler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	webhookID, ok := parseUUID(w, chi.URLParam(r, WebhookIDKey), "invalid webhook_id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseUUID(w, chi.URLParam(r, DeliveryIDKey), "invalid delivery_id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.Queries.RetryWebhookDelivery(r.Context(), db.RetryWebhookDeliveryParams{ID: deliveryID, WebhookID: webhookID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "no delivered or dead delivery found"}`))
+			return
+		}
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Str("delivery_id", deliveryID.String()).Msg("Failed to retry webhook delivery")
+		errhandler.ServerError(w, errhandler.RespDBDataUpdateFailure)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(newDeliveryResponse(delivery))
+}
+
+// loadWebhook reads the webhook from the URL, it writes the error response and returns false when
+// the webhook cannot be used
+func (h *Handler) loadWebhook(w http.ResponseWriter, r *http.Request) (db.Webhook, bool) {
+	webhookID, ok := parseUUID(w, chi.URLParam(r, WebhookIDKey), "invalid webhook_id")
+	if !ok {
+		return db.Webhook{}, false
+	}
+
+	webhook, err := h.Queries.GetWebhook(r.Context(), webhookID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			errhandler.NotFound(w, []byte(`{"error": "webhook not found"}`))
+			return webhook, false
+		}
+		h.Logger.Error().Err(err).Str("webhook_id", webhookID.String()).Msg("Failed to load webhook")
+		errhandler.ServerError(w, errhandler.RespDBDataAccessFailure)
+		return webhook, false
+	}
+
+	return webhook, true
+}
+
+func (h *Handler) decodeWebhookRequest(w http.ResponseWriter, r *http.Request, reqID string) (WebhookRequest, bool) {
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error().Err(err).Str(l.KeyReqID, reqID).Msg("Failed to decode request")
+		errhandler.BadRequest(w, errhandler.RespInvalidRequestBody)
+		return req, false
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+		if err != nil {
+			h.Logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to marshal validation errors")
+			errhandler.ServerError(w, errhandler.RespJSONEncodeFailure)
+			return req, false
+		}
+		errhandler.ValidationErrors(w, respBody)
+		return req, false
+	}
+
+	if !strings.HasPrefix(req.URL, "http://") && !strings.HasPrefix(req.URL, "https://") {
+		errhandler.BadRequest(w, []byte(`{"error": "url must be an http or https URL"}`))
+		return req, false
+	}
+
+	for _, event := range req.Events {
+		if !slices.Contains(Events, event) {
+			respBody, _ := json.Marshal(errhandler.Error{Error: fmt.Sprintf("unknown event %q, supported events are %s", event, strings.Join(Events, ", "))})
+			errhandler.BadRequest(w, respBody)
+			return req, false
+		}
+	}
+
+	return req, true
+}
+
+func deliveryParams(webhookID pgtype.UUID, status, limit, cursor string) (db.ListWebhookDeliveriesParams, error) {
+	params := db.ListWebhookDeliveriesParams{WebhookID: webhookID, RowLimit: DefaultLimit}
+
+	switch status {
+	case "":
+	case StatusPending, StatusDelivered, StatusDead:
+		params.Status = pgtype.Text{String: status, Valid: true}
+	default:
+		return params, fmt.Errorf("status must be one of %s, %s or %s", StatusPending, StatusDelivered, StatusDead)
+	}
+
+	if limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > MaxLimit {
+			return params, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+		params.RowLimit = int32(n)
+	}
+
+	if cursor != "" {
+		createdAt, id, ok := decodeCursor(cursor)
+		if !ok {
+			return params, errors.New("invalid cursor")
+		}
+		params.BeforeCreatedAt = pgtype.Timestamptz{Time: createdAt, Valid: true}
+		params.BeforeID = id
+	}
+
+	return params, nil
+}
+
+// encodeCursor keeps the position of a delivery, the id breaks ties of deliveries stored at once
+func encodeCursor(createdAt time.Time, id pgtype.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+func decodeCursor(cursor string) (time.Time, pgtype.UUID, bool) {
+	var id pgtype.UUID
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, id, false
+	}
+
+	createdAt, rawID, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, id, false
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return time.Time{}, id, false
+	}
+	if err := id.Scan(rawID); err != nil {
+		return time.Time{}, id, false
+	}
+
+	return at, id, true
+}
+
+// generateSecret returns 32 random bytes as hex
+func generateSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// parseUUID scans a URL parameter, it writes a bad request and returns false when it is not a UUID
+func parseUUID(w http.ResponseWriter, value, message string) (pgtype.UUID, bool) {
+	var id pgtype.UUID
+	if err := id.Scan(value); err != nil {
+		respBody, _ := json.Marshal(errhandler.Error{Error: message})
+		errhandler.BadRequest(w, respBody)
+		return id, false
+	}
+	return id, true
+}
+
+// nonNil keeps an omitted filter an empty array, the columns are not null
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
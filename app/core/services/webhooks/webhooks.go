@@ -0,0 +1,96 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+// Headers of a delivery request
+const (
+	HeaderEvent     = "X-Fritz-Event"
+	HeaderDelivery  = "X-Fritz-Delivery"
+	HeaderTimestamp = "X-Fritz-Timestamp"
+	HeaderSignature = "X-Fritz-Signature"
+)
+
+// Events are the hooks a webhook can subscribe to, an event is named after its hook so plugins and
+// webhooks see the same events
+var Events = []string{
+	hooks.HookAfterEntityCreate,
+	hooks.HookAfterEntityUpdate,
+	hooks.HookAfterEntityDelete,
+	hooks.HookAfterEntityPublish,
+	hooks.HookAfterEntityUnpublish,
+	hooks.HookAfterDefinitionCreate,
+	hooks.HookAfterDefinitionUpdate,
+	hooks.HookAfterDefinitionDelete,
+}
+
+// Event is the body of a delivery, Data is the payload of the hook
+type Event struct {
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// Subject is what an event is about, the filters of a webhook are matched against it. The class of
+// a definition event is the definition ID and it has no path.
+type Subject struct {
+	Class string
+	Path  string
+}
+
+// Matches tells whether the webhook subscribes to the event. An empty filter matches everything, a
+// path prefix only matches entity events below it.
+func Matches(webhook db.Webhook, event string, subject Subject) bool {
+	if len(webhook.Events) > 0 && !slices.Contains(webhook.Events, event) {
+		return false
+	}
+	if len(webhook.Classes) > 0 && !slices.Contains(webhook.Classes, subject.Class) {
+		return false
+	}
+	if webhook.PathPrefix != "" && (subject.Path == "" || !strings.HasPrefix(subject.Path, webhook.PathPrefix)) {
+		return false
+	}
+	return true
+}
+
+// Sign returns the signature of a delivery, the hex HMAC-SHA256 of the timestamp and body joined
+// by a dot. Receivers recompute it with the shared secret and reject stale timestamps.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff is the wait before the next attempt after the given number of failed attempts, base
+// doubled with every attempt and capped at max
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	if attempts < 1 {
+		return base
+	}
+
+	wait := base
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= max || wait <= 0 {
+			return max
+		}
+	}
+	return min(wait, max)
+}
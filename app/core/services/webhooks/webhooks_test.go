@@ -0,0 +1,186 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/kernel/hooks"
+	"github.com/oriiyx/fritz/app/core/services/objects/definitions"
+	db "github.com/oriiyx/fritz/database/generated"
+)
+
+func TestMatches(t *testing.T) {
+	entity := Subject{Class: "Product", Path: "/products/tv"}
+	definition := Subject{Class: "Product"}
+
+	for _, tc := range []struct {
+		name    string
+		webhook db.Webhook
+		event   string
+		subject Subject
+		want    bool
+	}{
+		{"no filters", db.Webhook{}, hooks.HookAfterEntityCreate, entity, true},
+		{"event", db.Webhook{Events: []string{hooks.HookAfterEntityPublish}}, hooks.HookAfterEntityPublish, entity, true},
+		{"other event", db.Webhook{Events: []string{hooks.HookAfterEntityPublish}}, hooks.HookAfterEntityCreate, entity, false},
+		{"class", db.Webhook{Classes: []string{"Product", "Category"}}, hooks.HookAfterEntityUpdate, entity, true},
+		{"other class", db.Webhook{Classes: []string{"Category"}}, hooks.HookAfterEntityUpdate, entity, false},
+		{"path prefix", db.Webhook{PathPrefix: "/products/"}, hooks.HookAfterEntityDelete, entity, true},
+		{"other path prefix", db.Webhook{PathPrefix: "/blog/"}, hooks.HookAfterEntityDelete, entity, false},
+		{"definition without path prefix", db.Webhook{Classes: []string{"Product"}}, hooks.HookAfterDefinitionUpdate, definition, true},
+		{"definition with path prefix", db.Webhook{PathPrefix: "/"}, hooks.HookAfterDefinitionUpdate, definition, false},
+	} {
+		if got := Matches(tc.webhook, tc.event, tc.subject); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestSubjectOf(t *testing.T) {
+	entity := db.Entity{OKey: "tv", OPath: "/products/tv"}
+
+	subject, ok := subjectOf(&hooks.EntityPublishPayload{Class: "Product", Entity: entity})
+	if !ok || subject != (Subject{Class: "Product", Path: "/products/tv"}) {
+		t.Errorf("unexpected entity subject %+v", subject)
+	}
+
+	subject, ok = subjectOf(&hooks.DefinitionPayload{Definition: &definitions.EntityDefinition{ID: "Product"}})
+	if !ok || subject != (Subject{Class: "Product"}) {
+		t.Errorf("unexpected definition subject %+v", subject)
+	}
+
+	if _, ok := subjectOf("unknown"); ok {
+		t.Error("expected an unknown payload to have no subject")
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{"event":"entity.after_create"}' | openssl dgst -sha256 -hmac secret
+	want := "sha256=b3d2340df9d38d34432e9a07ef0b093a1610a3ec6cf184b6bcb5be04532c0c08"
+	if got := Sign("secret", "1700000000", []byte(`{"event":"entity.after_create"}`)); got != want {
+		t.Errorf("expected signature %q, got %q", want, got)
+	}
+	if got := Sign("secret", "1700000001", []byte(`{"event":"entity.after_create"}`)); got == want {
+		t.Error("expected the timestamp to change the signature")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := 30*time.Second, 10*time.Minute
+	for attempts, want := range map[int]time.Duration{
+		0:   30 * time.Second,
+		1:   30 * time.Second,
+		2:   time.Minute,
+		3:   2 * time.Minute,
+		5:   8 * time.Minute,
+		6:   10 * time.Minute,
+		100: 10 * time.Minute,
+	} {
+		if got := Backoff(attempts, base, max); got != want {
+			t.Errorf("attempt %d: expected %v, got %v", attempts, want, got)
+		}
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, BackoffBase: time.Minute, BackoffMax: time.Hour}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	delivered := policy.outcome(db.ClaimDueWebhookDeliveryRow{}, now, http.StatusOK, nil)
+	if delivered.Status != StatusDelivered || !delivered.DeliveredAt.Valid || delivered.LastError.Valid || delivered.LastStatusCode.Int32 != http.StatusOK {
+		t.Errorf("unexpected delivered outcome %+v", delivered)
+	}
+
+	retried := policy.outcome(db.ClaimDueWebhookDeliveryRow{Attempts: 1}, now, http.StatusBadGateway, errors.New("unexpected response status 502"))
+	if retried.Status != StatusPending || !retried.NextAttemptAt.Time.Equal(now.Add(2*time.Minute)) || retried.DeliveredAt.Valid {
+		t.Errorf("unexpected retried outcome %+v", retried)
+	}
+
+	dead := policy.outcome(db.ClaimDueWebhookDeliveryRow{Attempts: 2}, now, 0, errors.New("connection refused"))
+	if dead.Status != StatusDead || dead.LastStatusCode.Valid || dead.LastError.String != "connection refused" {
+		t.Errorf("unexpected dead outcome %+v", dead)
+	}
+}
+
+func TestSend(t *testing.T) {
+	payload := []byte(`{"event":"entity.after_create","data":{}}`)
+	status := http.StatusNoContent
+
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	worker := NewWorker(nil, nil, nil, 0, Policy{}, time.Second)
+	delivery := db.ClaimDueWebhookDeliveryRow{
+		ID:      pgtype.UUID{Bytes: [16]byte{1}, Valid: true},
+		Event:   hooks.HookAfterEntityCreate,
+		Payload: payload,
+		Url:     server.URL,
+		Secret:  "secret",
+	}
+
+	code, err := worker.send(context.Background(), delivery)
+	if err != nil || code != http.StatusNoContent {
+		t.Fatalf("expected a delivered request, got %d %v", code, err)
+	}
+
+	if string(body) != string(payload) {
+		t.Errorf("expected body %s, got %s", payload, body)
+	}
+	if got := received.Header.Get(HeaderEvent); got != hooks.HookAfterEntityCreate {
+		t.Errorf("unexpected event header %q", got)
+	}
+	if got := received.Header.Get(HeaderDelivery); got != delivery.ID.String() {
+		t.Errorf("unexpected delivery header %q", got)
+	}
+	timestamp := received.Header.Get(HeaderTimestamp)
+	if got, want := received.Header.Get(HeaderSignature), Sign("secret", timestamp, payload); got != want {
+		t.Errorf("expected signature %q, got %q", want, got)
+	}
+
+	status = http.StatusInternalServerError
+	if code, err := worker.send(context.Background(), delivery); err == nil || code != http.StatusInternalServerError {
+		t.Errorf("expected a failed attempt, got %d %v", code, err)
+	}
+}
+
+func TestDeliveryParams(t *testing.T) {
+	params, err := deliveryParams(pgtype.UUID{}, StatusDead, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.RowLimit != DefaultLimit || params.Status.String != StatusDead || params.BeforeCreatedAt.Valid {
+		t.Errorf("unexpected params %+v", params)
+	}
+
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 600, time.UTC)
+	id := pgtype.UUID{Bytes: [16]byte{1, 2, 3}, Valid: true}
+	params, err = deliveryParams(pgtype.UUID{}, "", "10", encodeCursor(createdAt, id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.RowLimit != 10 || !params.BeforeCreatedAt.Time.Equal(createdAt) || params.BeforeID != id {
+		t.Errorf("unexpected params %+v", params)
+	}
+
+	for _, args := range [][3]string{
+		{"failed", "", ""},
+		{"", "0", ""},
+		{"", "", "bm8gc2VwYXJhdG9y"},
+	} {
+		if _, err := deliveryParams(pgtype.UUID{}, args[0], args[1], args[2]); err == nil {
+			t.Errorf("expected %v to be invalid", args)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/oriiyx/fritz/database/generated"
+	"github.com/rs/zerolog"
+)
+
+// Beginner starts transactions, it is satisfied by *pgxpool.Pool and pgx.Tx
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Policy decides when a failed delivery is tried again. The wait starts at BackoffBase and doubles
+// with every failed attempt up to BackoffMax, after MaxAttempts attempts the delivery is dead.
+type Policy struct {
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+// Worker sends due webhook deliveries
+type Worker struct {
+	conn     Beginner
+	queries  *db.Queries
+	logger   *zerolog.Logger
+	interval time.Duration
+	policy   Policy
+	client   *http.Client
+}
+
+func NewWorker(conn Beginner, queries *db.Queries, logger *zerolog.Logger, interval time.Duration, policy Policy, timeout time.Duration) *Worker {
+	return &Worker{
+		conn:     conn,
+		queries:  queries,
+		logger:   logger,
+		interval: interval,
+		policy:   policy,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// Run sends due deliveries right away and then every interval until ctx is done. A zero interval
+// disables outbound webhooks, deliveries are still stored and sent once it is enabled.
+func (wk *Worker) Run(ctx context.Context) {
+	if wk.interval <= 0 {
+		wk.logger.Info().Msg("Webhook delivery is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(wk.interval)
+	defer ticker.Stop()
+
+	for {
+		sent, err := wk.RunDue(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			wk.logger.Error().Err(err).Int("sent", sent).Msg("Failed to send webhook deliveries")
+		} else if sent > 0 {
+			wk.logger.Info().Int("sent", sent).Msg("Sent webhook deliveries")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue attempts every delivery due at now, each in its own transaction, and returns how many
+// were attempted. The outcome of a failed attempt is recorded on the delivery, it is not an error.
+func (wk *Worker) RunDue(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+	for {
+		ran, err := wk.runNext(ctx, pgtype.Timestamptz{Time: now, Valid: true})
+		if err != nil || !ran {
+			return sent, err
+		}
+		sent++
+	}
+}
+
+// runNext claims the oldest due delivery and attempts it, it returns false when none is due. The
+// delivery stays locked while it is sent so no other worker sends it twice.
+func (wk *Worker) runNext(ctx context.Context, now pgtype.Timestamptz) (bool, error) {
+	ran := false
+	err := pgx.BeginFunc(ctx, wk.conn, func(tx pgx.Tx) error {
+		queries := wk.queries.WithTx(tx)
+
+		delivery, err := queries.ClaimDueWebhookDelivery(ctx, now)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ran = true
+
+		statusCode, sendErr := wk.send(ctx, delivery)
+		if sendErr != nil {
+			wk.logger.Warn().Err(sendErr).
+				Str("delivery_id", delivery.ID.String()).
+				Str("webhook_id", delivery.WebhookID.String()).
+				Str("event", delivery.Event).
+				Msg("Webhook delivery failed")
+		}
+
+		return queries.FinishWebhookDeliveryAttempt(ctx, wk.policy.outcome(delivery, time.Now(), statusCode, sendErr))
+	})
+
+	return ran, err
+}
+
+// send posts the delivery to its webhook and returns the status code of the response, any status
+// outside 2xx is an error
+func (wk *Worker) send(ctx context.Context, delivery db.ClaimDueWebhookDeliveryRow) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := wk.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// The body is drained so the connection can be reused, receivers have nothing to tell
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// outcome records an attempt made at now. A failed attempt is retried after the backoff unless it
+// was the last one, then the delivery is dead.
+func (p Policy) outcome(delivery db.ClaimDueWebhookDeliveryRow, now time.Time, statusCode int, err error) db.FinishWebhookDeliveryAttemptParams {
+	params := db.FinishWebhookDeliveryAttemptParams{
+		ID:            delivery.ID,
+		Status:        StatusDelivered,
+		NextAttemptAt: pgtype.Timestamptz{Time: now, Valid: true},
+	}
+	if statusCode != 0 {
+		params.LastStatusCode = pgtype.Int4{Int32: int32(statusCode), Valid: true}
+	}
+
+	if err == nil {
+		params.DeliveredAt = pgtype.Timestamptz{Time: now, Valid: true}
+		return params
+	}
+
+	params.LastError = pgtype.Text{String: err.Error(), Valid: true}
+
+	attempts := int(delivery.Attempts) + 1
+	if attempts >= p.MaxAttempts {
+		params.Status = StatusDead
+		return params
+	}
+
+	params.Status = StatusPending
+	params.NextAttemptAt.Time = now.Add(Backoff(attempts, p.BackoffBase, p.BackoffMax))
+	return params
+}
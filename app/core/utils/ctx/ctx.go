@@ -17,3 +17,17 @@ func RequestID(ctx context.Context) string {
 func SetRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, keyRequestID, requestID)
 }
+
+const keyClientIP key = "clientIP"
+
+// ClientIP Get the address of the client of the request from context.
+func ClientIP(ctx context.Context) string {
+	clientIP, _ := ctx.Value(keyClientIP).(string)
+
+	return clientIP
+}
+
+// SetClientIP Set the address of the client of the request in context.
+func SetClientIP(ctx context.Context, clientIP string) context.Context {
+	return context.WithValue(ctx, keyClientIP, clientIP)
+}
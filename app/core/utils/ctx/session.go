@@ -3,6 +3,7 @@ package ctx
 import (
 	"context"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/oriiyx/fritz/database/generated"
 )
 
@@ -18,3 +19,11 @@ func GetSession(ctx context.Context) *db.Session {
 func SetSession(ctx context.Context, session *db.Session) context.Context {
 	return context.WithValue(ctx, sessionKey, session)
 }
+
+// GetUserID Get the user of the session in context, it is not valid without a session.
+func GetUserID(ctx context.Context) pgtype.UUID {
+	if session := GetSession(ctx); session != nil {
+		return session.UserIdentityID
+	}
+	return pgtype.UUID{}
+}
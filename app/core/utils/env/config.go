@@ -13,6 +13,12 @@ type Conf struct {
 	Auth         ConfAuth
 	Server       ConfServer
 	Session      ConfSession
+	Definitions  ConfDefinitions
+	Trash        ConfTrash
+	Versions     ConfVersions
+	Publishing   ConfPublishing
+	Locks        ConfLocks
+	Webhooks     ConfWebhooks
 	GoogleAuth   ConfGoogleAuth
 	GithubAuth   ConfGithubAuth
 	IsProduction bool
@@ -25,6 +31,10 @@ type ConfServer struct {
 	Debug  bool   `env:"SERVER_DEBUG,required"`
 	Secret []byte `env:"SECRET_KEY,required"`
 
+	// TrustProxy takes the client address from the X-Forwarded-For and X-Real-IP headers, only
+	// enable it behind a proxy that sets them
+	TrustProxy bool `env:"SERVER_TRUST_PROXY,default=false"`
+
 	CORSMaxAge           *int     `env:"CORS_MAX_AGE"`
 	CORSOrigins          []string `env:"CORS_ORIGINS"`
 	CORSMethods          []string `env:"CORS_METHODS"`
@@ -66,6 +76,46 @@ type ConfSession struct {
 	SessionCookieName string        `env:"SESSION_COOKIE_NAME,required"`
 }
 
+type ConfDefinitions struct {
+	// DriftPolicy decides how startup reacts when compiled adapters drift from stored definitions: strict, warn or off
+	DriftPolicy string `env:"DEFINITIONS_DRIFT_POLICY,default=warn"`
+}
+
+type ConfTrash struct {
+	// Retention is how long trashed entities are kept before the purger deletes them, zero keeps them forever
+	Retention     time.Duration `env:"TRASH_RETENTION,default=720h"`
+	PurgeInterval time.Duration `env:"TRASH_PURGE_INTERVAL,default=1h"`
+}
+
+type ConfVersions struct {
+	// KeepLast and MaxAge keep a version while it is among the newest KeepLast or younger than MaxAge,
+	// zero disables a rule and with both zero the history is never pruned
+	KeepLast int           `env:"VERSIONS_KEEP_LAST,default=50"`
+	MaxAge   time.Duration `env:"VERSIONS_MAX_AGE,default=0s"`
+}
+
+type ConfPublishing struct {
+	// ScheduleInterval is how often due publish schedules are run, zero disables scheduled publishing
+	ScheduleInterval time.Duration `env:"PUBLISH_SCHEDULE_INTERVAL,default=1m"`
+}
+
+type ConfLocks struct {
+	// TTL is how long an edit lock is held without a heartbeat
+	TTL time.Duration `env:"LOCK_TTL,default=2m"`
+}
+
+type ConfWebhooks struct {
+	// DeliveryInterval is how often due webhook deliveries are sent, zero disables outbound webhooks
+	DeliveryInterval time.Duration `env:"WEBHOOK_DELIVERY_INTERVAL,default=10s"`
+
+	// MaxAttempts is how often a delivery is tried before it is dead, retries wait BackoffBase doubled
+	// with every failed attempt up to BackoffMax
+	MaxAttempts int           `env:"WEBHOOK_MAX_ATTEMPTS,default=8"`
+	BackoffBase time.Duration `env:"WEBHOOK_BACKOFF_BASE,default=30s"`
+	BackoffMax  time.Duration `env:"WEBHOOK_BACKOFF_MAX,default=6h"`
+	Timeout     time.Duration `env:"WEBHOOK_TIMEOUT,default=10s"`
+}
+
 func (c *Conf) GetBaseURL() string {
 	return fmt.Sprintf("%s:%v", c.Server.URL, c.Server.Port)
 }
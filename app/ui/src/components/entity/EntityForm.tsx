@@ -38,7 +38,8 @@ export function EntityForm({entity, definition, onSaveSuccess}: EntityFormProps)
     const initialFormData = useMemo(() => {
         // If in edit mode and data is available, use it
         if (isEditMode && entityData) {
-            return entityData.data
+            // Published entities are edited through their draft
+            return entityData.draft?.data ?? entityData.data
         }
 
         // Transition mode: Initialize with defaults
@@ -87,7 +88,6 @@ export function EntityForm({entity, definition, onSaveSuccess}: EntityFormProps)
                     parent_id: entity.parent_id,
                     key: entity.o_key,
                     path: entity.o_path,
-                    published: entity.published,
                     type: entity.o_type,
                     data: formData,
                 })
@@ -21,7 +21,8 @@ export interface Entity {
 export interface CreateEntityRequest {
     parent_id?: string | null
     key: string
-    path: string
+    // Derived from the parent when omitted, a given path must match it
+    path?: string
     type?: string
     published: boolean
 }
@@ -33,7 +34,6 @@ export interface SaveEntityRequest {
     key: string
     path: string
     type?: string
-    published: boolean
     data: Record<string, unknown>
 }
 
@@ -53,10 +53,22 @@ export interface ModifyEntityResponse {
     data: Record<string, unknown>
 }
 
+// Pending edits of a published entity
+export interface EntityDraft {
+    entity_id: string
+    entity_class: string
+    data: Record<string, unknown>
+    base_version: number
+    updated_by: string | null
+    created_at: string
+    updated_at: string
+}
+
 // Response from reading entity data
 export interface ReadEntityResponse {
     entity: Entity
     data: Record<string, unknown>
+    draft?: EntityDraft | null
 }
 
 export const entitiesApi = {
@@ -79,7 +91,8 @@ export const entitiesApi = {
 
     /**
      * Read entity data (requires has_data === true)
-     * This fetches the actual data stored in entity_{class_id} table
+     * This fetches the actual data stored in entity_{class_id} table along with
+     * the pending draft, unpublished entities are only readable this way
      */
     readEntity: async (
         definitionId: string,
@@ -88,7 +101,7 @@ export const entitiesApi = {
         try {
             const response = await apiClient.post<ReadEntityResponse>(
                 `/api/v1/entities/${definitionId}/read`,
-                {id: entityId}
+                {id: entityId, draft: true}
             )
             return response.data
         } catch (error: unknown) {
@@ -9,17 +9,27 @@ export interface TreeNode {
     o_path: string
     o_type: 'folder' | 'object' | 'variant'
     published: boolean
+    sort_index: number
     created_at: string
     updated_at: string
     has_children: boolean
     children_count: number
 }
 
+export type TreeSort = 'default' | 'key' | 'created_at' | 'updated_at' | 'index'
+
 export interface GetChildrenParams {
     parent_id: string
     limit?: number
     offset?: number
+    // Continues after the previous page, cannot be combined with offset
+    cursor?: string
     definition_id?: string
+    o_type?: TreeNode['o_type']
+    published?: boolean
+    q?: string
+    sort?: TreeSort
+    desc?: boolean
 }
 
 export interface GetChildrenResponse {
@@ -28,6 +38,7 @@ export interface GetChildrenResponse {
     limit: number
     offset: number
     has_more: boolean
+    next_cursor?: string
 }
 
 export const treeApi = {
@@ -40,7 +51,13 @@ export const treeApi = {
                     parent_id: params.parent_id,
                     limit: params.limit || 25,
                     offset: params.offset || 0,
+                    cursor: params.cursor,
                     definition_id: params.definition_id,
+                    o_type: params.o_type,
+                    published: params.published,
+                    q: params.q,
+                    sort: params.sort,
+                    desc: params.desc,
                 }
             )
             return response.data
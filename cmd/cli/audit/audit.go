@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/oriiyx/fritz/app/core/services/audit"
+	"github.com/oriiyx/fritz/cmd/cli/config"
+	"github.com/spf13/cobra"
+)
+
+func NewAuditCmd(deps *config.Dependencies) *cobra.Command {
+	var (
+		q     audit.Query
+		since string
+		until string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Query the audit log",
+		Long: `Query the append-only audit log of entity and definition operations, the CLI twin of the
+audit API.
+
+Entries are written newest first as JSON Lines. When more entries match, the cursor of the next
+page is printed to standard error.`,
+		Example: `  # Everything a user did since the start of the year
+  fritz audit --actor 7d0c... --since 2026-01-01T00:00:00Z
+
+  # The history of one entity
+  fritz audit --target-type entity --target-id 1f3a... --limit 200`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, bound := range []struct {
+				flag  string
+				value string
+				dest  *time.Time
+			}{{"--since", since, &q.Since}, {"--until", until, &q.Until}} {
+				if bound.value == "" {
+					continue
+				}
+				t, err := time.Parse(time.RFC3339, bound.value)
+				if err != nil {
+					return fmt.Errorf("%s must be an RFC 3339 time", bound.flag)
+				}
+				*bound.dest = t
+			}
+
+			page, err := audit.List(cmd.Context(), deps.Queries, q)
+			if err != nil {
+				return err
+			}
+
+			encoder := json.NewEncoder(os.Stdout)
+			for _, entry := range page.Items {
+				if err := encoder.Encode(entry); err != nil {
+					return err
+				}
+			}
+
+			if page.NextCursor != "" {
+				_, _ = fmt.Fprintf(os.Stderr, "More entries follow, continue with --cursor %s\n", page.NextCursor)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&q.ActorID, "actor", "", "only entries of the user with this ID")
+	cmd.Flags().StringVar(&q.Action, "action", "", "only entries of this action, such as update or publish")
+	cmd.Flags().StringVar(&q.TargetType, "target-type", "", "only entries of this target type: entity or definition")
+	cmd.Flags().StringVar(&q.TargetID, "target-id", "", "only entries of the target with this ID")
+	cmd.Flags().StringVar(&since, "since", "", "only entries at or after this RFC 3339 time")
+	cmd.Flags().StringVar(&until, "until", "", "only entries before this RFC 3339 time")
+	cmd.Flags().IntVar(&q.Limit, "limit", audit.DefaultLimit, fmt.Sprintf("entries per page, at most %d", audit.MaxLimit))
+	cmd.Flags().StringVar(&q.Cursor, "cursor", "", "continue after the page that printed this cursor")
+
+	return cmd
+}
@@ -2,11 +2,13 @@ package config
 
 import (
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oriiyx/fritz/app/core/utils/env"
 	db "github.com/oriiyx/fritz/database/generated"
 	"github.com/rs/zerolog"
 )
 
 type Dependencies struct {
+	Conf    *env.Conf
 	DB      *pgxpool.Pool
 	Queries *db.Queries
 	Logger  *zerolog.Logger
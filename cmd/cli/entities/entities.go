@@ -0,0 +1,31 @@
+package entities
+
+import (
+	"github.com/oriiyx/fritz/cmd/cli/config"
+	"github.com/spf13/cobra"
+)
+
+func NewEntitiesCmd(deps *config.Dependencies) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "entities",
+		Short: "Manage Fritz entities",
+		Long: `Manage entity instances of the Fritz system.
+
+Provides bulk operations on entities such as importing supplier files and exporting data
+for downstream channels.`,
+		Example: `  # Import a CSV file with a saved import profile
+  fritz entities import products.csv --profile 7d0c...
+
+  # Export every product as JSON Lines
+  fritz entities export product --format jsonl --output products.jsonl`,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Usage()
+		},
+	}
+
+	// Add subcommands with dependencies
+	cmd.AddCommand(NewImportCmd(deps))
+	cmd.AddCommand(NewExportCmd(deps))
+
+	return cmd
+}
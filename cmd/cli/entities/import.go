@@ -0,0 +1,182 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/oriiyx/fritz/app/core/kernel"
+	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/versions"
+	"github.com/oriiyx/fritz/app/core/services/imports/pipeline"
+	"github.com/oriiyx/fritz/app/core/services/objects/definition_builder"
+	"github.com/oriiyx/fritz/app/core/services/webhooks"
+	"github.com/oriiyx/fritz/app/core/utils/rw"
+	"github.com/oriiyx/fritz/cmd/cli/config"
+	db "github.com/oriiyx/fritz/database/generated"
+	"github.com/spf13/cobra"
+)
+
+func NewImportCmd(deps *config.Dependencies) *cobra.Command {
+	var (
+		profileID   string
+		classID     string
+		mappingFile string
+		format      string
+		dryRun      bool
+		batchSize   int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import [file]",
+		Short: "Import entities from a CSV or JSON Lines file",
+		Long: `Import entities from a CSV or JSON Lines file, the CLI twin of the imports API.
+
+Rows are mapped with a saved import profile, or with a class, mapping file and format.
+Rows are matched on o_path and o_key: missing entities are created, existing ones updated.
+The o_path is the full path of the entity, like /products/anvil for the key anvil.
+Every row is validated first, rows that fail are reported and skipped.
+With --dry-run nothing is written and the report shows what would happen.`,
+		Example: `  # Validate a supplier file against a saved profile
+  fritz entities import products.csv --profile 7d0c3b1e-8f43-4a57-9d2e-0c1f0e4b6a55 --dry-run
+
+  # Import with an ad-hoc mapping
+  fritz entities import products.jsonl --class product --mapping mapping.json --format jsonl`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("cannot read import file: %w", err)
+			}
+
+			adapters.LoadAll(deps.Queries)
+			eb := definition_builder.NewDefinitionsBuilder(deps.Logger, deps.DB, rw.New(deps.Logger))
+			policy := versions.Policy{KeepLast: deps.Conf.Versions.KeepLast, MaxAge: deps.Conf.Versions.MaxAge}
+
+			// The CLI loads no plugins, the webhooks still learn about the imported entities and
+			// the server delivers them
+			hooks := kernel.NewHooks()
+			webhooks.NewDispatcher(deps.Queries, deps.Logger).Subscribe(hooks)
+
+			var (
+				p      *pipeline.Pipeline
+				run    db.ImportRun
+				err    error
+				source pgtype.UUID
+			)
+
+			switch {
+			case profileID != "":
+				if err := source.Scan(profileID); err != nil {
+					return fmt.Errorf("invalid profile id: %s", profileID)
+				}
+				profile, err := deps.Queries.GetImportProfile(cmd.Context(), source)
+				if err != nil {
+					return fmt.Errorf("failed to load import profile: %w", err)
+				}
+				p, err = pipeline.FromProfile(deps.DB, deps.Queries, hooks, eb, profile, policy)
+				if err != nil {
+					return err
+				}
+				classID = profile.EntityClass
+				format = profile.Format
+
+			case classID != "" && mappingFile != "":
+				raw, err := os.ReadFile(mappingFile)
+				if err != nil {
+					return fmt.Errorf("failed to read mapping file: %w", err)
+				}
+				var mapping pipeline.Mapping
+				if err := json.Unmarshal(raw, &mapping); err != nil {
+					return fmt.Errorf("failed to decode mapping file: %w", err)
+				}
+				p, err = pipeline.ForClass(deps.DB, deps.Queries, hooks, eb, classID, mapping, policy)
+				if err != nil {
+					return err
+				}
+
+			default:
+				return errors.New("either --profile or --class with --mapping is required")
+			}
+
+			if !pipeline.Format(format).Valid() {
+				return fmt.Errorf("unsupported format %q, use csv or jsonl", format)
+			}
+
+			run, err = deps.Queries.CreateImportRun(cmd.Context(), db.CreateImportRunParams{
+				ProfileID:   source,
+				EntityClass: classID,
+				DryRun:      dryRun,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create import run: %w", err)
+			}
+
+			opts := pipeline.Options{
+				DryRun:    dryRun,
+				BatchSize: batchSize,
+				OnProgress: func(progress pipeline.Progress) error {
+					deps.Logger.Info().Msgf("  %d/%d rows processed, %d failed", progress.Processed, progress.Total, progress.Failed)
+					return nil
+				},
+				OnHookError: func(name string, err error) {
+					deps.Logger.Warn().Err(err).Str("hook", name).Msg("Hook failed after an import batch")
+				},
+			}
+
+			deps.Logger.Info().Str("run_id", run.ID.String()).Str("class_id", classID).Bool("dry_run", dryRun).Msg("Import started")
+
+			run, err = p.Execute(cmd.Context(), run, pipeline.Format(format), path, opts)
+			printReport(deps, run)
+			if err != nil {
+				return fmt.Errorf("import failed: %w", err)
+			}
+			if run.FailedRows > 0 {
+				return fmt.Errorf("%d rows failed", run.FailedRows)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&profileID, "profile", "", "ID of a saved import profile")
+	cmd.Flags().StringVar(&classID, "class", "", "entity class to import into, used with --mapping")
+	cmd.Flags().StringVar(&mappingFile, "mapping", "", "JSON file with the field mapping, used with --class")
+	cmd.Flags().StringVar(&format, "format", string(pipeline.FormatCSV), "file format when no profile is used: csv or jsonl")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "validate every row without writing")
+	cmd.Flags().IntVar(&batchSize, "batch-size", pipeline.DefaultBatchSize, "rows processed between progress updates")
+
+	return cmd
+}
+
+func printReport(deps *config.Dependencies, run db.ImportRun) {
+	verb := "Imported"
+	if run.DryRun {
+		verb = "Dry run"
+	}
+
+	deps.Logger.Info().Msg("\n\n")
+	deps.Logger.Info().Msgf("%s: %s", verb, run.Status)
+	deps.Logger.Info().Msgf("  Run ID   : %s", run.ID.String())
+	deps.Logger.Info().Msgf("  Rows     : %d", run.TotalRows)
+	deps.Logger.Info().Msgf("  Created  : %d", run.CreatedRows)
+	deps.Logger.Info().Msgf("  Updated  : %d", run.UpdatedRows)
+	deps.Logger.Info().Msgf("  Failed   : %d", run.FailedRows)
+
+	var rowErrors []pipeline.RowError
+	if err := json.Unmarshal(run.RowErrors, &rowErrors); err != nil {
+		return
+	}
+	for _, rowErr := range rowErrors {
+		if rowErr.Message != "" {
+			deps.Logger.Warn().Msgf("  row %d %s: %s", rowErr.Row, rowErr.Key, rowErr.Message)
+		}
+		for field, messages := range rowErr.Errors {
+			for _, message := range messages {
+				deps.Logger.Warn().Msgf("  row %d %s: %s %s", rowErr.Row, rowErr.Key, field, message)
+			}
+		}
+	}
+}
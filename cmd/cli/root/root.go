@@ -11,8 +11,10 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/oriiyx/fritz/app/core/utils/env"
 	logger2 "github.com/oriiyx/fritz/app/core/utils/logger"
+	"github.com/oriiyx/fritz/cmd/cli/audit"
 	"github.com/oriiyx/fritz/cmd/cli/config"
 	"github.com/oriiyx/fritz/cmd/cli/definitions"
+	"github.com/oriiyx/fritz/cmd/cli/entities"
 	"github.com/oriiyx/fritz/cmd/cli/users"
 	"github.com/oriiyx/fritz/cmd/cli/version"
 	db "github.com/oriiyx/fritz/database/generated"
@@ -86,6 +88,8 @@ Use 'fritz [command] --help' for more information about a command.`,
 	// Add subcommands with injected dependencies
 	cmd.AddCommand(users.NewUsersCmd(deps))
 	cmd.AddCommand(definitions.NewDefinitionsCmd(deps))
+	cmd.AddCommand(entities.NewEntitiesCmd(deps))
+	cmd.AddCommand(audit.NewAuditCmd(deps))
 	cmd.AddCommand(newVersionCmd())
 
 	return cmd
@@ -142,6 +146,7 @@ func initDependencies() *config.Dependencies {
 	queries := db.New(pool)
 
 	return &config.Dependencies{
+		Conf:    conf,
 		DB:      pool,
 		Queries: queries,
 		Logger:  logger,
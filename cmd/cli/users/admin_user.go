@@ -0,0 +1,58 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/oriiyx/fritz/cmd/cli/config"
+	db "github.com/oriiyx/fritz/database/generated"
+	"github.com/spf13/cobra"
+)
+
+func NewAdminUserCmd(deps *config.Dependencies) *cobra.Command {
+	var revoke bool
+
+	cmd := &cobra.Command{
+		Use:   "admin [email]",
+		Short: "Grant or revoke administrator rights of a Fritz user",
+		Long: `Grant or revoke administrator rights of an existing user account.
+
+Administrators can break the edit locks other users hold on entities.`,
+		Example: `  # Make a user an administrator
+  fritz users admin admin@example.com
+
+  # Take administrator rights away again
+  fritz users admin admin@example.com --revoke`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			email := args[0]
+
+			user, err := deps.Queries.GetUserByEmail(cmd.Context(), email)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("no user with email %s", email)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to load user: %w", err)
+			}
+
+			err = deps.Queries.SetUserAdmin(cmd.Context(), db.SetUserAdminParams{ID: user.ID, IsAdmin: !revoke})
+			if err != nil {
+				deps.Logger.Error().Err(err).Str("email", email).Msg("Failed to update user")
+				return fmt.Errorf("failed to update user: %w", err)
+			}
+
+			deps.Logger.Info().
+				Str("email", email).
+				Str("user_id", user.ID.String()).
+				Bool("is_admin", !revoke).
+				Msg("User updated successfully")
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&revoke, "revoke", false, "revoke administrator rights instead of granting them")
+
+	return cmd
+}
@@ -16,6 +16,9 @@ All user operations require database access.`,
 		Example: `  # Create a new user
   fritz users create user@example.com password123
 
+  # Make a user an administrator
+  fritz users admin user@example.com
+
   # List all users (future)
   fritz users list`,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -25,6 +28,7 @@ All user operations require database access.`,
 
 	// Add subcommands with dependencies
 	cmd.AddCommand(NewCreateUserCmd(deps))
+	cmd.AddCommand(NewAdminUserCmd(deps))
 
 	return cmd
 }
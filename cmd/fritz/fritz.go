@@ -21,6 +21,12 @@ import (
 	"github.com/oriiyx/fritz/app/core/kernel"
 	"github.com/oriiyx/fritz/app/core/services"
 	"github.com/oriiyx/fritz/app/core/services/entities/adapters"
+	"github.com/oriiyx/fritz/app/core/services/entities/drift"
+	"github.com/oriiyx/fritz/app/core/services/entities/publishing"
+	"github.com/oriiyx/fritz/app/core/services/entities/trash"
+	"github.com/oriiyx/fritz/app/core/services/entities/versions"
+	"github.com/oriiyx/fritz/app/core/services/imports/pipeline"
+	"github.com/oriiyx/fritz/app/core/services/webhooks"
 	"github.com/oriiyx/fritz/app/core/utils/env"
 	logger2 "github.com/oriiyx/fritz/app/core/utils/logger"
 	"github.com/oriiyx/fritz/app/core/utils/rw"
@@ -92,6 +98,7 @@ func loadKernel(conf *env.Conf, l *zerolog.Logger, ctx context.Context) *kernel.
 	customWriter := rw.New(l)
 
 	adapters.LoadAll(queries)
+	checkDefinitionsDrift(ctx, conf, l, queries)
 
 	k := kernel.New()
 
@@ -127,6 +134,10 @@ func loadKernel(conf *env.Conf, l *zerolog.Logger, ctx context.Context) *kernel.
 		l.Fatal().Err(err).Msg("Failed to register custom writer service.")
 	}
 
+	if err = k.Registry().Register(services.ImportRuns, pipeline.NewBackground()); err != nil {
+		l.Fatal().Err(err).Msg("Failed to register import runs service.")
+	}
+
 	return k
 }
 
@@ -138,6 +149,7 @@ func startApplication(ctx context.Context, k *kernel.Kernel, conf *env.Conf, l *
 	v := k.Registry().MustGet(services.Validator).(*validator.Validate)
 	store := k.Registry().MustGet(services.CookieStore).(*sessions.CookieStore)
 	cw := k.Registry().MustGet(services.CustomWriter).(*rw.CustomWriter)
+	importRuns := k.Registry().MustGet(services.ImportRuns).(*pipeline.Background)
 
 	// Create router controller
 	routerController := router.NewController(ctx, conf, pool, store, k, chiRouter, l, queries, v, cw)
@@ -157,12 +169,39 @@ func startApplication(ctx context.Context, k *kernel.Kernel, conf *env.Conf, l *
 		IdleTimeout:  conf.Server.TimeoutIdle,
 	}
 
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	purger := trash.NewPurger(pool, queries, l, conf.Trash.Retention, conf.Trash.PurgeInterval)
+	go purger.Run(workersCtx)
+
+	versionPolicy := versions.Policy{KeepLast: conf.Versions.KeepLast, MaxAge: conf.Versions.MaxAge}
+	scheduler := publishing.NewScheduler(pool, queries, l, k.Hooks(), conf.Publishing.ScheduleInterval, versionPolicy)
+	go scheduler.Run(workersCtx)
+
+	webhooks.NewDispatcher(queries, l).Subscribe(k.Hooks())
+	webhookPolicy := webhooks.Policy{
+		MaxAttempts: conf.Webhooks.MaxAttempts,
+		BackoffBase: conf.Webhooks.BackoffBase,
+		BackoffMax:  conf.Webhooks.BackoffMax,
+	}
+	webhookWorker := webhooks.NewWorker(pool, queries, l, conf.Webhooks.DeliveryInterval, webhookPolicy, conf.Webhooks.Timeout)
+	go webhookWorker.Run(workersCtx)
+
 	closed := make(chan struct{})
 	go func() {
 		sigint := make(chan os.Signal, 1)
 		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
 		<-sigint
 
+		stopWorkers()
+
+		// Runs in progress are cancelled and record their failure while the database is still open
+		l.Info().Msg("Stopping import runs")
+		runsCtx, cancelRuns := context.WithTimeout(context.Background(), conf.Server.TimeoutIdle)
+		if err := importRuns.Shutdown(runsCtx); err != nil {
+			l.Error().Err(err).Msg("Import runs did not stop in time")
+		}
+		cancelRuns()
+
 		l.Info().Msg("Shutting down Kernel")
 		err := k.Shutdown(ctx)
 		if err != nil {
@@ -196,6 +235,44 @@ func startApplication(ctx context.Context, k *kernel.Kernel, conf *env.Conf, l *
 	l.Info().Msgf("Server shutdown successfully")
 }
 
+// checkDefinitionsDrift compares compiled adapters with stored definitions and live tables
+// and either refuses to start or logs warnings depending on the configured policy
+func checkDefinitionsDrift(ctx context.Context, conf *env.Conf, l *zerolog.Logger, queries *db.Queries) {
+	policy := conf.Definitions.DriftPolicy
+	if err := drift.ValidatePolicy(policy); err != nil {
+		l.Fatal().Err(err).Msg("Invalid DEFINITIONS_DRIFT_POLICY")
+	}
+	if policy == drift.PolicyOff {
+		l.Info().Msg("Definitions drift check disabled")
+		return
+	}
+
+	report, err := drift.NewChecker(queries, policy).Check(ctx)
+	if err != nil {
+		if policy == drift.PolicyStrict {
+			l.Fatal().Err(err).Msg("Failed to check definitions drift")
+		}
+		l.Warn().Err(err).Msg("Failed to check definitions drift")
+		return
+	}
+
+	for _, class := range report.Classes {
+		for _, problem := range class.Problems {
+			l.Warn().
+				Str("class_id", class.ClassID).
+				Str("adapter_hash", class.AdapterHash).
+				Str("stored_hash", class.StoredHash).
+				Msg(problem)
+		}
+	}
+
+	if report.Drift && policy == drift.PolicyStrict {
+		l.Fatal().Msg("Compiled adapters drift from stored definitions, refusing to start")
+	}
+
+	l.Info().Bool("drift", report.Drift).Int("classes", len(report.Classes)).Msg("Definitions drift check completed")
+}
+
 func createCookieStore(conf *env.Conf) *sessions.CookieStore {
 	store := sessions.NewCookieStore(conf.Server.Secret)
 	store.MaxAge(conf.Auth.MaxAge)
@@ -0,0 +1,2 @@
+ALTER TABLE entities
+    DROP COLUMN IF EXISTS version;
@@ -0,0 +1,3 @@
+-- Version counter for optimistic concurrency control, bumped on every save
+ALTER TABLE entities
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
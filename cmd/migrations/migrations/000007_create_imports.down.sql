@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_import_runs_created_at;
+DROP INDEX IF EXISTS idx_import_runs_profile_id;
+DROP TABLE IF EXISTS import_runs;
+DROP TABLE IF EXISTS import_profiles;
\ No newline at end of file
@@ -0,0 +1,42 @@
+-- Import profiles - saved mappings from a source file layout to the components of an entity class
+CREATE TABLE IF NOT EXISTS import_profiles
+(
+    id           UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    name         TEXT        NOT NULL,
+    entity_class TEXT        NOT NULL, -- References the entity definition (e.g., 'product', 'customer')
+    format       TEXT        NOT NULL, -- Source format: 'csv' or 'jsonl'
+    mapping      JSONB       NOT NULL, -- Key, path and field mappings with their transforms
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT unique_import_profile_name UNIQUE (name),
+    CONSTRAINT valid_import_profile_format CHECK (format IN ('csv', 'jsonl'))
+);
+
+-- Import runs - one execution of a profile against an uploaded file, dry runs only validate
+CREATE TABLE IF NOT EXISTS import_runs
+(
+    id             UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    profile_id     UUID        NULL REFERENCES import_profiles (id) ON DELETE SET NULL,
+    entity_class   TEXT        NOT NULL,
+    dry_run        BOOLEAN     NOT NULL DEFAULT false,
+    status         TEXT        NOT NULL DEFAULT 'pending', -- 'pending', 'running', 'completed', 'failed'
+    total_rows     INTEGER     NOT NULL DEFAULT 0,
+    processed_rows INTEGER     NOT NULL DEFAULT 0,
+    created_rows   INTEGER     NOT NULL DEFAULT 0,
+    updated_rows   INTEGER     NOT NULL DEFAULT 0,
+    failed_rows    INTEGER     NOT NULL DEFAULT 0,
+    row_errors     JSONB       NOT NULL DEFAULT '[]', -- Per-row error report, capped by the pipeline
+    error          TEXT        NULL,                  -- Set when the run as a whole failed
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at     TIMESTAMPTZ NULL,
+    finished_at    TIMESTAMPTZ NULL,
+
+    CONSTRAINT valid_import_run_status CHECK (status IN ('pending', 'running', 'completed', 'failed'))
+);
+
+-- Index for listing the runs of a profile
+CREATE INDEX IF NOT EXISTS idx_import_runs_profile_id ON import_runs (profile_id);
+
+-- Index for listing the most recent runs
+CREATE INDEX IF NOT EXISTS idx_import_runs_created_at ON import_runs (created_at DESC);
\ No newline at end of file
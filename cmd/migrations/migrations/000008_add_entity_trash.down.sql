@@ -0,0 +1,18 @@
+-- Trashed entities are removed for good, they would otherwise reappear as live entities
+DELETE
+FROM entities
+WHERE id IN (SELECT entity_id FROM trash_items);
+
+DROP INDEX IF EXISTS unique_entity_path_key;
+ALTER TABLE entities
+    ADD CONSTRAINT unique_entity_path_key UNIQUE (o_path, o_key);
+
+DROP INDEX IF EXISTS idx_entities_trash_id;
+ALTER TABLE entities
+    DROP COLUMN IF EXISTS trash_id,
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS deleted_at;
+
+DROP INDEX IF EXISTS idx_trash_items_entity_class;
+DROP INDEX IF EXISTS idx_trash_items_deleted_at;
+DROP TABLE IF EXISTS trash_items;
\ No newline at end of file
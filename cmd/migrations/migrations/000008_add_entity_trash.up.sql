@@ -0,0 +1,29 @@
+-- Recycle bin, one item per delete request. The deleted entity and its descendants stay in the
+-- entities table, marked with deleted_at and the trash item, until the item is restored or purged
+CREATE TABLE IF NOT EXISTS trash_items
+(
+    id           UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    entity_id    UUID        NOT NULL REFERENCES entities (id) ON DELETE CASCADE, -- Root of the deleted subtree
+    entity_class TEXT        NOT NULL,
+    o_key        TEXT        NOT NULL,
+    o_path       TEXT        NOT NULL,
+    parent_id    UUID        NULL,                                               -- Original parent, restored to by default
+    entity_count INTEGER     NOT NULL DEFAULT 0,                                 -- Root and descendants moved to the trash
+    deleted_by   UUID        NULL REFERENCES users (id) ON DELETE SET NULL,
+    deleted_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_trash_items_deleted_at ON trash_items (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_trash_items_entity_class ON trash_items (entity_class);
+
+ALTER TABLE entities
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS deleted_by UUID        NULL REFERENCES users (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS trash_id   UUID        NULL REFERENCES trash_items (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_entities_trash_id ON entities (trash_id) WHERE trash_id IS NOT NULL;
+
+-- Trashed entities release their path and key so they can be reused
+ALTER TABLE entities
+    DROP CONSTRAINT IF EXISTS unique_entity_path_key;
+CREATE UNIQUE INDEX IF NOT EXISTS unique_entity_path_key ON entities (o_path, o_key) WHERE deleted_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_entity_versions_created_at;
+DROP TABLE IF EXISTS entity_versions;
\ No newline at end of file
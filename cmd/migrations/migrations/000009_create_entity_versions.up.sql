@@ -0,0 +1,19 @@
+-- Version history, one record per successful write of an entity with a snapshot of its metadata
+-- and class data as they were after the write
+CREATE TABLE IF NOT EXISTS entity_versions
+(
+    id           UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    entity_id    UUID        NOT NULL REFERENCES entities (id) ON DELETE CASCADE,
+    entity_class TEXT        NOT NULL,
+    version      BIGINT      NOT NULL, -- Entity version the snapshot was taken at
+    parent_id    UUID        NULL,
+    o_key        TEXT        NOT NULL,
+    o_path       TEXT        NOT NULL,
+    published    BOOLEAN     NOT NULL,
+    data         JSONB       NULL,     -- Class data row, NULL while the entity has no data
+    created_by   UUID        NULL REFERENCES users (id) ON DELETE SET NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_entity_version UNIQUE (entity_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_entity_versions_created_at ON entity_versions (entity_id, created_at);
\ No newline at end of file
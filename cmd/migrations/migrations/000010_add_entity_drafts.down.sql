@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_publish_schedules_due;
+DROP INDEX IF EXISTS idx_publish_schedules_entity_id;
+DROP TABLE IF EXISTS publish_schedules;
+DROP TABLE IF EXISTS entity_drafts;
\ No newline at end of file
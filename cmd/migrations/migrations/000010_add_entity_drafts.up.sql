@@ -0,0 +1,31 @@
+-- Draft workspace, at most one pending draft per entity. The class data row holds the published
+-- content, a draft holds the edits that publishing writes to it
+CREATE TABLE IF NOT EXISTS entity_drafts
+(
+    entity_id    UUID PRIMARY KEY REFERENCES entities (id) ON DELETE CASCADE,
+    entity_class TEXT        NOT NULL,
+    data         JSONB       NOT NULL,
+    base_version BIGINT      NOT NULL, -- Entity version the draft was last saved against
+    updated_by   UUID        NULL REFERENCES users (id) ON DELETE SET NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Publish and unpublish actions to run at a later time
+CREATE TABLE IF NOT EXISTS publish_schedules
+(
+    id          UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    entity_id   UUID        NOT NULL REFERENCES entities (id) ON DELETE CASCADE,
+    action      TEXT        NOT NULL,
+    run_at      TIMESTAMPTZ NOT NULL,
+    status      TEXT        NOT NULL DEFAULT 'pending',
+    error       TEXT        NULL,
+    created_by  UUID        NULL REFERENCES users (id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    executed_at TIMESTAMPTZ NULL,
+    CONSTRAINT valid_publish_schedule_action CHECK (action IN ('publish', 'unpublish')),
+    CONSTRAINT valid_publish_schedule_status CHECK (status IN ('pending', 'done', 'failed', 'cancelled'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_publish_schedules_entity_id ON publish_schedules (entity_id);
+CREATE INDEX IF NOT EXISTS idx_publish_schedules_due ON publish_schedules (run_at) WHERE status = 'pending';
\ No newline at end of file
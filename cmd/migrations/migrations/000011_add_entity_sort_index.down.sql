@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_entities_children_sort_index;
+DROP INDEX IF EXISTS idx_entities_children_updated_at;
+DROP INDEX IF EXISTS idx_entities_children_created_at;
+DROP INDEX IF EXISTS idx_entities_children_key;
+
+ALTER TABLE entities
+    DROP COLUMN IF EXISTS sort_index;
\ No newline at end of file
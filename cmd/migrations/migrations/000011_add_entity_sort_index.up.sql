@@ -0,0 +1,9 @@
+-- Manual position of an entity among its siblings, lower first
+ALTER TABLE entities
+    ADD COLUMN IF NOT EXISTS sort_index INTEGER NOT NULL DEFAULT 0;
+
+-- Keyset paging of children for each sort of the tree view
+CREATE INDEX IF NOT EXISTS idx_entities_children_key ON entities (parent_id, o_key, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_entities_children_created_at ON entities (parent_id, created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_entities_children_updated_at ON entities (parent_id, updated_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_entities_children_sort_index ON entities (parent_id, sort_index, o_key, id) WHERE deleted_at IS NULL;
\ No newline at end of file
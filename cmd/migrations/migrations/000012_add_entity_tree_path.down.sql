@@ -0,0 +1,15 @@
+DROP TRIGGER IF EXISTS entities_count_moved_children ON entities;
+DROP TRIGGER IF EXISTS entities_count_children ON entities;
+DROP TRIGGER IF EXISTS entities_move_subtree ON entities;
+DROP TRIGGER IF EXISTS entities_tree_path ON entities;
+
+DROP FUNCTION IF EXISTS entities_count_children();
+DROP FUNCTION IF EXISTS entities_move_subtree();
+DROP FUNCTION IF EXISTS entities_set_tree_path();
+
+DROP INDEX IF EXISTS idx_entities_tree_path;
+
+ALTER TABLE entities
+    DROP COLUMN IF EXISTS children_count,
+    DROP COLUMN IF EXISTS depth,
+    DROP COLUMN IF EXISTS tree_path;
\ No newline at end of file
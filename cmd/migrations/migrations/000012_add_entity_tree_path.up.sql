@@ -0,0 +1,131 @@
+-- Materialized hierarchy. tree_path lists the ids from the root down to the entity itself like
+-- /<root id>/<parent id>/<id>/, so every descendant of an entity starts with its tree_path. Ids never
+-- change, so a rename keeps it and only a move rewrites the subtree. The C collation lets one btree
+-- index serve both tree order and the descendant ranges, which run from the tree_path of an entity up
+-- to its tree_path followed by '~', a character above every character of an id.
+ALTER TABLE entities
+    ADD COLUMN IF NOT EXISTS tree_path      TEXT COLLATE "C" NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS depth          INTEGER        NOT NULL DEFAULT 0, -- The root is at depth 0
+    ADD COLUMN IF NOT EXISTS children_count INTEGER        NOT NULL DEFAULT 0; -- Live direct children
+
+WITH RECURSIVE tree AS (SELECT id, '/' || id::text || '/' AS tree_path, 0 AS depth
+                        FROM entities
+                        WHERE parent_id IS NULL
+
+                        UNION ALL
+
+                        SELECT e.id, t.tree_path || e.id::text || '/', t.depth + 1
+                        FROM entities e
+                                 INNER JOIN tree t ON e.parent_id = t.id)
+UPDATE entities
+SET tree_path = tree.tree_path,
+    depth     = tree.depth
+FROM tree
+WHERE entities.id = tree.id;
+
+UPDATE entities
+SET children_count = children.count
+FROM (SELECT parent_id, COUNT(*) AS count
+      FROM entities
+      WHERE parent_id IS NOT NULL
+        AND deleted_at IS NULL
+      GROUP BY parent_id) children
+WHERE entities.id = children.parent_id;
+
+CREATE INDEX IF NOT EXISTS idx_entities_tree_path ON entities (tree_path) WHERE deleted_at IS NULL;
+
+-- Derives tree_path and depth from the parent on insert and whenever parent_id is written
+CREATE OR REPLACE FUNCTION entities_set_tree_path()
+    RETURNS TRIGGER AS
+$$
+DECLARE
+    parent_path  TEXT;
+    parent_depth INTEGER;
+BEGIN
+    IF NEW.parent_id IS NULL THEN
+        NEW.tree_path := '/' || NEW.id::text || '/';
+        NEW.depth := 0;
+        RETURN NEW;
+    END IF;
+
+    SELECT tree_path, depth
+    INTO parent_path, parent_depth
+    FROM entities
+    WHERE id = NEW.parent_id;
+
+    -- A missing parent is reported by the foreign key
+    IF NOT FOUND THEN
+        RETURN NEW;
+    END IF;
+
+    IF parent_path LIKE '%/' || NEW.id::text || '/%' THEN
+        RAISE EXCEPTION 'entity % cannot be placed under its own descendant', NEW.id
+            USING ERRCODE = 'check_violation';
+    END IF;
+
+    NEW.tree_path := parent_path || NEW.id::text || '/';
+    NEW.depth := parent_depth + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Moves the descendants along when the tree_path of an entity changed
+CREATE OR REPLACE FUNCTION entities_move_subtree()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    UPDATE entities
+    SET tree_path = NEW.tree_path || substr(tree_path, length(OLD.tree_path) + 1),
+        depth     = depth + NEW.depth - OLD.depth
+    WHERE tree_path > OLD.tree_path
+      AND tree_path < OLD.tree_path || '~';
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Keeps children_count of the parents in step with inserts, deletes, moves, trash and restore
+CREATE OR REPLACE FUNCTION entities_count_children()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        IF OLD.parent_id IS NOT NULL AND OLD.deleted_at IS NULL THEN
+            UPDATE entities SET children_count = children_count - 1 WHERE id = OLD.parent_id;
+        END IF;
+    END IF;
+
+    IF TG_OP <> 'DELETE' THEN
+        IF NEW.parent_id IS NOT NULL AND NEW.deleted_at IS NULL THEN
+            UPDATE entities SET children_count = children_count + 1 WHERE id = NEW.parent_id;
+        END IF;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER entities_tree_path
+    BEFORE INSERT OR UPDATE OF parent_id
+    ON entities
+    FOR EACH ROW
+EXECUTE FUNCTION entities_set_tree_path();
+
+CREATE TRIGGER entities_move_subtree
+    AFTER UPDATE OF parent_id
+    ON entities
+    FOR EACH ROW
+    WHEN (OLD.tree_path IS DISTINCT FROM NEW.tree_path)
+EXECUTE FUNCTION entities_move_subtree();
+
+CREATE TRIGGER entities_count_children
+    AFTER INSERT OR DELETE
+    ON entities
+    FOR EACH ROW
+EXECUTE FUNCTION entities_count_children();
+
+CREATE TRIGGER entities_count_moved_children
+    AFTER UPDATE OF parent_id, deleted_at
+    ON entities
+    FOR EACH ROW
+    WHEN (OLD.parent_id IS DISTINCT FROM NEW.parent_id OR (OLD.deleted_at IS NULL) <> (NEW.deleted_at IS NULL))
+EXECUTE FUNCTION entities_count_children();
\ No newline at end of file
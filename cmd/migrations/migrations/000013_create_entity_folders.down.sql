@@ -0,0 +1 @@
+DROP TABLE IF EXISTS entity_folders;
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- Metadata of folder entities, folders have no data row of a class
+CREATE TABLE IF NOT EXISTS entity_folders
+(
+    entity_id       UUID PRIMARY KEY REFERENCES entities (id) ON DELETE CASCADE,
+    description     TEXT        NULL,
+    icon            TEXT        NULL,
+    allowed_classes TEXT[]      NOT NULL DEFAULT '{}', -- Classes of the children, empty allows every class
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Existing folders, including the root, allow every class
+INSERT INTO entity_folders (entity_id)
+SELECT id
+FROM entities
+WHERE o_type = 'folder'
+ON CONFLICT DO NOTHING;
\ No newline at end of file
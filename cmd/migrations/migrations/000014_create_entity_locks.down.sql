@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS entity_locks;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS is_admin;
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- Administrators can break the edit locks of other users
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT false;
+
+-- Edit locks, an entity is locked by one session of a user until the lock expires without a heartbeat
+CREATE TABLE IF NOT EXISTS entity_locks
+(
+    entity_id   UUID PRIMARY KEY REFERENCES entities (id) ON DELETE CASCADE,
+    user_id     UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    session_id  UUID        NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+    acquired_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_entity_locks_user_id ON entity_locks (user_id);
\ No newline at end of file
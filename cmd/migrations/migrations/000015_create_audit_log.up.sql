@@ -0,0 +1,40 @@
+-- Append-only trail of entity and definition operations. The actor is kept without a foreign key so
+-- the trail outlives deleted users, changes lists the changed fields with their old and new values.
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    id          UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    actor_id    UUID,
+    action      TEXT        NOT NULL,
+    target_type TEXT        NOT NULL,
+    target_id   TEXT        NOT NULL,
+    changes     JSONB       NOT NULL DEFAULT '[]',
+    request_id  TEXT,
+    ip          TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log (target_type, target_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_id ON audit_log (actor_id, created_at DESC);
+
+-- Entries are never changed or removed once written
+CREATE OR REPLACE FUNCTION audit_log_append_only()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only'
+        USING ERRCODE = 'insufficient_privilege';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE
+    ON audit_log
+    FOR EACH ROW
+EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE
+    ON audit_log
+    FOR EACH STATEMENT
+EXECUTE FUNCTION audit_log_append_only();
\ No newline at end of file
@@ -0,0 +1,36 @@
+-- Endpoints that receive signed event payloads. Empty events and classes match every event and
+-- class, the path prefix matches the o_path of an entity.
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id          UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    url         TEXT        NOT NULL,
+    secret      TEXT        NOT NULL, -- HMAC-SHA256 key of the payload signatures
+    events      TEXT[]      NOT NULL DEFAULT '{}',
+    classes     TEXT[]      NOT NULL DEFAULT '{}',
+    path_prefix TEXT        NOT NULL DEFAULT '',
+    active      BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_by  UUID        NULL REFERENCES users (id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One event for one webhook. Failed attempts stay pending until the next attempt, a delivery that
+-- runs out of attempts is dead and only a manual retry sends it again.
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id               UUID PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    webhook_id       UUID        NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event            TEXT        NOT NULL,
+    payload          JSONB       NOT NULL,
+    status           TEXT        NOT NULL DEFAULT 'pending',
+    attempts         INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_status_code INTEGER     NULL,
+    last_error       TEXT        NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at     TIMESTAMPTZ NULL,
+    CONSTRAINT valid_webhook_delivery_status CHECK (status IN ('pending', 'delivered', 'dead'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
\ No newline at end of file
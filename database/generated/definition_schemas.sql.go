@@ -238,6 +238,44 @@ func (q *Queries) GetDefinitionSchemasWithDifferentHash(ctx context.Context, arg
 	return items, nil
 }
 
+const getTableColumns = `-- name: GetTableColumns :many
+SELECT column_name::text             AS column_name,
+       udt_name::text                AS udt_name,
+       (is_nullable = 'YES')::boolean AS is_nullable
+FROM information_schema.columns
+WHERE table_schema = current_schema()
+  AND table_name = $1::text
+ORDER BY ordinal_position
+`
+
+type GetTableColumnsRow struct {
+	ColumnName string `json:"column_name"`
+	UdtName    string `json:"udt_name"`
+	IsNullable bool   `json:"is_nullable"`
+}
+
+// Get live columns of a table (for drift detection between adapters and database)
+// noinspection SqlResolve
+func (q *Queries) GetTableColumns(ctx context.Context, tableName string) ([]GetTableColumnsRow, error) {
+	rows, err := q.db.Query(ctx, getTableColumns, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetTableColumnsRow{}
+	for rows.Next() {
+		var i GetTableColumnsRow
+		if err := rows.Scan(&i.ColumnName, &i.UdtName, &i.IsNullable); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateDefinitionSchema = `-- name: UpdateDefinitionSchema :one
 UPDATE definition_schemas
 SET name        = $2,
@@ -11,6 +11,117 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const appendEntitySortIndex = `-- name: AppendEntitySortIndex :one
+UPDATE entities
+SET sort_index = (SELECT COALESCE(MAX(s.sort_index) + 1, 0)
+                  FROM entities s
+                  WHERE s.parent_id = entities.parent_id
+                    AND s.id <> entities.id
+                    AND s.deleted_at IS NULL)
+WHERE id = $1
+RETURNING sort_index
+`
+
+// Places an entity after its siblings, like after a move to another parent
+// noinspection SqlResolve
+func (q *Queries) AppendEntitySortIndex(ctx context.Context, id pgtype.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, appendEntitySortIndex, id)
+	var sort_index int32
+	err := row.Scan(&sort_index)
+	return sort_index, err
+}
+
+const claimEntityVersion = `-- name: ClaimEntityVersion :one
+UPDATE entities
+SET version    = version + 1,
+    updated_by = $3,
+    updated_at = NOW()
+WHERE id = $1
+  AND version = $2
+  AND deleted_at IS NULL
+RETURNING id, entity_class, parent_id, o_key, o_path, o_type, published, has_data, created_at, updated_at, created_by, updated_by, version, deleted_at, deleted_by, trash_id, sort_index, tree_path, depth, children_count
+`
+
+type ClaimEntityVersionParams struct {
+	ID        pgtype.UUID `json:"id"`
+	Version   int64       `json:"version"`
+	UpdatedBy pgtype.UUID `json:"updated_by"`
+}
+
+// Advances the version of an entity for a write that does not touch its row or data, like a draft
+// save. It matches no rows when the version moved on.
+// noinspection SqlResolve
+func (q *Queries) ClaimEntityVersion(ctx context.Context, arg ClaimEntityVersionParams) (Entity, error) {
+	row := q.db.QueryRow(ctx, claimEntityVersion, arg.ID, arg.Version, arg.UpdatedBy)
+	var i Entity
+	err := row.Scan(
+		&i.ID,
+		&i.EntityClass,
+		&i.ParentID,
+		&i.OKey,
+		&i.OPath,
+		&i.OType,
+		&i.Published,
+		&i.HasData,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreatedBy,
+		&i.UpdatedBy,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TrashID,
+		&i.SortIndex,
+		&i.TreePath,
+		&i.Depth,
+		&i.ChildrenCount,
+	)
+	return i, err
+}
+
+const countEntityDescendantsByClass = `-- name: CountEntityDescendantsByClass :many
+SELECT entity_class, COUNT(*) AS count
+FROM entities
+WHERE tree_path > $1::text
+  AND tree_path < $1::text || '~'
+  AND deleted_at IS NULL
+  AND ($2::integer IS NULL OR depth <= $2::integer)
+GROUP BY entity_class
+ORDER BY entity_class
+`
+
+type CountEntityDescendantsByClassParams struct {
+	TreePath string      `json:"tree_path"`
+	MaxDepth pgtype.Int4 `json:"max_depth"`
+}
+
+type CountEntityDescendantsByClassRow struct {
+	EntityClass string `json:"entity_class"`
+	Count       int64  `json:"count"`
+}
+
+// Counts the live descendants below a tree path per class, optionally down to a depth
+// noinspection SqlResolve
+func (q *Queries) CountEntityDescendantsByClass(ctx context.Context, arg CountEntityDescendantsByClassParams) ([]CountEntityDescendantsByClassRow, error) {
+	rows, err := q.db.Query(ctx, countEntityDescendantsByClass, arg.TreePath, arg.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountEntityDescendantsByClassRow{}
+	for rows.Next() {
+		var i CountEntityDescendantsByClassRow
+		if err := rows.Scan(&i.EntityClass, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createEntity = `-- name: CreateEntity :one
 INSERT INTO entities (entity_class,
                       parent_id,
@@ -20,9 +131,14 @@ INSERT INTO entities (entity_class,
                       published,
                       created_by,
                       updated_by,
-                      has_data)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-RETURNING id, entity_class, parent_id, o_key, o_path, o_type, published, has_data, created_at, updated_at, created_by, updated_by
+                      has_data,
+                      sort_index)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9,
+        (SELECT COALESCE(MAX(s.sort_index) + 1, 0)
+         FROM entities s
+         WHERE s.parent_id = $2
+           AND s.deleted_at IS NULL))
+RETURNING id, entity_class, parent_id, o_key, o_path, o_type, published, has_data, created_at, updated_at, created_by, updated_by, version, deleted_at, deleted_by, trash_id, sort_index, tree_path, depth, children_count
 `
 
 type CreateEntityParams struct {
@@ -37,6 +153,7 @@ type CreateEntityParams struct {
 	HasData     bool        `json:"has_data"`
 }
 
+// New entities are placed after their siblings
 // noinspection SqlResolve
 func (q *Queries) CreateEntity(ctx context.Context, arg CreateEntityParams) (Entity, error) {
 	row := q.db.QueryRow(ctx, createEntity,
@@ -64,6 +181,14 @@ func (q *Queries) CreateEntity(ctx context.Context, arg CreateEntityParams) (Ent
 		&i.UpdatedAt,
 		&i.CreatedBy,
 		&i.UpdatedBy,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TrashID,
+		&i.SortIndex,
+		&i.TreePath,
+		&i.Depth,
+		&i.ChildrenCount,
 	)
 	return i, err
 }
@@ -93,9 +218,10 @@ func (q *Queries) DeleteEntityByClass(ctx context.Context, entityClass string) e
 }
 
 const getEntityByID = `-- name: GetEntityByID :one
-SELECT id, entity_class, parent_id, o_key, o_path, o_type, published, has_data, created_at, updated_at, created_by, updated_by
+SELECT id, entity_class, parent_id, o_key, o_path, o_type, published, has_data, created_at, updated_at, created_by, updated_by, version, deleted_at, deleted_by, trash_id, sort_index, tree_path, depth, children_count
 FROM entities
 WHERE id = $1
+  AND deleted_at IS NULL
 `
 
 // noinspection SqlResolve
@@ -115,15 +241,24 @@ func (q *Queries) GetEntityByID(ctx context.Context, id pgtype.UUID) (Entity, er
 		&i.UpdatedAt,
 		&i.CreatedBy,
 		&i.UpdatedBy,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TrashID,
+		&i.SortIndex,
+		&i.TreePath,
+		&i.Depth,
+		&i.ChildrenCount,
 	)
 	return i, err
 }
 
 const getEntityByPath = `-- name: GetEntityByPath :one
-SELECT id, entity_class, parent_id, o_key, o_path, o_type, published, has_data, created_at, updated_at, created_by, updated_by
+SELECT id, entity_class, parent_id, o_key, o_path, o_type, published, has_data, created_at, updated_at, created_by, updated_by, version, deleted_at, deleted_by, trash_id, sort_index, tree_path, depth, children_count
 FROM entities
 WHERE o_path = $1
   AND o_key = $2
+  AND deleted_at IS NULL
 `
 
 type GetEntityByPathParams struct {
@@ -148,112 +283,18 @@ func (q *Queries) GetEntityByPath(ctx context.Context, arg GetEntityByPathParams
 		&i.UpdatedAt,
 		&i.CreatedBy,
 		&i.UpdatedBy,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TrashID,
+		&i.SortIndex,
+		&i.TreePath,
+		&i.Depth,
+		&i.ChildrenCount,
 	)
 	return i, err
 }
 
-const getEntityChildren = `-- name: GetEntityChildren :many
-SELECT e.id,
-       e.entity_class,
-       e.parent_id,
-       e.o_key,
-       e.o_path,
-       e.o_type,
-       e.published,
-       e.created_at,
-       e.updated_at,
-       -- Subquery to check if this entity has children
-       EXISTS(SELECT 1
-              FROM entities c
-              WHERE c.parent_id = e.id
-              LIMIT 1)                                            as has_children,
-       -- Count of children (useful for UI)
-       (SELECT COUNT(*) FROM entities c WHERE c.parent_id = e.id) as children_count
-FROM entities e
-WHERE e.parent_id = $1
-ORDER BY
-    -- Folders first, then objects, then variants
-    CASE e.o_type
-        WHEN 'folder' THEN 1
-        WHEN 'object' THEN 2
-        WHEN 'variant' THEN 3
-        END,
-    e.o_key ASC
-LIMIT $2 OFFSET $3
-`
-
-type GetEntityChildrenParams struct {
-	ParentID pgtype.UUID `json:"parent_id"`
-	Limit    int32       `json:"limit"`
-	Offset   int32       `json:"offset"`
-}
-
-type GetEntityChildrenRow struct {
-	ID            pgtype.UUID        `json:"id"`
-	EntityClass   string             `json:"entity_class"`
-	ParentID      pgtype.UUID        `json:"parent_id"`
-	OKey          string             `json:"o_key"`
-	OPath         string             `json:"o_path"`
-	OType         string             `json:"o_type"`
-	Published     bool               `json:"published"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	HasChildren   bool               `json:"has_children"`
-	ChildrenCount int64              `json:"children_count"`
-}
-
-// Get direct children of a parent entity with pagination
-// noinspection SqlResolve
-//
-//	AND ($2::text IS NULL OR e.entity_class = $2) -- TODO - potential improvement - Optional class filter
-//	AND ($3::text IS NULL OR e.o_type = $3)       -- TODO - potential improvement - Optional type filter
-func (q *Queries) GetEntityChildren(ctx context.Context, arg GetEntityChildrenParams) ([]GetEntityChildrenRow, error) {
-	rows, err := q.db.Query(ctx, getEntityChildren, arg.ParentID, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []GetEntityChildrenRow{}
-	for rows.Next() {
-		var i GetEntityChildrenRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.EntityClass,
-			&i.ParentID,
-			&i.OKey,
-			&i.OPath,
-			&i.OType,
-			&i.Published,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.HasChildren,
-			&i.ChildrenCount,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const getEntityChildrenCount = `-- name: GetEntityChildrenCount :one
-SELECT COUNT(*)
-FROM entities e
-WHERE e.parent_id = $1
-`
-
-// Get total count of children (for pagination metadata)
-// noinspection SqlResolve
-func (q *Queries) GetEntityChildrenCount(ctx context.Context, parentID pgtype.UUID) (int64, error) {
-	row := q.db.QueryRow(ctx, getEntityChildrenCount, parentID)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
-}
-
 const getEntityPath = `-- name: GetEntityPath :many
 
 WITH RECURSIVE entity_path AS (
@@ -266,6 +307,7 @@ WITH RECURSIVE entity_path AS (
            0 as depth
     FROM entities
     WHERE entities.id = $1
+      AND entities.deleted_at IS NULL
 
     UNION ALL
 
@@ -325,6 +367,239 @@ func (q *Queries) GetEntityPath(ctx context.Context, id pgtype.UUID) ([]GetEntit
 	return items, nil
 }
 
+const listEntityChildOrder = `-- name: ListEntityChildOrder :many
+SELECT id, sort_index
+FROM entities
+WHERE parent_id = $1
+  AND deleted_at IS NULL
+ORDER BY sort_index, o_key, id
+FOR UPDATE
+`
+
+type ListEntityChildOrderRow struct {
+	ID        pgtype.UUID `json:"id"`
+	SortIndex int32       `json:"sort_index"`
+}
+
+// Locks the live children of a parent in their manual order for a reorder
+// noinspection SqlResolve
+func (q *Queries) ListEntityChildOrder(ctx context.Context, parentID pgtype.UUID) ([]ListEntityChildOrderRow, error) {
+	rows, err := q.db.Query(ctx, listEntityChildOrder, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListEntityChildOrderRow{}
+	for rows.Next() {
+		var i ListEntityChildOrderRow
+		if err := rows.Scan(&i.ID, &i.SortIndex); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEntityDescendants = `-- name: ListEntityDescendants :many
+SELECT id, entity_class, parent_id, o_key, o_path, o_type, published, has_data, created_at, updated_at, created_by, updated_by, version, deleted_at, deleted_by, trash_id, sort_index, tree_path, depth, children_count
+FROM entities
+WHERE tree_path > $1::text
+  AND tree_path < $1::text || '~'
+  AND deleted_at IS NULL
+  AND ($2::integer IS NULL OR depth <= $2::integer)
+  AND ($3::text IS NULL OR entity_class = $3::text)
+  AND ($4::text IS NULL OR tree_path > $4::text)
+ORDER BY tree_path
+LIMIT $5
+`
+
+type ListEntityDescendantsParams struct {
+	TreePath    string      `json:"tree_path"`
+	MaxDepth    pgtype.Int4 `json:"max_depth"`
+	EntityClass pgtype.Text `json:"entity_class"`
+	After       pgtype.Text `json:"after"`
+	RowLimit    int32       `json:"row_limit"`
+}
+
+// Gets a page of the live descendants below a tree path in tree order, optionally down to a depth and of one class.
+// Descendants sort between the tree path and the tree path followed by '~', which is above every character of an id.
+// noinspection SqlResolve
+func (q *Queries) ListEntityDescendants(ctx context.Context, arg ListEntityDescendantsParams) ([]Entity, error) {
+	rows, err := q.db.Query(ctx, listEntityDescendants,
+		arg.TreePath,
+		arg.MaxDepth,
+		arg.EntityClass,
+		arg.After,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entity{}
+	for rows.Next() {
+		var i Entity
+		if err := rows.Scan(
+			&i.ID,
+			&i.EntityClass,
+			&i.ParentID,
+			&i.OKey,
+			&i.OPath,
+			&i.OType,
+			&i.Published,
+			&i.HasData,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CreatedBy,
+			&i.UpdatedBy,
+			&i.Version,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.TrashID,
+			&i.SortIndex,
+			&i.TreePath,
+			&i.Depth,
+			&i.ChildrenCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEntitySubtree = `-- name: ListEntitySubtree :many
+SELECT d.id, d.entity_class, d.parent_id, d.o_key, d.o_path, d.o_type, d.published, d.has_data, d.created_at, d.updated_at, d.created_by, d.updated_by, d.version, d.deleted_at, d.deleted_by, d.trash_id, d.sort_index, d.tree_path, d.depth, d.children_count
+FROM entities e
+         INNER JOIN entities d ON d.tree_path >= e.tree_path AND d.tree_path < e.tree_path || '~'
+WHERE e.id = $1
+  AND e.deleted_at IS NULL
+  AND d.deleted_at IS NULL
+ORDER BY d.o_path, d.o_key
+`
+
+// Gets a live entity and all of its live descendants, parents before their children
+// noinspection SqlResolve
+func (q *Queries) ListEntitySubtree(ctx context.Context, id pgtype.UUID) ([]Entity, error) {
+	rows, err := q.db.Query(ctx, listEntitySubtree, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entity{}
+	for rows.Next() {
+		var i Entity
+		if err := rows.Scan(
+			&i.ID,
+			&i.EntityClass,
+			&i.ParentID,
+			&i.OKey,
+			&i.OPath,
+			&i.OType,
+			&i.Published,
+			&i.HasData,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CreatedBy,
+			&i.UpdatedBy,
+			&i.Version,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.TrashID,
+			&i.SortIndex,
+			&i.TreePath,
+			&i.Depth,
+			&i.ChildrenCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rewriteSubtreePaths = `-- name: RewriteSubtreePaths :execrows
+WITH RECURSIVE subtree AS (SELECT id, o_path
+                           FROM entities
+                           WHERE entities.id = $1
+
+                           UNION ALL
+
+                           SELECT e.id, s.o_path || '/' || e.o_key
+                           FROM entities e
+                                    INNER JOIN subtree s ON e.parent_id = s.id
+                           WHERE e.deleted_at IS NULL)
+UPDATE entities
+SET o_path     = subtree.o_path,
+    version    = version + 1,
+    updated_at = NOW()
+FROM subtree
+WHERE entities.id = subtree.id
+  AND entities.id <> $1
+  AND entities.o_path <> subtree.o_path
+`
+
+// Derives the paths of the live descendants of an entity from its own path after a move or rename
+// noinspection SqlResolve
+func (q *Queries) RewriteSubtreePaths(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, rewriteSubtreePaths, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setEntityUpdatedBy = `-- name: SetEntityUpdatedBy :one
+UPDATE entities
+SET updated_by = $2
+WHERE id = $1
+RETURNING id, entity_class, parent_id, o_key, o_path, o_type, published, has_data, created_at, updated_at, created_by, updated_by, version, deleted_at, deleted_by, trash_id, sort_index, tree_path, depth, children_count
+`
+
+type SetEntityUpdatedByParams struct {
+	ID        pgtype.UUID `json:"id"`
+	UpdatedBy pgtype.UUID `json:"updated_by"`
+}
+
+// Records the user of a data write, the generated data queries only advance the version
+// noinspection SqlResolve
+func (q *Queries) SetEntityUpdatedBy(ctx context.Context, arg SetEntityUpdatedByParams) (Entity, error) {
+	row := q.db.QueryRow(ctx, setEntityUpdatedBy, arg.ID, arg.UpdatedBy)
+	var i Entity
+	err := row.Scan(
+		&i.ID,
+		&i.EntityClass,
+		&i.ParentID,
+		&i.OKey,
+		&i.OPath,
+		&i.OType,
+		&i.Published,
+		&i.HasData,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreatedBy,
+		&i.UpdatedBy,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TrashID,
+		&i.SortIndex,
+		&i.TreePath,
+		&i.Depth,
+		&i.ChildrenCount,
+	)
+	return i, err
+}
+
 const updateEntity = `-- name: UpdateEntity :one
 UPDATE entities
 SET parent_id  = $1,
@@ -333,9 +608,12 @@ SET parent_id  = $1,
     published  = $4,
     updated_by = $5,
     has_data   = $6,
+    version    = version + 1,
     updated_at = NOW()
 WHERE id = $7
-RETURNING id, entity_class, parent_id, o_key, o_path, o_type, published, has_data, created_at, updated_at, created_by, updated_by
+  AND version = $8
+  AND deleted_at IS NULL
+RETURNING id, entity_class, parent_id, o_key, o_path, o_type, published, has_data, created_at, updated_at, created_by, updated_by, version, deleted_at, deleted_by, trash_id, sort_index, tree_path, depth, children_count
 `
 
 type UpdateEntityParams struct {
@@ -346,6 +624,7 @@ type UpdateEntityParams struct {
 	UpdatedBy pgtype.UUID `json:"updated_by"`
 	HasData   bool        `json:"has_data"`
 	ID        pgtype.UUID `json:"id"`
+	Version   int64       `json:"version"`
 }
 
 // noinspection SqlResolve
@@ -358,6 +637,7 @@ func (q *Queries) UpdateEntity(ctx context.Context, arg UpdateEntityParams) (Ent
 		arg.UpdatedBy,
 		arg.HasData,
 		arg.ID,
+		arg.Version,
 	)
 	var i Entity
 	err := row.Scan(
@@ -373,6 +653,36 @@ func (q *Queries) UpdateEntity(ctx context.Context, arg UpdateEntityParams) (Ent
 		&i.UpdatedAt,
 		&i.CreatedBy,
 		&i.UpdatedBy,
+		&i.Version,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.TrashID,
+		&i.SortIndex,
+		&i.TreePath,
+		&i.Depth,
+		&i.ChildrenCount,
 	)
 	return i, err
 }
+
+const updateEntitySortIndexes = `-- name: UpdateEntitySortIndexes :execrows
+UPDATE entities
+SET sort_index = positions.sort_index
+FROM unnest($1::uuid[], $2::integer[]) AS positions(id, sort_index)
+WHERE entities.id = positions.id
+  AND entities.sort_index <> positions.sort_index
+`
+
+type UpdateEntitySortIndexesParams struct {
+	Ids         []pgtype.UUID `json:"ids"`
+	SortIndexes []int32       `json:"sort_indexes"`
+}
+
+// noinspection SqlResolve
+func (q *Queries) UpdateEntitySortIndexes(ctx context.Context, arg UpdateEntitySortIndexesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateEntitySortIndexes, arg.Ids, arg.SortIndexes)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
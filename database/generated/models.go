@@ -8,6 +8,18 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AuditLog struct {
+	ID         pgtype.UUID        `json:"id"`
+	ActorID    pgtype.UUID        `json:"actor_id"`
+	Action     string             `json:"action"`
+	TargetType string             `json:"target_type"`
+	TargetID   string             `json:"target_id"`
+	Changes    []byte             `json:"changes"`
+	RequestID  pgtype.Text        `json:"request_id"`
+	Ip         pgtype.Text        `json:"ip"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
 type DefinitionSchema struct {
 	ID          string             `json:"id"`
 	Name        string             `json:"name"`
@@ -19,18 +31,95 @@ type DefinitionSchema struct {
 }
 
 type Entity struct {
+	ID            pgtype.UUID        `json:"id"`
+	EntityClass   string             `json:"entity_class"`
+	ParentID      pgtype.UUID        `json:"parent_id"`
+	OKey          string             `json:"o_key"`
+	OPath         string             `json:"o_path"`
+	OType         string             `json:"o_type"`
+	Published     bool               `json:"published"`
+	HasData       bool               `json:"has_data"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	CreatedBy     pgtype.UUID        `json:"created_by"`
+	UpdatedBy     pgtype.UUID        `json:"updated_by"`
+	Version       int64              `json:"version"`
+	DeletedAt     pgtype.Timestamptz `json:"deleted_at"`
+	DeletedBy     pgtype.UUID        `json:"deleted_by"`
+	TrashID       pgtype.UUID        `json:"trash_id"`
+	SortIndex     int32              `json:"sort_index"`
+	TreePath      string             `json:"tree_path"`
+	Depth         int32              `json:"depth"`
+	ChildrenCount int32              `json:"children_count"`
+}
+
+type EntityDraft struct {
+	EntityID    pgtype.UUID        `json:"entity_id"`
+	EntityClass string             `json:"entity_class"`
+	Data        []byte             `json:"data"`
+	BaseVersion int64              `json:"base_version"`
+	UpdatedBy   pgtype.UUID        `json:"updated_by"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type EntityFolder struct {
+	EntityID       pgtype.UUID        `json:"entity_id"`
+	Description    pgtype.Text        `json:"description"`
+	Icon           pgtype.Text        `json:"icon"`
+	AllowedClasses []string           `json:"allowed_classes"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+}
+
+type EntityLock struct {
+	EntityID   pgtype.UUID        `json:"entity_id"`
+	UserID     pgtype.UUID        `json:"user_id"`
+	SessionID  pgtype.UUID        `json:"session_id"`
+	AcquiredAt pgtype.Timestamptz `json:"acquired_at"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+}
+
+type EntityVersion struct {
 	ID          pgtype.UUID        `json:"id"`
+	EntityID    pgtype.UUID        `json:"entity_id"`
 	EntityClass string             `json:"entity_class"`
+	Version     int64              `json:"version"`
 	ParentID    pgtype.UUID        `json:"parent_id"`
 	OKey        string             `json:"o_key"`
 	OPath       string             `json:"o_path"`
-	OType       string             `json:"o_type"`
 	Published   bool               `json:"published"`
-	HasData     bool               `json:"has_data"`
+	Data        []byte             `json:"data"`
+	CreatedBy   pgtype.UUID        `json:"created_by"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type ImportProfile struct {
+	ID          pgtype.UUID        `json:"id"`
+	Name        string             `json:"name"`
+	EntityClass string             `json:"entity_class"`
+	Format      string             `json:"format"`
+	Mapping     []byte             `json:"mapping"`
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
-	CreatedBy   pgtype.UUID        `json:"created_by"`
-	UpdatedBy   pgtype.UUID        `json:"updated_by"`
+}
+
+type ImportRun struct {
+	ID            pgtype.UUID        `json:"id"`
+	ProfileID     pgtype.UUID        `json:"profile_id"`
+	EntityClass   string             `json:"entity_class"`
+	DryRun        bool               `json:"dry_run"`
+	Status        string             `json:"status"`
+	TotalRows     int32              `json:"total_rows"`
+	ProcessedRows int32              `json:"processed_rows"`
+	CreatedRows   int32              `json:"created_rows"`
+	UpdatedRows   int32              `json:"updated_rows"`
+	FailedRows    int32              `json:"failed_rows"`
+	RowErrors     []byte             `json:"row_errors"`
+	Error         pgtype.Text        `json:"error"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	StartedAt     pgtype.Timestamptz `json:"started_at"`
+	FinishedAt    pgtype.Timestamptz `json:"finished_at"`
 }
 
 type OauthIdentity struct {
@@ -44,6 +133,18 @@ type OauthIdentity struct {
 	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
 }
 
+type PublishSchedule struct {
+	ID         pgtype.UUID        `json:"id"`
+	EntityID   pgtype.UUID        `json:"entity_id"`
+	Action     string             `json:"action"`
+	RunAt      pgtype.Timestamptz `json:"run_at"`
+	Status     string             `json:"status"`
+	Error      pgtype.Text        `json:"error"`
+	CreatedBy  pgtype.UUID        `json:"created_by"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	ExecutedAt pgtype.Timestamptz `json:"executed_at"`
+}
+
 type Session struct {
 	ID             pgtype.UUID        `json:"id"`
 	UserIdentityID pgtype.UUID        `json:"user_identity_id"`
@@ -54,6 +155,18 @@ type Session struct {
 	LastActivityAt pgtype.Timestamptz `json:"last_activity_at"`
 }
 
+type TrashItem struct {
+	ID          pgtype.UUID        `json:"id"`
+	EntityID    pgtype.UUID        `json:"entity_id"`
+	EntityClass string             `json:"entity_class"`
+	OKey        string             `json:"o_key"`
+	OPath       string             `json:"o_path"`
+	ParentID    pgtype.UUID        `json:"parent_id"`
+	EntityCount int32              `json:"entity_count"`
+	DeletedBy   pgtype.UUID        `json:"deleted_by"`
+	DeletedAt   pgtype.Timestamptz `json:"deleted_at"`
+}
+
 type User struct {
 	ID        pgtype.UUID        `json:"id"`
 	Email     string             `json:"email"`
@@ -61,4 +174,32 @@ type User struct {
 	FullName  pgtype.Text        `json:"full_name"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	IsAdmin   bool               `json:"is_admin"`
+}
+
+type Webhook struct {
+	ID         pgtype.UUID        `json:"id"`
+	Url        string             `json:"url"`
+	Secret     string             `json:"secret"`
+	Events     []string           `json:"events"`
+	Classes    []string           `json:"classes"`
+	PathPrefix string             `json:"path_prefix"`
+	Active     bool               `json:"active"`
+	CreatedBy  pgtype.UUID        `json:"created_by"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             pgtype.UUID        `json:"id"`
+	WebhookID      pgtype.UUID        `json:"webhook_id"`
+	Event          string             `json:"event"`
+	Payload        []byte             `json:"payload"`
+	Status         string             `json:"status"`
+	Attempts       int32              `json:"attempts"`
+	NextAttemptAt  pgtype.Timestamptz `json:"next_attempt_at"`
+	LastStatusCode pgtype.Int4        `json:"last_status_code"`
+	LastError      pgtype.Text        `json:"last_error"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	DeliveredAt    pgtype.Timestamptz `json:"delivered_at"`
 }
@@ -51,7 +51,7 @@ func (q *Queries) CreateOAuthIdentity(ctx context.Context, arg CreateOAuthIdenti
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (email, password)
 VALUES ($1, hash_password($2))
-RETURNING id, email, password, full_name, created_at, updated_at
+RETURNING id, email, password, full_name, created_at, updated_at, is_admin
 `
 
 type CreateUserParams struct {
@@ -70,6 +70,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.FullName,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsAdmin,
 	)
 	return i, err
 }
@@ -104,7 +105,7 @@ func (q *Queries) GetOAuthIdentityByProviderAndToken(ctx context.Context, arg Ge
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password, full_name, created_at, updated_at
+SELECT id, email, password, full_name, created_at, updated_at, is_admin
 FROM users
 WHERE email = $1
 `
@@ -120,12 +121,13 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.FullName,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsAdmin,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, password, full_name, created_at, updated_at
+SELECT id, email, password, full_name, created_at, updated_at, is_admin
 FROM users
 WHERE id = $1
 `
@@ -141,10 +143,29 @@ func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (User, error)
 		&i.FullName,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsAdmin,
 	)
 	return i, err
 }
 
+const setUserAdmin = `-- name: SetUserAdmin :exec
+UPDATE users
+SET is_admin   = $2,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserAdminParams struct {
+	ID      pgtype.UUID `json:"id"`
+	IsAdmin bool        `json:"is_admin"`
+}
+
+// noinspection SqlResolve
+func (q *Queries) SetUserAdmin(ctx context.Context, arg SetUserAdminParams) error {
+	_, err := q.db.Exec(ctx, setUserAdmin, arg.ID, arg.IsAdmin)
+	return err
+}
+
 const verifyPassword = `-- name: VerifyPassword :one
 SELECT verify_password($1, password)
 FROM users
@@ -0,0 +1,32 @@
+-- name: CreateAuditLogEntry :exec
+-- Appends an entry to the audit trail
+-- noinspection SqlResolve
+INSERT INTO audit_log (actor_id, action, target_type, target_id, changes, request_id, ip)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListAuditLog :many
+-- Gets a page of the audit trail newest first with the email of the actor, every filter is optional.
+-- The page continues below the created_at and id of the last entry of the previous page.
+-- noinspection SqlResolve
+SELECT a.id,
+       a.actor_id,
+       u.email AS actor_email,
+       a.action,
+       a.target_type,
+       a.target_id,
+       a.changes,
+       a.request_id,
+       a.ip,
+       a.created_at
+FROM audit_log a
+         LEFT JOIN users u ON u.id = a.actor_id
+WHERE (sqlc.narg(actor_id)::uuid IS NULL OR a.actor_id = sqlc.narg(actor_id)::uuid)
+  AND (sqlc.narg(action)::text IS NULL OR a.action = sqlc.narg(action)::text)
+  AND (sqlc.narg(target_type)::text IS NULL OR a.target_type = sqlc.narg(target_type)::text)
+  AND (sqlc.narg(target_id)::text IS NULL OR a.target_id = sqlc.narg(target_id)::text)
+  AND (sqlc.narg(since)::timestamptz IS NULL OR a.created_at >= sqlc.narg(since)::timestamptz)
+  AND (sqlc.narg(until)::timestamptz IS NULL OR a.created_at < sqlc.narg(until)::timestamptz)
+  AND (sqlc.narg(before_created_at)::timestamptz IS NULL OR
+       (a.created_at, a.id) < (sqlc.narg(before_created_at)::timestamptz, sqlc.narg(before_id)::uuid))
+ORDER BY a.created_at DESC, a.id DESC
+LIMIT sqlc.arg(row_limit);
\ No newline at end of file
@@ -63,4 +63,15 @@ WHERE id = $1;
 SELECT id, name, schema_hash
 FROM definition_schemas
 WHERE id = ANY ($1::text[])
-  AND schema_hash != ANY ($2::text[]);
\ No newline at end of file
+  AND schema_hash != ANY ($2::text[]);
+
+-- name: GetTableColumns :many
+-- Get live columns of a table (for drift detection between adapters and database)
+-- noinspection SqlResolve
+SELECT column_name::text             AS column_name,
+       udt_name::text                AS udt_name,
+       (is_nullable = 'YES')::boolean AS is_nullable
+FROM information_schema.columns
+WHERE table_schema = current_schema()
+  AND table_name = sqlc.arg(table_name)::text
+ORDER BY ordinal_position;
@@ -1,4 +1,5 @@
 -- name: CreateEntity :one
+-- New entities are placed after their siblings
 -- noinspection SqlResolve
 INSERT INTO entities (entity_class,
                       parent_id,
@@ -8,8 +9,13 @@ INSERT INTO entities (entity_class,
                       published,
                       created_by,
                       updated_by,
-                      has_data)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+                      has_data,
+                      sort_index)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9,
+        (SELECT COALESCE(MAX(s.sort_index) + 1, 0)
+         FROM entities s
+         WHERE s.parent_id = $2
+           AND s.deleted_at IS NULL))
 RETURNING *;
 
 -- name: UpdateEntity :one
@@ -21,8 +27,11 @@ SET parent_id  = $1,
     published  = $4,
     updated_by = $5,
     has_data   = $6,
+    version    = version + 1,
     updated_at = NOW()
 WHERE id = $7
+  AND version = $8
+  AND deleted_at IS NULL
 RETURNING *;
 
 -- name: DeleteEntity :exec
@@ -41,56 +50,16 @@ WHERE entity_class = $1;
 -- noinspection SqlResolve
 SELECT *
 FROM entities
-WHERE id = $1;
+WHERE id = $1
+  AND deleted_at IS NULL;
 
 -- name: GetEntityByPath :one
 -- noinspection SqlResolve
 SELECT *
 FROM entities
 WHERE o_path = $1
-  AND o_key = $2;
-
--- name: GetEntityChildren :many
--- Get direct children of a parent entity with pagination
--- noinspection SqlResolve
-SELECT e.id,
-       e.entity_class,
-       e.parent_id,
-       e.o_key,
-       e.o_path,
-       e.o_type,
-       e.published,
-       e.created_at,
-       e.updated_at,
-       -- Subquery to check if this entity has children
-       EXISTS(SELECT 1
-              FROM entities c
-              WHERE c.parent_id = e.id
-              LIMIT 1)                                            as has_children,
-       -- Count of children (useful for UI)
-       (SELECT COUNT(*) FROM entities c WHERE c.parent_id = e.id) as children_count
-FROM entities e
-WHERE e.parent_id = $1
---   AND ($2::text IS NULL OR e.entity_class = $2) -- TODO - potential improvement - Optional class filter
---   AND ($3::text IS NULL OR e.o_type = $3)       -- TODO - potential improvement - Optional type filter
-ORDER BY
-    -- Folders first, then objects, then variants
-    CASE e.o_type
-        WHEN 'folder' THEN 1
-        WHEN 'object' THEN 2
-        WHEN 'variant' THEN 3
-        END,
-    e.o_key ASC
-LIMIT $2 OFFSET $3;
-
--- name: GetEntityChildrenCount :one
--- Get total count of children (for pagination metadata)
--- noinspection SqlResolve
-SELECT COUNT(*)
-FROM entities e
-WHERE e.parent_id = $1;
---   AND ($2::text IS NULL OR e.entity_class = $2) - todo
---   AND ($3::text IS NULL OR e.o_type = $3); - todo
+  AND o_key = $2
+  AND deleted_at IS NULL;
 
 -- name: GetEntityPath :many
 -- Get all ancestors from root to this entity (for breadcrumb)
@@ -106,6 +75,7 @@ WITH RECURSIVE entity_path AS (
            0 as depth
     FROM entities
     WHERE entities.id = $1
+      AND entities.deleted_at IS NULL
 
     UNION ALL
 
@@ -120,4 +90,115 @@ WITH RECURSIVE entity_path AS (
              INNER JOIN entity_path ep ON e.id = ep.parent_id)
 SELECT id, parent_id, o_key, o_path, o_type, depth
 FROM entity_path
-ORDER BY depth DESC; -- Root first, target last
\ No newline at end of file
+ORDER BY depth DESC; -- Root first, target last
+
+-- name: ListEntitySubtree :many
+-- Gets a live entity and all of its live descendants, parents before their children
+-- noinspection SqlResolve
+SELECT d.*
+FROM entities e
+         INNER JOIN entities d ON d.tree_path >= e.tree_path AND d.tree_path < e.tree_path || '~'
+WHERE e.id = $1
+  AND e.deleted_at IS NULL
+  AND d.deleted_at IS NULL
+ORDER BY d.o_path, d.o_key;
+
+-- name: RewriteSubtreePaths :execrows
+-- Derives the paths of the live descendants of an entity from its own path after a move or rename
+-- noinspection SqlResolve
+WITH RECURSIVE subtree AS (SELECT id, o_path
+                           FROM entities
+                           WHERE entities.id = $1
+
+                           UNION ALL
+
+                           SELECT e.id, s.o_path || '/' || e.o_key
+                           FROM entities e
+                                    INNER JOIN subtree s ON e.parent_id = s.id
+                           WHERE e.deleted_at IS NULL)
+UPDATE entities
+SET o_path     = subtree.o_path,
+    version    = version + 1,
+    updated_at = NOW()
+FROM subtree
+WHERE entities.id = subtree.id
+  AND entities.id <> $1
+  AND entities.o_path <> subtree.o_path;
+
+-- name: ListEntityDescendants :many
+-- Gets a page of the live descendants below a tree path in tree order, optionally down to a depth and of one class.
+-- Descendants sort between the tree path and the tree path followed by '~', which is above every character of an id.
+-- noinspection SqlResolve
+SELECT *
+FROM entities
+WHERE tree_path > sqlc.arg(tree_path)::text
+  AND tree_path < sqlc.arg(tree_path)::text || '~'
+  AND deleted_at IS NULL
+  AND (sqlc.narg(max_depth)::integer IS NULL OR depth <= sqlc.narg(max_depth)::integer)
+  AND (sqlc.narg(entity_class)::text IS NULL OR entity_class = sqlc.narg(entity_class)::text)
+  AND (sqlc.narg(after)::text IS NULL OR tree_path > sqlc.narg(after)::text)
+ORDER BY tree_path
+LIMIT sqlc.arg(row_limit);
+
+-- name: CountEntityDescendantsByClass :many
+-- Counts the live descendants below a tree path per class, optionally down to a depth
+-- noinspection SqlResolve
+SELECT entity_class, COUNT(*) AS count
+FROM entities
+WHERE tree_path > sqlc.arg(tree_path)::text
+  AND tree_path < sqlc.arg(tree_path)::text || '~'
+  AND deleted_at IS NULL
+  AND (sqlc.narg(max_depth)::integer IS NULL OR depth <= sqlc.narg(max_depth)::integer)
+GROUP BY entity_class
+ORDER BY entity_class;
+
+-- name: ListEntityChildOrder :many
+-- Locks the live children of a parent in their manual order for a reorder
+-- noinspection SqlResolve
+SELECT id, sort_index
+FROM entities
+WHERE parent_id = $1
+  AND deleted_at IS NULL
+ORDER BY sort_index, o_key, id
+FOR UPDATE;
+
+-- name: UpdateEntitySortIndexes :execrows
+-- noinspection SqlResolve
+UPDATE entities
+SET sort_index = positions.sort_index
+FROM unnest(sqlc.arg(ids)::uuid[], sqlc.arg(sort_indexes)::integer[]) AS positions(id, sort_index)
+WHERE entities.id = positions.id
+  AND entities.sort_index <> positions.sort_index;
+
+-- name: AppendEntitySortIndex :one
+-- Places an entity after its siblings, like after a move to another parent
+-- noinspection SqlResolve
+UPDATE entities
+SET sort_index = (SELECT COALESCE(MAX(s.sort_index) + 1, 0)
+                  FROM entities s
+                  WHERE s.parent_id = entities.parent_id
+                    AND s.id <> entities.id
+                    AND s.deleted_at IS NULL)
+WHERE id = $1
+RETURNING sort_index;
+
+-- name: ClaimEntityVersion :one
+-- Advances the version of an entity for a write that does not touch its row or data, like a draft
+-- save. It matches no rows when the version moved on.
+-- noinspection SqlResolve
+UPDATE entities
+SET version    = version + 1,
+    updated_by = $3,
+    updated_at = NOW()
+WHERE id = $1
+  AND version = $2
+  AND deleted_at IS NULL
+RETURNING *;
+
+-- name: SetEntityUpdatedBy :one
+-- Records the user of a data write, the generated data queries only advance the version
+-- noinspection SqlResolve
+UPDATE entities
+SET updated_by = $2
+WHERE id = $1
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,24 @@
+-- name: UpsertEntityFolder :one
+-- noinspection SqlResolve
+INSERT INTO entity_folders (entity_id, description, icon, allowed_classes)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (entity_id) DO UPDATE
+    SET description     = EXCLUDED.description,
+        icon            = EXCLUDED.icon,
+        allowed_classes = EXCLUDED.allowed_classes,
+        updated_at      = NOW()
+RETURNING *;
+
+-- name: GetEntityFolder :one
+-- noinspection SqlResolve
+SELECT *
+FROM entity_folders
+WHERE entity_id = $1;
+
+-- name: CopyEntityFolder :exec
+-- Gives a copy of a folder the metadata of the source folder
+-- noinspection SqlResolve
+INSERT INTO entity_folders (entity_id, description, icon, allowed_classes)
+SELECT sqlc.arg(entity_id)::uuid, description, icon, allowed_classes
+FROM entity_folders
+WHERE entity_folders.entity_id = sqlc.arg(source_id);
\ No newline at end of file
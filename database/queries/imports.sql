@@ -0,0 +1,83 @@
+-- name: CreateImportProfile :one
+-- noinspection SqlResolve
+INSERT INTO import_profiles (name, entity_class, format, mapping)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetImportProfile :one
+-- noinspection SqlResolve
+SELECT *
+FROM import_profiles
+WHERE id = $1;
+
+-- name: ListImportProfiles :many
+-- noinspection SqlResolve
+SELECT *
+FROM import_profiles
+ORDER BY name ASC;
+
+-- name: UpdateImportProfile :one
+-- noinspection SqlResolve
+UPDATE import_profiles
+SET name         = $2,
+    entity_class = $3,
+    format       = $4,
+    mapping      = $5,
+    updated_at   = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteImportProfile :exec
+-- noinspection SqlResolve
+DELETE
+FROM import_profiles
+WHERE id = $1;
+
+-- name: CreateImportRun :one
+-- noinspection SqlResolve
+INSERT INTO import_runs (profile_id, entity_class, dry_run)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetImportRun :one
+-- noinspection SqlResolve
+SELECT *
+FROM import_runs
+WHERE id = $1;
+
+-- name: ListImportRuns :many
+-- Most recent runs first, optionally restricted to one profile
+-- noinspection SqlResolve
+SELECT *
+FROM import_runs
+WHERE sqlc.narg(profile_id)::uuid IS NULL
+   OR profile_id = sqlc.narg(profile_id)::uuid
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: StartImportRun :exec
+-- noinspection SqlResolve
+UPDATE import_runs
+SET status     = 'running',
+    started_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateImportRunProgress :exec
+-- noinspection SqlResolve
+UPDATE import_runs
+SET total_rows     = $2,
+    processed_rows = $3,
+    created_rows   = $4,
+    updated_rows   = $5,
+    failed_rows    = $6,
+    row_errors     = $7
+WHERE id = $1;
+
+-- name: FinishImportRun :one
+-- noinspection SqlResolve
+UPDATE import_runs
+SET status      = $2,
+    error       = $3,
+    finished_at = NOW()
+WHERE id = $1
+RETURNING *;
\ No newline at end of file
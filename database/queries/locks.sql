@@ -0,0 +1,58 @@
+-- name: AcquireEntityLock :one
+-- Takes the lock of an entity when it is free, expired or already held by the same user
+-- noinspection SqlResolve
+INSERT INTO entity_locks (entity_id, user_id, session_id, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (entity_id) DO UPDATE
+    SET user_id     = EXCLUDED.user_id,
+        session_id  = EXCLUDED.session_id,
+        acquired_at = CASE
+                          WHEN entity_locks.session_id = EXCLUDED.session_id THEN entity_locks.acquired_at
+                          ELSE NOW() END,
+        expires_at  = EXCLUDED.expires_at
+WHERE entity_locks.expires_at <= NOW()
+   OR entity_locks.user_id = EXCLUDED.user_id
+RETURNING *;
+
+-- name: RenewEntityLock :one
+-- Extends the lock of an entity for the session that holds it
+-- noinspection SqlResolve
+UPDATE entity_locks
+SET expires_at = $3
+WHERE entity_id = $1
+  AND session_id = $2
+RETURNING *;
+
+-- name: ReleaseEntityLock :execrows
+-- Releases the lock of an entity held by the user, any session of the user may release it
+-- noinspection SqlResolve
+DELETE
+FROM entity_locks
+WHERE entity_id = $1
+  AND user_id = $2;
+
+-- name: BreakEntityLock :execrows
+-- noinspection SqlResolve
+DELETE
+FROM entity_locks
+WHERE entity_id = $1;
+
+-- name: GetEntityLock :one
+-- Gets the lock of an entity with its holder while it has not expired, the session stays private
+-- noinspection SqlResolve
+SELECT l.entity_id,
+       l.user_id,
+       l.acquired_at,
+       l.expires_at,
+       u.email     AS user_email,
+       u.full_name AS user_full_name
+FROM entity_locks l
+         INNER JOIN users u ON u.id = l.user_id
+WHERE l.entity_id = $1
+  AND l.expires_at > NOW();
+
+-- name: DeleteExpiredEntityLocks :execrows
+-- noinspection SqlResolve
+DELETE
+FROM entity_locks
+WHERE expires_at <= NOW();
\ No newline at end of file
@@ -0,0 +1,62 @@
+-- name: UpsertEntityDraft :one
+-- noinspection SqlResolve
+INSERT INTO entity_drafts (entity_id, entity_class, data, base_version, updated_by)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (entity_id) DO UPDATE
+    SET data         = EXCLUDED.data,
+        base_version = EXCLUDED.base_version,
+        updated_by   = EXCLUDED.updated_by,
+        updated_at   = NOW()
+RETURNING *;
+
+-- name: GetEntityDraft :one
+-- noinspection SqlResolve
+SELECT *
+FROM entity_drafts
+WHERE entity_id = $1;
+
+-- name: DeleteEntityDraft :exec
+-- noinspection SqlResolve
+DELETE
+FROM entity_drafts
+WHERE entity_id = $1;
+
+-- name: CreatePublishSchedule :one
+-- noinspection SqlResolve
+INSERT INTO publish_schedules (entity_id, action, run_at, created_by)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListPublishSchedules :many
+-- noinspection SqlResolve
+SELECT *
+FROM publish_schedules
+WHERE entity_id = $1
+ORDER BY run_at, id;
+
+-- name: CancelPublishSchedule :one
+-- noinspection SqlResolve
+UPDATE publish_schedules
+SET status = 'cancelled'
+WHERE id = $1
+  AND entity_id = $2
+  AND status = 'pending'
+RETURNING *;
+
+-- name: ClaimDuePublishSchedule :one
+-- Locks the oldest due schedule, workers skip schedules another worker holds
+-- noinspection SqlResolve
+SELECT *
+FROM publish_schedules
+WHERE status = 'pending'
+  AND run_at <= $1
+ORDER BY run_at, id
+LIMIT 1 FOR UPDATE SKIP LOCKED;
+
+-- name: FinishPublishSchedule :exec
+-- noinspection SqlResolve
+UPDATE publish_schedules
+SET status      = $2,
+    error       = $3,
+    executed_at = NOW()
+WHERE id = $1;
\ No newline at end of file
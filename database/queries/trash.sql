@@ -0,0 +1,99 @@
+-- name: CreateTrashItem :one
+-- noinspection SqlResolve
+INSERT INTO trash_items (entity_id,
+                         entity_class,
+                         o_key,
+                         o_path,
+                         parent_id,
+                         deleted_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: TrashEntityTree :execrows
+-- Moves a live entity and its live descendants into a trash item
+-- noinspection SqlResolve
+WITH RECURSIVE subtree AS (SELECT id
+                           FROM entities
+                           WHERE entities.id = sqlc.arg(entity_id)
+                             AND entities.deleted_at IS NULL
+
+                           UNION ALL
+
+                           SELECT e.id
+                           FROM entities e
+                                    INNER JOIN subtree s ON e.parent_id = s.id
+                           WHERE e.deleted_at IS NULL)
+UPDATE entities
+SET deleted_at = NOW(),
+    deleted_by = sqlc.narg(deleted_by),
+    trash_id   = sqlc.arg(trash_id),
+    version    = version + 1
+WHERE id IN (SELECT id FROM subtree);
+
+-- name: SetTrashItemCount :one
+-- noinspection SqlResolve
+UPDATE trash_items
+SET entity_count = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: GetTrashItem :one
+-- noinspection SqlResolve
+SELECT *
+FROM trash_items
+WHERE id = $1;
+
+-- name: ListTrashItems :many
+-- Newest deletes first, optionally restricted to a class or a key and path search
+-- noinspection SqlResolve
+SELECT *
+FROM trash_items
+WHERE (sqlc.narg(entity_class)::text IS NULL OR entity_class = sqlc.narg(entity_class)::text)
+  AND (sqlc.narg(search)::text IS NULL OR o_key ILIKE sqlc.narg(search)::text OR o_path ILIKE sqlc.narg(search)::text)
+ORDER BY deleted_at DESC, id
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountTrashItems :one
+-- noinspection SqlResolve
+SELECT COUNT(*)
+FROM trash_items
+WHERE (sqlc.narg(entity_class)::text IS NULL OR entity_class = sqlc.narg(entity_class)::text)
+  AND (sqlc.narg(search)::text IS NULL OR o_key ILIKE sqlc.narg(search)::text OR o_path ILIKE sqlc.narg(search)::text);
+
+-- name: ListExpiredTrashItems :many
+-- Oldest first, for the retention purge. A page continues after the deleted_at and id of the last
+-- item of the previous page, so an item the purge failed on is not listed again.
+-- noinspection SqlResolve
+SELECT *
+FROM trash_items
+WHERE deleted_at < sqlc.arg(deleted_before)
+  AND (sqlc.narg(after_deleted_at)::timestamptz IS NULL OR
+       (deleted_at, id) > (sqlc.narg(after_deleted_at)::timestamptz, sqlc.narg(after_id)::uuid))
+ORDER BY deleted_at, id
+LIMIT sqlc.arg(row_limit);
+
+-- name: RelocateTrashedTree :execrows
+-- Rewrites the path prefix of a trashed subtree and sets the parent of its root before a restore
+-- to another parent
+-- noinspection SqlResolve
+UPDATE entities
+SET o_path    = sqlc.arg(new_path)::text || substr(o_path, length(sqlc.arg(old_path)::text) + 1),
+    parent_id = CASE WHEN id = sqlc.arg(root_id) THEN sqlc.narg(parent_id)::uuid ELSE parent_id END
+WHERE trash_id = sqlc.arg(trash_id)
+  AND starts_with(o_path, sqlc.arg(old_path)::text);
+
+-- name: RestoreTrashItem :execrows
+-- noinspection SqlResolve
+UPDATE entities
+SET deleted_at = NULL,
+    deleted_by = NULL,
+    trash_id   = NULL,
+    version    = version + 1,
+    updated_at = NOW()
+WHERE trash_id = $1;
+
+-- name: DeleteTrashItem :exec
+-- noinspection SqlResolve
+DELETE
+FROM trash_items
+WHERE id = $1;
\ No newline at end of file
@@ -33,4 +33,11 @@ WHERE id = $1;
 SELECT *
 FROM oauth_identities
 WHERE provider = $1
-  AND id_token = $2;
\ No newline at end of file
+  AND id_token = $2;
+
+-- name: SetUserAdmin :exec
+-- noinspection SqlResolve
+UPDATE users
+SET is_admin   = $2,
+    updated_at = NOW()
+WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,49 @@
+-- name: CreateEntityVersion :one
+-- noinspection SqlResolve
+INSERT INTO entity_versions (entity_id,
+                             entity_class,
+                             version,
+                             parent_id,
+                             o_key,
+                             o_path,
+                             published,
+                             data,
+                             created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetEntityVersion :one
+-- noinspection SqlResolve
+SELECT *
+FROM entity_versions
+WHERE entity_id = $1
+  AND version = $2;
+
+-- name: ListEntityVersions :many
+-- Newest versions first
+-- noinspection SqlResolve
+SELECT *
+FROM entity_versions
+WHERE entity_id = $1
+ORDER BY version DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountEntityVersions :one
+-- noinspection SqlResolve
+SELECT COUNT(*)
+FROM entity_versions
+WHERE entity_id = $1;
+
+-- name: PruneEntityVersions :execrows
+-- Deletes the versions of an entity that are neither among the newest keep_last nor created after
+-- keep_after
+-- noinspection SqlResolve
+DELETE
+FROM entity_versions
+WHERE entity_id = sqlc.arg(entity_id)
+  AND created_at < sqlc.arg(keep_after)
+  AND id NOT IN (SELECT v.id
+                 FROM entity_versions v
+                 WHERE v.entity_id = sqlc.arg(entity_id)
+                 ORDER BY v.version DESC
+                 LIMIT sqlc.arg(keep_last));
\ No newline at end of file
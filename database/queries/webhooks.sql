@@ -0,0 +1,105 @@
+-- name: CreateWebhook :one
+-- noinspection SqlResolve
+INSERT INTO webhooks (url, secret, events, classes, path_prefix, active, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetWebhook :one
+-- noinspection SqlResolve
+SELECT *
+FROM webhooks
+WHERE id = $1;
+
+-- name: ListWebhooks :many
+-- noinspection SqlResolve
+SELECT *
+FROM webhooks
+ORDER BY created_at, id;
+
+-- name: ListActiveWebhooks :many
+-- noinspection SqlResolve
+SELECT *
+FROM webhooks
+WHERE active
+ORDER BY created_at, id;
+
+-- name: UpdateWebhook :one
+-- noinspection SqlResolve
+UPDATE webhooks
+SET url         = $2,
+    secret      = $3,
+    events      = $4,
+    classes     = $5,
+    path_prefix = $6,
+    active      = $7,
+    updated_at  = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteWebhook :execrows
+-- noinspection SqlResolve
+DELETE
+FROM webhooks
+WHERE id = $1;
+
+-- name: CreateWebhookDelivery :exec
+-- noinspection SqlResolve
+INSERT INTO webhook_deliveries (webhook_id, event, payload)
+VALUES ($1, $2, $3);
+
+-- name: ClaimDueWebhookDelivery :one
+-- Locks the oldest due delivery of an active webhook with the endpoint to send it to, workers skip
+-- deliveries another worker holds
+-- noinspection SqlResolve
+SELECT d.id,
+       d.webhook_id,
+       d.event,
+       d.payload,
+       d.attempts,
+       w.url,
+       w.secret
+FROM webhook_deliveries d
+         INNER JOIN webhooks w ON w.id = d.webhook_id
+WHERE d.status = 'pending'
+  AND d.next_attempt_at <= $1
+  AND w.active
+ORDER BY d.next_attempt_at, d.id
+LIMIT 1 FOR UPDATE OF d SKIP LOCKED;
+
+-- name: FinishWebhookDeliveryAttempt :exec
+-- Records the outcome of an attempt, the status and next attempt follow from the retry policy
+-- noinspection SqlResolve
+UPDATE webhook_deliveries
+SET status           = $2,
+    attempts         = attempts + 1,
+    next_attempt_at  = $3,
+    last_status_code = $4,
+    last_error       = $5,
+    delivered_at     = $6
+WHERE id = $1;
+
+-- name: ListWebhookDeliveries :many
+-- Gets a page of the delivery log of a webhook newest first, the status filter is optional. The page
+-- continues below the created_at and id of the last delivery of the previous page.
+-- noinspection SqlResolve
+SELECT *
+FROM webhook_deliveries
+WHERE webhook_id = sqlc.arg(webhook_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+  AND (sqlc.narg(before_created_at)::timestamptz IS NULL OR
+       (created_at, id) < (sqlc.narg(before_created_at)::timestamptz, sqlc.narg(before_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: RetryWebhookDelivery :one
+-- Sends a delivered or dead delivery again with a fresh set of attempts
+-- noinspection SqlResolve
+UPDATE webhook_deliveries
+SET status          = 'pending',
+    attempts        = 0,
+    next_attempt_at = NOW(),
+    last_error      = NULL
+WHERE id = $1
+  AND webhook_id = $2
+  AND status <> 'pending'
+RETURNING *;
\ No newline at end of file